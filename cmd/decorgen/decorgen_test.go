@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGolden(t *testing.T) {
+	dir := filepath.Join("..", "..", "examples", "decorator", "users")
+	const output = "userrepository_decorator.go"
+
+	it, err := load(dir, "UserRepository", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code differs from %s; run go generate in %s\n%s", output, dir, got)
+	}
+}
+
+func TestGenerateImportNames(t *testing.T) {
+	it, err := load(filepath.Join("testdata", "renamed"), "Sampler", "sampler_decorator.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"math/rand/v2"`,
+		`tmpl "text/template"`,
+		`Source(ctx context.Context) (*rand.Rand, error)`,
+		`Render(t *tmpl.Template) string`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code lacks %s:\n%s", want, src)
+		}
+	}
+}
+
+func TestContextByType(t *testing.T) {
+	it, err := load(filepath.Join("testdata", "ctxnames"), "Jobs", "jobs_decorator.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"Run": true, "Aliased": true, "Lookalike": false}
+	for _, m := range it.methods {
+		if m.hasCtx != want[m.name] {
+			t.Errorf("%s: hasCtx = %v, want %v", m.name, m.hasCtx, want[m.name])
+		}
+		if !m.hasError {
+			t.Errorf("%s: trailing error not recognised", m.name)
+		}
+	}
+
+	src, err := generate(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`stdctx "context"`,
+		`decorator.Invoke(ctx, d.Interceptor`,
+		`decorator.Invoke(context.Background(), d.Interceptor`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code lacks %s:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"slices"
+	"strings"
+)
+
+const decoratorImport = `"github.com/thanhnamdk2710/go-handbook/pkg/decorator"`
+
+func generate(it *iface) ([]byte, error) {
+	var b bytes.Buffer
+	w := func(format string, args ...any) {
+		fmt.Fprintf(&b, format, args...)
+	}
+
+	std := []string{`"context"`}
+	other := []string{decoratorImport}
+	for name, spec := range it.imports {
+		switch {
+		case name == "context":
+		case isStdlib(spec):
+			std = append(std, spec)
+		default:
+			other = append(other, spec)
+		}
+	}
+	slices.Sort(std)
+	slices.Sort(other)
+
+	w("// Code generated by decorgen. DO NOT EDIT.\n\n")
+	w("package %s\n\n", it.pkg)
+	w("import (\n%s\n\n%s\n)\n\n", strings.Join(std, "\n"), strings.Join(other, "\n"))
+
+	typ := it.name + "Decorator"
+	w("// %s wraps a %s, passing every call through Interceptor.\n", typ, it.name)
+	w("// Embed it to override individual methods.\n")
+	w("type %s struct {\n\tNext %s\n\tInterceptor decorator.Interceptor\n}\n\n", typ, it.name)
+
+	w("// New%s wraps next with interceptors, outermost first.\n", typ)
+	w("func New%s(next %s, interceptors ...decorator.Interceptor) *%s {\n", typ, it.name, typ)
+	w("\treturn &%s{Next: next, Interceptor: decorator.Chain(interceptors...)}\n}\n\n", typ)
+	w("var _ %s = (*%s)(nil)\n", it.name, typ)
+
+	for _, m := range it.methods {
+		w("\n")
+		writeMethod(&b, typ, m)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, b.Bytes())
+	}
+	return src, nil
+}
+
+func writeMethod(b *bytes.Buffer, typ string, m method) {
+	w := func(format string, args ...any) {
+		fmt.Fprintf(b, format, args...)
+	}
+
+	var params, callArgs, invArgs []string
+	for i, p := range m.params {
+		params = append(params, p.name+" "+p.typ)
+		arg := p.name
+		if m.variadic && i == len(m.params)-1 {
+			arg += "..."
+		}
+		callArgs = append(callArgs, arg)
+		if !(i == 0 && m.hasCtx) {
+			invArgs = append(invArgs, p.name)
+		}
+	}
+
+	var results, resultVars, resultPtrs []string
+	for i, r := range m.results {
+		name := fmt.Sprintf("r%d", i)
+		results = append(results, r)
+		resultVars = append(resultVars, name)
+		resultPtrs = append(resultPtrs, "&"+name)
+	}
+	if m.hasError {
+		results = append(results, "error")
+	}
+
+	sig := strings.Join(results, ", ")
+	if len(results) > 1 {
+		sig = "(" + sig + ")"
+	}
+	w("func (d *%s) %s(%s) %s {\n", typ, m.name, strings.Join(params, ", "), sig)
+
+	for i, r := range m.results {
+		w("\tvar %s %s\n", resultVars[i], r)
+	}
+	fields := fmt.Sprintf("Method: %q", m.name)
+	if len(invArgs) > 0 {
+		fields += fmt.Sprintf(", Args: []any{%s}", strings.Join(invArgs, ", "))
+	}
+	if len(resultPtrs) > 0 {
+		fields += fmt.Sprintf(", Results: []any{%s}", strings.Join(resultPtrs, ", "))
+	}
+	w("\tinv := &decorator.Invocation{%s}\n", fields)
+
+	ctx := "context.Background()"
+	if m.hasCtx {
+		ctx = "ctx"
+	}
+	call := fmt.Sprintf("d.Next.%s(%s)", m.name, strings.Join(callArgs, ", "))
+	assign := slices.Clone(resultVars)
+	if m.hasError {
+		assign = append(assign, "err")
+	}
+
+	invoke := "err := "
+	if m.hasError && len(resultVars) == 0 {
+		invoke = "return "
+	} else if !m.hasError {
+		// Interceptor errors cannot be reported by a method without an
+		// error result.
+		invoke = "_ = "
+	}
+	w("\t%sdecorator.Invoke(%s, d.Interceptor, inv, func(ctx context.Context) error {\n", invoke, ctx)
+	switch {
+	case m.hasError && len(resultVars) == 0:
+		w("\t\treturn %s\n", call)
+	case m.hasError:
+		w("\t\tvar err error\n")
+		w("\t\t%s = %s\n", strings.Join(assign, ", "), call)
+		w("\t\treturn err\n")
+	case len(assign) > 0:
+		w("\t\t%s = %s\n", strings.Join(assign, ", "), call)
+		w("\t\treturn nil\n")
+	default:
+		w("\t\t%s\n", call)
+		w("\t\treturn nil\n")
+	}
+	w("\t})\n")
+
+	ret := slices.Clone(resultVars)
+	if m.hasError && len(resultVars) > 0 {
+		ret = append(ret, "err")
+	}
+	if len(ret) > 0 {
+		w("\treturn %s\n", strings.Join(ret, ", "))
+	}
+	w("}\n")
+}
+
+// isStdlib reports whether an import spec refers to the standard
+// library, whose paths have no dot in their first element.
+func isStdlib(spec string) bool {
+	path := spec[strings.Index(spec, `"`)+1:]
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,55 @@
+// Decorgen generates a decorator base struct for an interface.
+//
+// The generated struct implements the interface by passing every call
+// through a decorator.Interceptor before delegating to the wrapped value.
+// Embed it to override individual methods.
+//
+// Usage:
+//
+//	decorgen -type UserRepository [-dir .] [-output userrepository_decorator.go]
+//
+// It is typically invoked through go:generate from the package that
+// declares the interface.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("decorgen: ")
+
+	typeName := flag.String("type", "", "interface type to decorate (required)")
+	dir := flag.String("dir", ".", "directory of the package declaring the interface")
+	output := flag.String("output", "", "output file name; default <type>_decorator.go")
+	flag.Parse()
+
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_decorator.go"
+	}
+	outPath := filepath.Join(*dir, *output)
+
+	iface, err := load(*dir, *typeName, filepath.Base(outPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(iface)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outPath, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("wrote", outPath)
+}
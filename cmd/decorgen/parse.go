@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// iface is the part of an interface declaration the generator needs.
+type iface struct {
+	pkg     string
+	name    string
+	methods []method
+	imports map[string]string // package name -> import spec
+}
+
+type method struct {
+	name     string
+	params   []param
+	results  []string // types of non-error results
+	hasCtx   bool     // first parameter is a context.Context
+	hasError bool     // last result is an error
+	variadic bool
+}
+
+type param struct {
+	name string
+	typ  string
+}
+
+// reserved names are used by the generated method bodies.
+var reserved = map[string]bool{
+	"d": true, "inv": true, "err": true, "decorator": true, "context": true,
+}
+
+// load parses the non-test Go files in dir, skipping the generator's own
+// output, and extracts the named interface.
+func load(dir, name, skip string) (*iface, error) {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || !strings.HasSuffix(n, ".go") || strings.HasSuffix(n, "_test.go") || n == skip {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, n), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	// Type-check the package so imports are known by the names their
+	// packages declare, not guessed from their paths, and context.Context
+	// and error are recognised by identity rather than spelling. Errors
+	// are ignored: the generator's previous output is skipped, so the
+	// package may not be complete.
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil), Error: func(error) {}}
+	conf.Check(files[0].Name.Name, fset, files, info)
+
+	p := &loader{fset: fset, files: files, info: info}
+	out := &iface{pkg: files[0].Name.Name, name: name, imports: map[string]string{}}
+	if err := p.collect(out, name, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type loader struct {
+	fset  *token.FileSet
+	files []*ast.File
+	info  *types.Info
+}
+
+// collect appends the methods of the named interface, expanding
+// interfaces embedded from the same package.
+func (l *loader) collect(out *iface, name string, seen map[string]bool) error {
+	if seen[name] {
+		return nil
+	}
+	seen[name] = true
+
+	spec := l.find(name)
+	if spec == nil {
+		return fmt.Errorf("type %s not found", name)
+	}
+	if spec.TypeParams != nil {
+		return fmt.Errorf("%s: generic interfaces are not supported", name)
+	}
+	it, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return fmt.Errorf("%s is not an interface", name)
+	}
+
+	for _, field := range it.Methods.List {
+		switch t := field.Type.(type) {
+		case *ast.FuncType:
+			m, err := l.method(field.Names[0].Name, t)
+			if err != nil {
+				return err
+			}
+			out.methods = append(out.methods, m)
+			l.addImports(out, t)
+		case *ast.Ident:
+			if err := l.collect(out, t.Name, seen); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: embedded %s is not supported; list its methods explicitly", name, l.expr(field.Type))
+		}
+	}
+	return nil
+}
+
+func (l *loader) find(name string) *ast.TypeSpec {
+	for _, f := range l.files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gen.Specs {
+				if ts := s.(*ast.TypeSpec); ts.Name.Name == name {
+					return ts
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (l *loader) method(name string, fn *ast.FuncType) (method, error) {
+	m := method{name: name}
+
+	if fn.Params != nil {
+		for _, field := range fn.Params.List {
+			typ := l.expr(field.Type)
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				m.variadic = true
+			}
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{{Name: "_"}}
+			}
+			for _, n := range names {
+				m.params = append(m.params, param{name: n.Name, typ: typ})
+			}
+		}
+	}
+	if fn.Params != nil && len(fn.Params.List) > 0 && l.isContext(fn.Params.List[0].Type) {
+		m.hasCtx = true
+	}
+	for i := range m.params {
+		p := &m.params[i]
+		switch {
+		case i == 0 && m.hasCtx:
+			p.name = "ctx"
+		case p.name == "_" || p.name == "ctx" || reserved[p.name] || isResultName(p.name):
+			p.name = "arg" + strconv.Itoa(i)
+		}
+	}
+
+	if fn.Results != nil {
+		var results []string
+		for _, field := range fn.Results.List {
+			typ := l.expr(field.Type)
+			for range max(len(field.Names), 1) {
+				results = append(results, typ)
+			}
+		}
+		if n := len(results); n > 0 && types.Identical(l.info.TypeOf(fn.Results.List[len(fn.Results.List)-1].Type), errorType) {
+			m.hasError = true
+			results = results[:n-1]
+		}
+		m.results = results
+	}
+	return m, nil
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// isContext reports whether e denotes context.Context, whatever name the
+// context package is imported under and through any aliases.
+func (l *loader) isContext(e ast.Expr) bool {
+	named, ok := types.Unalias(l.info.TypeOf(e)).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+// addImports records the imports used by fn's signature. An import is
+// written with an explicit name when its local name differs from the
+// package's own, as for renamed imports.
+func (l *loader) addImports(out *iface, fn *ast.FuncType) {
+	ast.Inspect(fn, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		name, ok := l.info.Uses[pkg].(*types.PkgName)
+		if !ok {
+			return true
+		}
+		imported := name.Imported()
+		spec := strconv.Quote(imported.Path())
+		if name.Name() != imported.Name() {
+			spec = name.Name() + " " + spec
+		}
+		out.imports[name.Name()] = spec
+		return true
+	})
+}
+
+func (l *loader) expr(n ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, l.fset, n)
+	return buf.String()
+}
+
+func isResultName(name string) bool {
+	if len(name) < 2 || name[0] != 'r' {
+		return false
+	}
+	_, err := strconv.Atoi(name[1:])
+	return err == nil
+}
@@ -0,0 +1,17 @@
+package ctxnames
+
+import stdctx "context"
+
+// Ctx is an alias for context.Context.
+type Ctx = stdctx.Context
+
+// Context is a local type that merely shares the name.
+type Context struct{}
+
+// Jobs takes contexts under other names, and a lookalike that is not
+// one.
+type Jobs interface {
+	Run(c stdctx.Context, id string) error
+	Aliased(c Ctx) (int, error)
+	Lookalike(c Context) error
+}
@@ -0,0 +1,15 @@
+package renamed
+
+import (
+	"context"
+	"math/rand/v2"
+
+	tmpl "text/template"
+)
+
+// Sampler uses imports whose package names differ from the last element
+// of their paths.
+type Sampler interface {
+	Source(ctx context.Context) (*rand.Rand, error)
+	Render(t *tmpl.Template) string
+}
@@ -0,0 +1,70 @@
+// Handbook is a companion tool for the handbook's code snippets.
+//
+// Usage:
+//
+//	handbook snippets [chapter]      list Go snippets, e.g. "handbook snippets 3.6"
+//	handbook methods <snippet-id>    explain method sets for a snippet, e.g. "handbook methods 3.6/9"
+//
+// A snippet ID is the chapter number followed by the 1-based index of a
+// ```go block in that chapter. The docs directory is found by walking up
+// from the working directory unless -docs is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	docs := flag.String("docs", "", "path to the docs directory")
+	verbose := flag.Bool("v", false, "also print type-checking errors")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	dir, err := findDocs(*docs)
+	if err != nil {
+		fail(err)
+	}
+
+	args := flag.Args()
+	switch args[0] {
+	case "snippets":
+		chapter := ""
+		if len(args) > 1 {
+			chapter = args[1]
+		}
+		err = listSnippets(os.Stdout, dir, chapter)
+	case "methods":
+		if len(args) != 2 {
+			usage()
+			os.Exit(2)
+		}
+		err = explainMethods(os.Stdout, dir, args[1], *verbose)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `usage: handbook [-docs dir] [-v] <command> [args]
+
+commands:
+  snippets [chapter]     list Go snippets and their IDs
+  methods <snippet-id>   print method sets and interface satisfaction
+`)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "handbook:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stdImports lets snippets that omit their imports still type-check.
+// Snippets are fragments, so a missing "fmt" import is the norm.
+var stdImports = map[string]string{
+	"bufio": "bufio", "bytes": "bytes", "context": "context", "errors": "errors",
+	"fmt": "fmt", "io": "io", "json": "encoding/json", "log": "log", "math": "math",
+	"http": "net/http", "os": "os", "sort": "sort", "sql": "database/sql",
+	"strconv": "strconv", "strings": "strings", "sync": "sync", "time": "time",
+	"atomic": "sync/atomic", "reflect": "reflect", "rand": "math/rand",
+}
+
+// wellKnown are interfaces checked for every type in addition to the
+// interfaces the snippet declares.
+var wellKnown = []struct{ pkg, name string }{
+	{"fmt", "Stringer"},
+	{"io", "Reader"},
+	{"io", "Writer"},
+	{"io", "Closer"},
+	{"io", "ReadWriter"},
+	{"io", "ReadCloser"},
+	{"sort", "Interface"},
+	{"encoding/json", "Marshaler"},
+	{"encoding/json", "Unmarshaler"},
+}
+
+type checked struct {
+	fset   *token.FileSet
+	pkg    *types.Package
+	errors []error
+}
+
+// check parses and type-checks a snippet, keeping whatever declarations
+// are valid.
+func check(s snippet) (*checked, error) {
+	src := s.code
+	if !strings.HasPrefix(strings.TrimSpace(src), "package ") {
+		src = "package snippet\n" + src
+	}
+
+	fset := token.NewFileSet()
+	name := fmt.Sprintf("%s:%d", filepath.Base(s.file), s.line)
+	// Top-level statements such as "// Usage" examples are parse errors;
+	// the parser skips them and keeps the surrounding declarations.
+	file, _ := parser.ParseFile(fset, name, src, parser.AllErrors|parser.SkipObjectResolution)
+	if file == nil {
+		return nil, fmt.Errorf("snippet %s does not parse", s.id)
+	}
+	addMissingImports(file)
+
+	c := &checked{fset: fset}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			c.errors = append(c.errors, err)
+		},
+	}
+	c.pkg, _ = conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	return c, nil
+}
+
+func addMissingImports(file *ast.File) {
+	imported := make(map[string]bool)
+	for _, imp := range file.Imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		imported[filepath.Base(path)] = true
+		if imp.Name != nil {
+			imported[imp.Name.Name] = true
+		}
+	}
+
+	needed := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && stdImports[x.Name] != "" && !imported[x.Name] {
+				needed[x.Name] = true
+			}
+		}
+		return true
+	})
+
+	var specs []ast.Spec
+	for name := range needed {
+		specs = append(specs, &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: `"` + stdImports[name] + `"`}})
+	}
+	if len(specs) > 0 {
+		file.Decls = append([]ast.Decl{&ast.GenDecl{Tok: token.IMPORT, Specs: specs}}, file.Decls...)
+	}
+}
+
+type namedIface struct {
+	name  string
+	iface *types.Interface
+}
+
+func explainMethods(w io.Writer, docs, id string, verbose bool) error {
+	s, err := findSnippet(docs, id)
+	if err != nil {
+		return err
+	}
+	c, err := check(s)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "snippet %s (%s:%d)\n", s.id, s.file, s.line)
+	if len(c.errors) > 0 {
+		fmt.Fprintf(w, "note: %d type-checking errors; results cover the declarations that check (-v to list)\n", len(c.errors))
+		if verbose {
+			for _, e := range c.errors {
+				fmt.Fprintf(w, "  %v\n", e)
+			}
+		}
+	}
+
+	qual := types.RelativeTo(c.pkg)
+	ifaces := c.interfaces()
+	scope := c.pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintln(w)
+		if iface, ok := named.Underlying().(*types.Interface); ok {
+			fmt.Fprintf(w, "type %s interface\n", name)
+			printMethods(w, "  methods:", methodList(types.NewMethodSet(iface), qual))
+			continue
+		}
+		explainType(w, named, ifaces, qual)
+	}
+	return nil
+}
+
+// interfaces collects the snippet's named interfaces and the well-known
+// standard ones.
+func (c *checked) interfaces() []namedIface {
+	var out []namedIface
+	scope := c.pkg.Scope()
+	for _, name := range scope.Names() {
+		if tn, ok := scope.Lookup(name).(*types.TypeName); ok {
+			if iface, ok := tn.Type().Underlying().(*types.Interface); ok && iface.NumMethods() > 0 {
+				out = append(out, namedIface{name, iface})
+			}
+		}
+	}
+
+	out = append(out, namedIface{"error", types.Universe.Lookup("error").Type().Underlying().(*types.Interface)})
+	imp := importer.ForCompiler(c.fset, "source", nil)
+	for _, wk := range wellKnown {
+		pkg, err := imp.Import(wk.pkg)
+		if err != nil {
+			continue
+		}
+		if obj := pkg.Scope().Lookup(wk.name); obj != nil {
+			out = append(out, namedIface{pkg.Name() + "." + wk.name, obj.Type().Underlying().(*types.Interface)})
+		}
+	}
+	return out
+}
+
+func explainType(w io.Writer, named *types.Named, ifaces []namedIface, qual types.Qualifier) {
+	name := named.Obj().Name()
+	ptr := types.NewPointer(named)
+	valueSet := types.NewMethodSet(named)
+	ptrSet := types.NewMethodSet(ptr)
+
+	fmt.Fprintf(w, "type %s %s\n", name, kind(named))
+	printMethods(w, fmt.Sprintf("  method set of %s:", name), methodList(valueSet, qual))
+	printMethods(w, fmt.Sprintf("  method set of *%s:", name), methodList(ptrSet, qual))
+
+	var promoted []string
+	for i := 0; i < ptrSet.Len(); i++ {
+		sel := ptrSet.At(i)
+		if len(sel.Index()) > 1 {
+			promoted = append(promoted, fmt.Sprintf("%s via %s", signature(sel.Obj().(*types.Func), qual), embedPath(named, sel.Index())))
+		}
+	}
+	printSection(w, "  promoted from embedded fields:", promoted)
+
+	var satisfies, nearMisses []string
+	for _, ni := range ifaces {
+		if ni.iface == named.Underlying() {
+			continue
+		}
+		switch {
+		case types.Implements(named, ni.iface):
+			satisfies = append(satisfies, ni.name+" (value and pointer)")
+		case types.Implements(ptr, ni.iface):
+			satisfies = append(satisfies, ni.name+" (pointer only: some methods have pointer receivers)")
+		default:
+			if miss := nearMiss(ptr, ni.iface, qual); miss != "" {
+				nearMisses = append(nearMisses, ni.name+": "+miss)
+			}
+		}
+	}
+	printSection(w, "  satisfies:", satisfies)
+	printSection(w, "  near misses:", nearMisses)
+	printSection(w, "  ambiguous selectors (not promoted):", ambiguous(named))
+}
+
+// nearMiss describes why T almost implements iface: it has at least
+// one of the interface's methods but lacks or mistypes others.
+func nearMiss(T types.Type, iface *types.Interface, qual types.Qualifier) string {
+	var problems []string
+	matched := 0
+	for i := 0; i < iface.NumMethods(); i++ {
+		want := iface.Method(i)
+		obj, _, _ := types.LookupFieldOrMethod(T, true, want.Pkg(), want.Name())
+		got, ok := obj.(*types.Func)
+		switch {
+		case !ok:
+			problems = append(problems, "missing "+signature(want, qual))
+		case !types.Identical(got.Type().(*types.Signature), want.Type().(*types.Signature)):
+			matched++
+			problems = append(problems, fmt.Sprintf("wrong signature %s, want %s", signature(got, qual), signature(want, qual)))
+		default:
+			matched++
+		}
+	}
+	if matched == 0 {
+		return ""
+	}
+	return strings.Join(problems, "; ")
+}
+
+// ambiguous lists names reachable through more than one embedded field
+// at the same depth, like Name in the chapter's A/B/C example.
+func ambiguous(named *types.Named) []string {
+	candidates := make(map[string]bool)
+	collectNames(named, candidates, make(map[types.Type]bool), 0)
+
+	var out []string
+	for name := range candidates {
+		obj, index, _ := types.LookupFieldOrMethod(named, true, named.Obj().Pkg(), name)
+		if obj != nil || index == nil {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s: found at %s", name, strings.Join(pathsTo(named, name), ", ")))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func collectNames(T types.Type, names map[string]bool, seen map[types.Type]bool, depth int) {
+	T = deref(T)
+	if seen[T] || depth > 8 {
+		return
+	}
+	seen[T] = true
+
+	if named, ok := T.(*types.Named); ok {
+		for i := 0; i < named.NumMethods(); i++ {
+			names[named.Method(i).Name()] = true
+		}
+	}
+	switch u := T.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			names[f.Name()] = true
+			if f.Embedded() {
+				collectNames(f.Type(), names, seen, depth+1)
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumMethods(); i++ {
+			names[u.Method(i).Name()] = true
+		}
+	}
+}
+
+// pathsTo finds the shallowest embedding paths that reach name.
+func pathsTo(named *types.Named, name string) []string {
+	type node struct {
+		typ  types.Type
+		path string
+	}
+	level := []node{{named, named.Obj().Name()}}
+	for depth := 0; depth < 8 && len(level) > 0; depth++ {
+		var found []string
+		var next []node
+		for _, n := range level {
+			s, ok := deref(n.typ).Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			for i := 0; i < s.NumFields(); i++ {
+				f := s.Field(i)
+				if !f.Embedded() {
+					continue
+				}
+				path := n.path + "." + f.Name()
+				if hasDirect(f.Type(), name) {
+					found = append(found, path+"."+name)
+				}
+				next = append(next, node{f.Type(), path})
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+		level = next
+	}
+	return nil
+}
+
+// hasDirect reports whether T declares name as a method or field
+// without going through further embedding.
+func hasDirect(T types.Type, name string) bool {
+	T = deref(T)
+	if named, ok := T.(*types.Named); ok {
+		for i := 0; i < named.NumMethods(); i++ {
+			if named.Method(i).Name() == name {
+				return true
+			}
+		}
+	}
+	switch u := T.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if u.Field(i).Name() == name {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumMethods(); i++ {
+			if u.Method(i).Name() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// embedPath turns a selection index into T.Field.Field.
+func embedPath(named *types.Named, index []int) string {
+	parts := []string{named.Obj().Name()}
+	T := types.Type(named)
+	for _, i := range index[:len(index)-1] {
+		s, ok := deref(T).Underlying().(*types.Struct)
+		if !ok {
+			break
+		}
+		f := s.Field(i)
+		parts = append(parts, f.Name())
+		T = f.Type()
+	}
+	return strings.Join(parts, ".")
+}
+
+func methodList(ms *types.MethodSet, qual types.Qualifier) []string {
+	out := make([]string, 0, ms.Len())
+	for i := 0; i < ms.Len(); i++ {
+		out = append(out, signature(ms.At(i).Obj().(*types.Func), qual))
+	}
+	return out
+}
+
+func signature(fn *types.Func, qual types.Qualifier) string {
+	return fn.Name() + strings.TrimPrefix(types.TypeString(fn.Type(), qual), "func")
+}
+
+func printMethods(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		fmt.Fprintf(w, "%s (none)\n", title)
+		return
+	}
+	fmt.Fprintln(w, title)
+	for _, item := range items {
+		fmt.Fprintf(w, "    %s\n", item)
+	}
+}
+
+// printSection is printMethods for optional sections: empty ones are
+// left out to keep the report short.
+func printSection(w io.Writer, title string, items []string) {
+	if len(items) > 0 {
+		printMethods(w, title, items)
+	}
+}
+
+func kind(T types.Type) string {
+	switch T.Underlying().(type) {
+	case *types.Struct:
+		return "struct"
+	case *types.Signature:
+		return "func"
+	default:
+		return types.TypeString(T.Underlying(), nil)
+	}
+}
+
+func deref(T types.Type) types.Type {
+	if p, ok := T.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return T
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const fixtures = "testdata/docs"
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		run    func(w *bytes.Buffer) error
+	}{
+		{"snippets.golden", func(w *bytes.Buffer) error { return listSnippets(w, fixtures, "3.1") }},
+		{"ambiguous.golden", func(w *bytes.Buffer) error { return explainMethods(w, fixtures, "3.1/1", false) }},
+		{"pointer_receiver.golden", func(w *bytes.Buffer) error { return explainMethods(w, fixtures, "3.1/2", false) }},
+		{"promoted.golden", func(w *bytes.Buffer) error { return explainMethods(w, fixtures, "3.1/3", false) }},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSuffix(tt.golden, ".golden"), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.run(&buf); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s; rerun with -update if intended\n got:\n%s\nwant:\n%s", path, buf.Bytes(), want)
+			}
+		})
+	}
+}
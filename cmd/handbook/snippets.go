@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// snippet is one ```go block from a chapter.
+type snippet struct {
+	id   string
+	file string
+	line int // line of the opening fence
+	code string
+}
+
+// findDocs returns dir if set, or the nearest docs directory found by
+// walking up from the working directory.
+func findDocs(dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(wd, "docs")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+		parent := filepath.Dir(wd)
+		if parent == wd {
+			return "", errors.New("docs directory not found; use -docs")
+		}
+		wd = parent
+	}
+}
+
+// chapterFiles maps chapter numbers such as "3.6" to their markdown files.
+func chapterFiles(docs string) (map[string]string, error) {
+	matches, err := filepath.Glob(filepath.Join(docs, "*", "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	chapters := make(map[string]string)
+	for _, path := range matches {
+		number, _, ok := strings.Cut(filepath.Base(path), "_")
+		if ok {
+			chapters[number] = path
+		}
+	}
+	return chapters, nil
+}
+
+// loadSnippets extracts the Go blocks of a chapter.
+func loadSnippets(path, chapter string) ([]snippet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		snippets []snippet
+		current  *snippet
+		body     strings.Builder
+		lineNo   int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case current == nil && trimmed == "```go":
+			current = &snippet{
+				id:   fmt.Sprintf("%s/%d", chapter, len(snippets)+1),
+				file: path,
+				line: lineNo,
+			}
+			body.Reset()
+		case current != nil && trimmed == "```":
+			current.code = body.String()
+			snippets = append(snippets, *current)
+			current = nil
+		case current != nil:
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+	}
+	return snippets, scanner.Err()
+}
+
+// findSnippet resolves an ID such as "3.6/9".
+func findSnippet(docs, id string) (snippet, error) {
+	chapter, index, ok := strings.Cut(id, "/")
+	n, err := strconv.Atoi(index)
+	if !ok || err != nil || n < 1 {
+		return snippet{}, fmt.Errorf("invalid snippet id %q; want <chapter>/<n>, e.g. 3.6/9", id)
+	}
+
+	chapters, err := chapterFiles(docs)
+	if err != nil {
+		return snippet{}, err
+	}
+	path, ok := chapters[chapter]
+	if !ok {
+		return snippet{}, fmt.Errorf("unknown chapter %q", chapter)
+	}
+	snippets, err := loadSnippets(path, chapter)
+	if err != nil {
+		return snippet{}, err
+	}
+	if n > len(snippets) {
+		return snippet{}, fmt.Errorf("chapter %s has %d Go snippets", chapter, len(snippets))
+	}
+	return snippets[n-1], nil
+}
+
+// listSnippets prints the ID, location and first meaningful line of each
+// snippet, for one chapter or for all of them.
+func listSnippets(w io.Writer, docs, chapter string) error {
+	chapters, err := chapterFiles(docs)
+	if err != nil {
+		return err
+	}
+
+	var numbers []string
+	if chapter != "" {
+		if _, ok := chapters[chapter]; !ok {
+			return fmt.Errorf("unknown chapter %q", chapter)
+		}
+		numbers = []string{chapter}
+	} else {
+		for number := range chapters {
+			numbers = append(numbers, number)
+		}
+		sort.Slice(numbers, func(i, j int) bool {
+			return chapterLess(numbers[i], numbers[j])
+		})
+	}
+
+	for _, number := range numbers {
+		snippets, err := loadSnippets(chapters[number], number)
+		if err != nil {
+			return err
+		}
+		for _, s := range snippets {
+			fmt.Fprintf(w, "%-8s %s:%d\t%s\n", s.id, filepath.Base(s.file), s.line, summary(s.code))
+		}
+	}
+	return nil
+}
+
+func summary(code string) string {
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "package ") && !strings.HasPrefix(line, "import") {
+			return line
+		}
+	}
+	return ""
+}
+
+// chapterLess orders "3.10" after "3.9".
+func chapterLess(a, b string) bool {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, _ := strconv.Atoi(pa[i])
+		y, _ := strconv.Atoi(pb[i])
+		if x != y {
+			return x < y
+		}
+	}
+	return len(pa) < len(pb)
+}
@@ -0,0 +1,55 @@
+// Command adaptive simulates an overloaded backend with and without
+// adaptive concurrency limiting and prints how each algorithm settles.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/adaptive"
+	"github.com/thanhnamdk2710/go-handbook/pkg/adaptive/loadsim"
+)
+
+func main() {
+	var cfg loadsim.Config
+	flag.IntVar(&cfg.Servers, "servers", 20, "backend servers")
+	flag.DurationVar(&cfg.ServiceTime, "service", 50*time.Millisecond, "mean service time")
+	flag.Float64Var(&cfg.ArrivalRate, "rate", 600, "offered requests per second")
+	flag.DurationVar(&cfg.Duration, "duration", time.Minute, "simulated time")
+	flag.Uint64Var(&cfg.Seed, "seed", 1, "random seed")
+	flag.Parse()
+
+	fmt.Printf("backend capacity %.0f/s, offered %.0f/s\n\n", cfg.Capacity(), cfg.ArrivalRate)
+
+	algorithms := []struct {
+		name string
+		new  func() adaptive.Algorithm
+	}{
+		{"none", nil},
+		{"AIMD", func() adaptive.Algorithm {
+			// Without a latency threshold AIMD only backs off once clients
+			// time out.
+			return adaptive.NewAIMD(adaptive.AIMDConfig{Timeout: 2 * cfg.ServiceTime})
+		}},
+		{"Vegas", func() adaptive.Algorithm { return adaptive.NewVegas(adaptive.VegasConfig{}) }},
+		{"Gradient", func() adaptive.Algorithm { return adaptive.NewGradient(adaptive.GradientConfig{}) }},
+	}
+	for _, a := range algorithms {
+		var newLimiter func(func() time.Time) *adaptive.Limiter
+		if a.new != nil {
+			newLimiter = func(now func() time.Time) *adaptive.Limiter {
+				return adaptive.New(a.new(), adaptive.WithClock(now))
+			}
+		}
+		report := loadsim.Run(cfg, newLimiter)
+		fmt.Printf("== %s\n%v", a.name, report)
+		if a.new != nil {
+			// Little's law: servers in flight keep the backend busy with no
+			// queue; allow some queueing headroom above that.
+			lo, hi := cfg.Servers/2, cfg.Servers*3
+			fmt.Printf("converged within [%d, %d]: %v\n", lo, hi, report.Converged(lo, hi))
+		}
+		fmt.Println()
+	}
+}
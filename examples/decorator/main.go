@@ -0,0 +1,75 @@
+// This example wraps a UserRepository with interceptors built by the
+// generated UserRepositoryDecorator, then overrides one method through
+// embedding as in the type embedding chapter.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/examples/decorator/users"
+	"github.com/thanhnamdk2710/go-handbook/pkg/cache"
+	"github.com/thanhnamdk2710/go-handbook/pkg/decorator"
+	"github.com/thanhnamdk2710/go-handbook/pkg/retry"
+)
+
+// flakyRepository fails the first lookup of every user.
+type flakyRepository struct {
+	users.UserRepository
+	seen map[string]bool
+}
+
+func (r *flakyRepository) Find(ctx context.Context, id string) (*users.User, error) {
+	if !r.seen[id] {
+		r.seen[id] = true
+		return nil, errors.New("connection reset")
+	}
+	return r.UserRepository.Find(ctx, id)
+}
+
+// auditedRepository overrides Save and inherits every other method from
+// the generated decorator.
+type auditedRepository struct {
+	*users.UserRepositoryDecorator
+}
+
+func (r auditedRepository) Save(ctx context.Context, user *users.User) error {
+	fmt.Println("audit: saving", user.ID)
+	return r.UserRepositoryDecorator.Save(ctx, user)
+}
+
+func main() {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	store := &flakyRepository{UserRepository: users.NewMemoryRepository(), seen: map[string]bool{}}
+	calls := cache.New[string, []any](cache.WithTTL(time.Minute))
+
+	repo := auditedRepository{users.NewUserRepositoryDecorator(store,
+		decorator.Logging(logger),
+		decorator.Caching(calls, decorator.MethodKey("Find")),
+		decorator.Retry(
+			retry.WithBackoff(retry.Constant(10*time.Millisecond)),
+			retry.WithClassifier(func(err error) retry.Class {
+				if errors.Is(err, users.ErrNotFound) {
+					return retry.Permanent
+				}
+				return retry.Unknown
+			}),
+		),
+	)}
+
+	repo.Save(ctx, &users.User{ID: "1", Name: "Alice", Email: "alice@example.com"})
+
+	// The first Find is retried once, the second is served from the cache.
+	for range 2 {
+		user, err := repo.Find(ctx, "1")
+		fmt.Println(user, err)
+	}
+	fmt.Println("count:", repo.Count())
+	fmt.Println("cache:", calls.Stats())
+}
@@ -0,0 +1,78 @@
+// Package users is a small sample domain for the decorator example.
+package users
+
+//go:generate go run github.com/thanhnamdk2710/go-handbook/cmd/decorgen -type UserRepository
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned when a user does not exist.
+var ErrNotFound = errors.New("users: not found")
+
+// User is a registered account.
+type User struct {
+	ID    string
+	Name  string
+	Email string
+}
+
+// UserRepository stores users, like the Repository interface in the
+// packages chapter.
+type UserRepository interface {
+	Save(ctx context.Context, user *User) error
+	Find(ctx context.Context, id string) (*User, error)
+	List(ctx context.Context, ids ...string) ([]*User, error)
+	Count() int
+}
+
+// MemoryRepository is an in-memory UserRepository.
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]*User
+}
+
+// NewMemoryRepository creates an empty repository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users: make(map[string]*User),
+	}
+}
+
+func (r *MemoryRepository) Save(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *MemoryRepository) Find(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryRepository) List(ctx context.Context, ids ...string) ([]*User, error) {
+	var result []*User
+	for _, id := range ids {
+		user, err := r.Find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, user)
+	}
+	return result, nil
+}
+
+func (r *MemoryRepository) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.users)
+}
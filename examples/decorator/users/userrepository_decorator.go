@@ -0,0 +1,62 @@
+// Code generated by decorgen. DO NOT EDIT.
+
+package users
+
+import (
+	"context"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/decorator"
+)
+
+// UserRepositoryDecorator wraps a UserRepository, passing every call through Interceptor.
+// Embed it to override individual methods.
+type UserRepositoryDecorator struct {
+	Next        UserRepository
+	Interceptor decorator.Interceptor
+}
+
+// NewUserRepositoryDecorator wraps next with interceptors, outermost first.
+func NewUserRepositoryDecorator(next UserRepository, interceptors ...decorator.Interceptor) *UserRepositoryDecorator {
+	return &UserRepositoryDecorator{Next: next, Interceptor: decorator.Chain(interceptors...)}
+}
+
+var _ UserRepository = (*UserRepositoryDecorator)(nil)
+
+func (d *UserRepositoryDecorator) Save(ctx context.Context, user *User) error {
+	inv := &decorator.Invocation{Method: "Save", Args: []any{user}}
+	return decorator.Invoke(ctx, d.Interceptor, inv, func(ctx context.Context) error {
+		return d.Next.Save(ctx, user)
+	})
+}
+
+func (d *UserRepositoryDecorator) Find(ctx context.Context, id string) (*User, error) {
+	var r0 *User
+	inv := &decorator.Invocation{Method: "Find", Args: []any{id}, Results: []any{&r0}}
+	err := decorator.Invoke(ctx, d.Interceptor, inv, func(ctx context.Context) error {
+		var err error
+		r0, err = d.Next.Find(ctx, id)
+		return err
+	})
+	return r0, err
+}
+
+func (d *UserRepositoryDecorator) List(ctx context.Context, ids ...string) ([]*User, error) {
+	var r0 []*User
+	inv := &decorator.Invocation{Method: "List", Args: []any{ids}, Results: []any{&r0}}
+	err := decorator.Invoke(ctx, d.Interceptor, inv, func(ctx context.Context) error {
+		var err error
+		r0, err = d.Next.List(ctx, ids...)
+		return err
+	})
+	return r0, err
+}
+
+func (d *UserRepositoryDecorator) Count() int {
+	var r0 int
+	inv := &decorator.Invocation{Method: "Count", Results: []any{&r0}}
+	_ = decorator.Invoke(context.Background(), d.Interceptor, inv, func(ctx context.Context) error {
+		r0 = d.Next.Count()
+		return nil
+	})
+	return r0
+}
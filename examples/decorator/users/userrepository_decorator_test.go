@@ -0,0 +1,137 @@
+package users_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/examples/decorator/users"
+	"github.com/thanhnamdk2710/go-handbook/pkg/cache"
+	"github.com/thanhnamdk2710/go-handbook/pkg/decorator"
+	"github.com/thanhnamdk2710/go-handbook/pkg/retry"
+)
+
+// countingRepository counts Find calls and fails the first failures of
+// them.
+type countingRepository struct {
+	users.UserRepository
+	finds    int
+	failures int
+}
+
+func (r *countingRepository) Find(ctx context.Context, id string) (*users.User, error) {
+	r.finds++
+	if r.finds <= r.failures {
+		return nil, errors.New("connection reset")
+	}
+	return r.UserRepository.Find(ctx, id)
+}
+
+func newStore(t *testing.T, failures int) *countingRepository {
+	t.Helper()
+	mem := users.NewMemoryRepository()
+	if err := mem.Save(context.Background(), &users.User{ID: "1", Name: "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+	return &countingRepository{UserRepository: mem, failures: failures}
+}
+
+func TestDecoratorWithoutInterceptors(t *testing.T) {
+	store := newStore(t, 0)
+	repo := users.NewUserRepositoryDecorator(store)
+
+	got, err := repo.List(context.Background(), "1", "1")
+	if err != nil || len(got) != 2 || got[0].Name != "Alice" {
+		t.Fatalf("List = %v, %v", got, err)
+	}
+	if repo.Count() != 1 {
+		t.Fatalf("Count = %d, want 1", repo.Count())
+	}
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := users.NewUserRepositoryDecorator(newStore(t, 0), decorator.Logging(logger))
+
+	if _, err := repo.Find(context.Background(), "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Find(context.Background(), "missing"); !errors.Is(err, users.ErrNotFound) {
+		t.Fatalf("Find(missing) = %v, want ErrNotFound", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "level=DEBUG") || !strings.Contains(lines[0], "method=Find") || !strings.Contains(lines[0], "args=[1]") {
+		t.Errorf("success line = %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "level=ERROR") || !strings.Contains(lines[1], "not found") {
+		t.Errorf("failure line = %s", lines[1])
+	}
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	notFound := retry.WithClassifier(func(err error) retry.Class {
+		if errors.Is(err, users.ErrNotFound) {
+			return retry.Permanent
+		}
+		return retry.Unknown
+	})
+	fast := retry.WithBackoff(retry.Constant(time.Millisecond))
+
+	store := newStore(t, 2)
+	repo := users.NewUserRepositoryDecorator(store, decorator.Retry(fast, notFound))
+	if u, err := repo.Find(context.Background(), "1"); err != nil || u.Name != "Alice" {
+		t.Fatalf("Find = %v, %v", u, err)
+	}
+	if store.finds != 3 {
+		t.Fatalf("Find called %d times, want 3", store.finds)
+	}
+
+	store = newStore(t, 0)
+	repo = users.NewUserRepositoryDecorator(store, decorator.Retry(fast, notFound))
+	if _, err := repo.Find(context.Background(), "missing"); !errors.Is(err, users.ErrNotFound) {
+		t.Fatalf("Find(missing) = %v", err)
+	}
+	if store.finds != 1 {
+		t.Fatalf("non-retryable error retried: %d calls", store.finds)
+	}
+}
+
+func TestCachingInterceptor(t *testing.T) {
+	store := newStore(t, 1)
+	calls := cache.New[string, []any]()
+	repo := users.NewUserRepositoryDecorator(store,
+		decorator.Caching(calls, decorator.MethodKey("Find")),
+		decorator.Retry(retry.WithMaxAttempts(2), retry.WithBackoff(retry.Constant(time.Millisecond))),
+	)
+
+	for range 3 {
+		u, err := repo.Find(context.Background(), "1")
+		if err != nil || u.Name != "Alice" {
+			t.Fatalf("Find = %v, %v", u, err)
+		}
+	}
+	// One failure and one success reach the store; later calls are hits.
+	if store.finds != 2 {
+		t.Fatalf("store saw %d Find calls, want 2", store.finds)
+	}
+	if st := calls.Stats(); st.Hits != 2 || st.Entries != 1 {
+		t.Fatalf("cache stats = %+v", st)
+	}
+
+	// Writes are not cached and still reach the store.
+	if err := repo.Save(context.Background(), &users.User{ID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.Count() != 2 || calls.Len() != 1 {
+		t.Fatalf("Count = %d, cache Len = %d", repo.Count(), calls.Len())
+	}
+}
@@ -0,0 +1,123 @@
+// Command pipeline runs a few pipelines, including ones that fail and
+// ones that are stopped early, and checks that each leaves no goroutines
+// behind.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/leakcheck"
+	"github.com/thanhnamdk2710/go-handbook/pkg/pipeline"
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit"
+)
+
+var errOdd = errors.New("odd length")
+
+func main() {
+	ok := true
+	for _, s := range scenarios {
+		before := leakcheck.IgnoreCurrent()
+		result, err := s.run()
+		leaked := leakcheck.Find(before)
+		fmt.Printf("%-16s %-40s err=%v leaked=%d\n", s.name, fmt.Sprint(result), err, len(leaked))
+		if len(leaked) > 0 {
+			fmt.Println(leakcheck.Report(leaked))
+			ok = false
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+var words = strings.Fields("the quick brown fox jumps over the lazy dog again")
+
+var scenarios = []struct {
+	name string
+	run  func() (any, error)
+}{
+	{"map-filter", func() (any, error) {
+		p := pipeline.New(context.Background())
+		upper := pipeline.Map(p, pipeline.From(p, words...), func(_ context.Context, w string) (string, error) {
+			return strings.ToUpper(w), nil
+		})
+		return pipeline.Collect(p, pipeline.Filter(p, upper, func(w string) bool { return len(w) > 3 }))
+	}},
+	{"ordered-fan-out", func() (any, error) {
+		p := pipeline.New(context.Background())
+		lengths := pipeline.OrderedFanOut(p, pipeline.From(p, words...), 4, func(_ context.Context, w string) (int, error) {
+			time.Sleep(time.Duration(10-len(w)) * time.Millisecond)
+			return len(w), nil
+		})
+		return pipeline.Collect(p, lengths)
+	}},
+	{"batch", func() (any, error) {
+		p := pipeline.New(context.Background())
+		return pipeline.Collect(p, pipeline.Batch(p, pipeline.From(p, words...), 4, time.Second))
+	}},
+	{"tee-merge", func() (any, error) {
+		p := pipeline.New(context.Background())
+		outs := pipeline.Tee(p, pipeline.From(p, 1, 2, 3), 2)
+		doubled := pipeline.Map(p, outs[1], func(_ context.Context, n int) (int, error) { return n * 10, nil })
+		merged, err := pipeline.Collect(p, pipeline.Merge(p, outs[0], doubled))
+		return len(merged), err
+	}},
+	{"throttle", func() (any, error) {
+		p := pipeline.New(context.Background())
+		lim := ratelimit.NewTokenBucket(ratelimit.Every(5*time.Millisecond), 1)
+		start := time.Now()
+		err := pipeline.Drain(p, pipeline.Throttle(p, pipeline.From(p, words...), lim))
+		return time.Since(start).Round(5 * time.Millisecond), err
+	}},
+	{"error-cancels", func() (any, error) {
+		p := pipeline.New(context.Background())
+		endless := pipeline.Generate(p, func(ctx context.Context, emit func(string) error) error {
+			for i := 0; ; i++ {
+				if err := emit(words[i%len(words)]); err != nil {
+					return err
+				}
+			}
+		})
+		checked := pipeline.FanOut(p, endless, 4, func(_ context.Context, w string) (string, error) {
+			if len(w)%2 == 1 && w != "the" && w != "fox" && w != "dog" {
+				return "", fmt.Errorf("%q: %w", w, errOdd)
+			}
+			return w, nil
+		})
+		tees := pipeline.Tee(p, pipeline.Batch(p, checked, 3, time.Millisecond), 2)
+		go pipeline.Drain(p, tees[1])
+		return nil, pipeline.Drain(p, tees[0])
+	}},
+	{"stop-early", func() (any, error) {
+		p := pipeline.New(context.Background())
+		nums := pipeline.Generate(p, func(ctx context.Context, emit func(int) error) error {
+			for i := 0; ; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+		})
+		squares := pipeline.OrderedFanOut(p, nums, 8, func(_ context.Context, n int) (int, error) { return n * n, nil })
+		var first []int
+		for v := range squares {
+			first = append(first, v)
+			if len(first) == 5 {
+				p.Stop()
+				break
+			}
+		}
+		return first, p.Wait()
+	}},
+	{"panic", func() (any, error) {
+		p := pipeline.New(context.Background())
+		out := pipeline.Map(p, pipeline.From(p, words...), func(_ context.Context, w string) (byte, error) {
+			return w[5], nil
+		})
+		return nil, pipeline.Drain(p, out)
+	}},
+}
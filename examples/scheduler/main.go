@@ -0,0 +1,102 @@
+// Command scheduler drives a scheduler with a fake clock through the
+// Europe/Berlin daylight saving changes of 2026 and then restarts it from
+// its state file, printing the due time of every run.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/scheduler"
+)
+
+func main() {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		log.Fatal(err)
+	}
+	dir, err := os.MkdirTemp("", "scheduler")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i, day := range []time.Time{
+		time.Date(2026, 3, 29, 0, 0, 0, 0, berlin),  // clocks jump from 02:00 to 03:00
+		time.Date(2026, 10, 25, 0, 0, 0, 0, berlin), // clocks fall back from 03:00 to 02:00
+	} {
+		fmt.Println("==", day.Format("2006-01-02"))
+		fc := clock.NewFake(day.Add(time.Hour))
+		s := start(fc, filepath.Join(dir, fmt.Sprintf("dst%d.json", i)), berlin)
+		advance(fc, 4*time.Hour)
+		stop(s)
+	}
+
+	// Restart: the process is down from 12:01 to 15:10 and comes back with
+	// the same state file. The hourly job catches up with a single run for
+	// 15:00, and the 12:00 run that already happened is not repeated.
+	fmt.Println("== restart")
+	state := filepath.Join(dir, "restart.json")
+	fc := clock.NewFake(time.Date(2026, 10, 26, 11, 59, 0, 0, berlin))
+	s := start(fc, state, berlin)
+	advance(fc, 2*time.Minute)
+	stop(s)
+	fc.Set(time.Date(2026, 10, 26, 15, 10, 0, 0, berlin))
+	s = start(fc, state, berlin)
+	advance(fc, time.Hour)
+	stop(s)
+	for _, j := range s.Jobs() {
+		fmt.Printf("%-10s runs=%d missed=%d next=%s\n", j.Name, j.Runs, j.Missed, j.Next.Format("15:04 MST"))
+	}
+}
+
+func start(fc *clock.Fake, state string, loc *time.Location) *scheduler.Scheduler {
+	s, err := scheduler.New(scheduler.WithClock(fc), scheduler.WithStateFile(state))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var mu sync.Mutex
+	job := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			due, _ := scheduler.Due(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Printf("  %-10s %s\n", name, due.In(loc).Format("15:04 MST"))
+			return nil
+		}
+	}
+	add := func(name, spec string) {
+		if err := s.Add(name, scheduler.MustParseCron(spec, loc), job(name)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	add("at-02:30", "30 2 * * *")      // skipped or repeated wall time: runs once
+	add("half-hour", "*/30 1-3 * * *") // fixed hours: repeated times run once
+	add("hourly", "0 * * * *")         // every hour: the repeated hour runs twice
+	if err := s.Start(); err != nil {
+		log.Fatal(err)
+	}
+	return s
+}
+
+// advance moves the fake clock a minute at a time, letting due runs finish
+// in between.
+func advance(fc *clock.Fake, d time.Duration) {
+	for end := fc.Now().Add(d); fc.Now().Before(end); {
+		fc.BlockUntil(1)
+		fc.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func stop(s *scheduler.Scheduler) {
+	if err := s.Stop(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,95 @@
+// Command taskgroup reworks the README's WaitGroup demo with a task
+// group: results are collected safely, a failing worker cancels the
+// rest, and a panicking worker is reported instead of crashing.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/taskgroup"
+)
+
+func main() {
+	// The README demo: three workers, results collected in order.
+	g, ctx := taskgroup.New(context.Background())
+	messages := make([]string, 3)
+	for i := range messages {
+		g.Go(func(ctx context.Context) error {
+			messages[i] = fmt.Sprintf("Worker %d completed task", i+1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		fmt.Println("error:", err)
+	}
+	for _, msg := range messages {
+		fmt.Println(msg)
+	}
+
+	// A failure cancels the workers still running.
+	g, ctx = taskgroup.New(context.Background())
+	g.SetLimit(2)
+	for i := 1; i <= 5; i++ {
+		g.Go(func(ctx context.Context) error {
+			if i == 2 {
+				return errors.New("worker 2 failed")
+			}
+			select {
+			case <-time.After(100 * time.Millisecond):
+				return nil
+			case <-ctx.Done():
+				fmt.Printf("worker %d stopped: %v\n", i, context.Cause(ctx))
+				return ctx.Err()
+			}
+		})
+	}
+	fmt.Println("first error:", g.Wait())
+
+	// With WithJoinErrors and WithContinueOnError every worker runs and
+	// every error, panics included, is reported.
+	g, _ = taskgroup.New(context.Background(), taskgroup.WithJoinErrors(), taskgroup.WithContinueOnError())
+	for i := 1; i <= 3; i++ {
+		g.Go(func(ctx context.Context) error {
+			switch i {
+			case 1:
+				return errors.New("worker 1 failed")
+			case 3:
+				var m map[string]int
+				m["boom"]++ // panics
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	var pe *taskgroup.PanicError
+	fmt.Printf("all errors: %d, panic recovered: %v\n", len(err.(interface{ Unwrap() []error }).Unwrap()), errors.As(err, &pe))
+
+	// A weighted semaphore shares a memory budget of 100 between jobs of
+	// different sizes.
+	sem := taskgroup.NewSemaphore(100)
+	var mu sync.Mutex
+	var inUse, peak int64
+	g, ctx = taskgroup.New(context.Background())
+	for _, size := range []int64{60, 30, 50, 20, 80, 10} {
+		g.Go(func(context.Context) error {
+			if err := sem.Acquire(ctx, size); err != nil {
+				return err
+			}
+			defer sem.Release(size)
+			mu.Lock()
+			inUse += size
+			peak = max(peak, inUse)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inUse -= size
+			mu.Unlock()
+			return nil
+		})
+	}
+	fmt.Printf("semaphore: err=%v peak=%d of 100\n", g.Wait(), peak)
+}
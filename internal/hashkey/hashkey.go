@@ -0,0 +1,114 @@
+// Package hashkey hashes comparable keys for sharding and hash tries.
+package hashkey
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+var seed = maphash.MakeSeed()
+
+// Of spreads common key types without allocating and hashes other keys by
+// walking them with reflect. Keys that compare equal hash equally:
+// pointers, channels and interfaces holding them hash by identity, and
+// -0 hashes like +0. Hashes are only stable within one process.
+func Of[K comparable](key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return maphash.String(seed, k)
+	case int:
+		return mix(uint64(k))
+	case int32:
+		return mix(uint64(k))
+	case int64:
+		return mix(uint64(k))
+	case uint:
+		return mix(uint64(k))
+	case uint32:
+		return mix(uint64(k))
+	case uint64:
+		return mix(k)
+	case float64:
+		return mix(floatBits(k))
+	default:
+		var h maphash.Hash
+		h.SetSeed(seed)
+		write(&h, reflect.ValueOf(any(key)))
+		return h.Sum64()
+	}
+}
+
+// write feeds v to h following the rules of ==.
+func write(h *maphash.Hash, v reflect.Value) {
+	if !v.IsValid() {
+		writeUint(h, 0)
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		writeUint(h, uint64(v.Len()))
+		h.WriteString(v.String())
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint(h, 1)
+		} else {
+			writeUint(h, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint(h, floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeUint(h, floatBits(real(c)))
+		writeUint(h, floatBits(imag(c)))
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint(h, uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			writeUint(h, 0)
+			return
+		}
+		write(h, v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			write(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			write(h, v.Field(i))
+		}
+	}
+	// Slices, maps and funcs are not comparable; a key holding one in an
+	// interface would already have panicked on ==.
+}
+
+func writeUint(h *maphash.Hash, x uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], x)
+	h.Write(buf[:])
+}
+
+// floatBits returns the bits of f with -0 folded into +0, since the two
+// compare equal.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// mix is the splitmix64 finalizer; it keeps sequential integer keys from
+// landing in neighbouring shards.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
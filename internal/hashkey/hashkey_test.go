@@ -0,0 +1,51 @@
+package hashkey
+
+import (
+	"math"
+	"testing"
+)
+
+type label struct{ name string }
+
+func (l *label) String() string { return l.name }
+
+type point struct {
+	x, y float64
+	tag  any
+}
+
+func TestEqualKeysHashEqually(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	if Of(negZero) != Of(0.0) {
+		t.Error("float64 -0 and +0 hash differently")
+	}
+	if Of(float32(negZero)) != Of(float32(0)) {
+		t.Error("float32 -0 and +0 hash differently")
+	}
+	if Of(point{x: negZero}) != Of(point{}) {
+		t.Error("struct with -0 field hashes differently from +0")
+	}
+	if Of(any(point{tag: 1})) != Of(any(point{tag: 1})) {
+		t.Error("equal interface keys hash differently")
+	}
+	if Of([2]string{"ab", "c"}) == Of([2]string{"a", "bc"}) {
+		t.Error("string boundaries are not part of the hash")
+	}
+}
+
+func TestPointerKeysHashByIdentity(t *testing.T) {
+	l := &label{name: "before"}
+	h := Of(l)
+	l.name = "after"
+	if Of(l) != h {
+		t.Error("pointer hash changed with the pointee")
+	}
+	if Of(&label{name: "after"}) == h {
+		t.Error("distinct pointers with equal contents share a hash")
+	}
+
+	ch := make(chan int)
+	if Of(ch) != Of(ch) || Of(any(ch)) != Of(ch) {
+		t.Error("channel hash is not stable")
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/orderedmap"
 )
 
 // Function to greet a person
@@ -36,14 +38,13 @@ func main() {
 	numbers := []int{1, 2, 3, 4, 5}
 	fmt.Println("\nNumbers:", numbers)
 
-	// Demonstrate map (key-value pairs)
-	colors := map[string]string{
-		"red":   "#ff0000",
-		"green": "#00ff00",
-		"blue":  "#0000ff",
-	}
+	// Demonstrate map (key-value pairs) that keeps insertion order
+	colors := orderedmap.New[string, string]()
+	colors.Set("red", "#ff0000")
+	colors.Set("green", "#00ff00")
+	colors.Set("blue", "#0000ff")
 	fmt.Println("\nColors:")
-	for name, hex := range colors {
+	for name, hex := range colors.All() {
 		fmt.Printf("%s: %s\n", name, hex)
 	}
 }
@@ -0,0 +1,271 @@
+package adaptive_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/adaptive"
+)
+
+// fixed is an Algorithm with a settable limit that records its samples.
+type fixed struct {
+	mu      sync.Mutex
+	limit   int
+	samples []adaptive.Sample
+}
+
+func (f *fixed) Limit() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.limit
+}
+
+func (f *fixed) Update(s adaptive.Sample) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.samples = append(f.samples, s)
+	return f.limit
+}
+
+// last returns the latest sample and how many there are.
+func (f *fixed) last() (adaptive.Sample, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.samples) == 0 {
+		return adaptive.Sample{}, 0
+	}
+	return f.samples[len(f.samples)-1], len(f.samples)
+}
+
+func TestAcquireFIFO(t *testing.T) {
+	lim := adaptive.New(&fixed{limit: 1})
+	held, _ := lim.TryAcquire()
+
+	type admitted struct {
+		id  int
+		tok *adaptive.Token
+	}
+	order := make(chan admitted)
+	for id := range 3 {
+		go func() {
+			tok, err := lim.Acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			order <- admitted{id, tok}
+		}()
+		// Let each waiter queue before the next one.
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := lim.TryAcquire(); ok {
+		t.Fatal("TryAcquire jumped the queue")
+	}
+
+	held.Success()
+	for want := range 3 {
+		a := <-order
+		if a.id != want {
+			t.Fatalf("waiter %d admitted, want %d", a.id, want)
+		}
+		if n := lim.InFlight(); n != 1 {
+			t.Fatalf("InFlight = %d, want 1", n)
+		}
+		a.tok.Success()
+	}
+	if n := lim.InFlight(); n != 0 {
+		t.Fatalf("InFlight = %d after every token was released", n)
+	}
+}
+
+func TestAcquireCancel(t *testing.T) {
+	lim := adaptive.New(&fixed{limit: 1})
+	held, _ := lim.TryAcquire()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := lim.Acquire(ctx)
+		cancelled <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	next := make(chan *adaptive.Token, 1)
+	go func() {
+		tok, err := lim.Acquire(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		next <- tok
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Acquire = %v", err)
+	}
+	// The slot skips the waiter that gave up.
+	held.Ignore()
+	tok := <-next
+	if n := lim.InFlight(); n != 1 {
+		t.Fatalf("InFlight = %d, want only the live waiter", n)
+	}
+	tok.Ignore()
+	if _, ok := lim.TryAcquire(); !ok {
+		t.Fatal("slot lost to the cancelled waiter")
+	}
+}
+
+func TestTokenFinishesOnce(t *testing.T) {
+	alg := &fixed{limit: 2}
+	lim := adaptive.New(alg)
+	tok, _ := lim.TryAcquire()
+	tok.Dropped()
+	tok.Success()
+	if s, n := alg.last(); n != 1 || !s.Dropped || s.InFlight != 1 {
+		t.Fatalf("samples after two finishes: last %+v of %d", s, n)
+	}
+	if n := lim.InFlight(); n != 0 {
+		t.Fatalf("InFlight = %d", n)
+	}
+}
+
+func TestLimitAtLeastOne(t *testing.T) {
+	alg := &fixed{limit: 3}
+	lim := adaptive.New(alg)
+	tok, _ := lim.TryAcquire()
+	alg.mu.Lock()
+	alg.limit = 0
+	alg.mu.Unlock()
+	tok.Success()
+	if got := lim.Limit(); got != 1 {
+		t.Fatalf("Limit = %d, want the floor of 1", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		status  int
+		cancel  bool
+		sample  bool
+		dropped bool
+	}{
+		{"ok", http.StatusOK, false, true, false},
+		{"client error", http.StatusBadRequest, false, true, false},
+		{"unavailable", http.StatusServiceUnavailable, false, true, true},
+		{"gateway timeout", http.StatusGatewayTimeout, false, true, true},
+		{"client gone", http.StatusOK, true, false, false},
+	} {
+		alg := &fixed{limit: 1}
+		lim := adaptive.New(alg)
+		h := adaptive.Middleware(lim)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if tt.cancel {
+			cancel()
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		cancel()
+
+		s, n := alg.last()
+		if (n == 1) != tt.sample || s.Dropped != tt.dropped {
+			t.Errorf("%s: %d samples, last %+v; want sample %v, dropped %v", tt.name, n, s, tt.sample, tt.dropped)
+		}
+		if lim.InFlight() != 0 {
+			t.Errorf("%s: token not released", tt.name)
+		}
+	}
+}
+
+func TestMiddlewareSheds(t *testing.T) {
+	lim := adaptive.New(&fixed{limit: 1})
+	held, _ := lim.TryAcquire()
+	defer held.Ignore()
+
+	called := false
+	h := adaptive.Middleware(lim)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || called {
+		t.Fatalf("request over the limit = %d, handler called %v; want 503 without calling it", rec.Code, called)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransport(t *testing.T) {
+	netErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	for _, tt := range []struct {
+		name    string
+		status  int
+		err     error
+		sample  bool
+		dropped bool
+	}{
+		{"ok", http.StatusOK, nil, true, false},
+		{"not found", http.StatusNotFound, nil, true, false},
+		{"too many requests", http.StatusTooManyRequests, nil, true, true},
+		{"unavailable", http.StatusServiceUnavailable, nil, true, true},
+		{"gateway timeout", http.StatusGatewayTimeout, nil, true, true},
+		{"network error", 0, netErr, true, true},
+		{"deadline", 0, context.DeadlineExceeded, true, true},
+		{"canceled", 0, context.Canceled, false, false},
+		{"other error", 0, errors.New("bad request body"), false, false},
+	} {
+		alg := &fixed{limit: 1}
+		lim := adaptive.New(alg)
+		tr := adaptive.NewTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+			if tt.err != nil {
+				return nil, tt.err
+			}
+			return &http.Response{StatusCode: tt.status, Body: http.NoBody}, nil
+		}), lim)
+
+		_, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://backend/", nil))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: RoundTrip = %v, want %v", tt.name, err, tt.err)
+		}
+		s, n := alg.last()
+		if (n == 1) != tt.sample || s.Dropped != tt.dropped {
+			t.Errorf("%s: %d samples, last %+v; want sample %v, dropped %v", tt.name, n, s, tt.sample, tt.dropped)
+		}
+		if lim.InFlight() != 0 {
+			t.Errorf("%s: token not released", tt.name)
+		}
+	}
+}
+
+func TestTransportFull(t *testing.T) {
+	lim := adaptive.New(&fixed{limit: 1})
+	held, _ := lim.TryAcquire()
+	defer held.Ignore()
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Error("request sent over the limit")
+		return nil, errors.New("unreachable")
+	})
+
+	tr := adaptive.NewTransport(base, lim)
+	tr.FailFast = true
+	if _, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://backend/", nil)); !errors.Is(err, adaptive.ErrLimitExceeded) {
+		t.Fatalf("FailFast RoundTrip = %v, want ErrLimitExceeded", err)
+	}
+
+	tr.FailFast = false
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "http://backend/", nil).WithContext(ctx)
+	if _, err := tr.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("waiting RoundTrip = %v, want the deadline", err)
+	}
+}
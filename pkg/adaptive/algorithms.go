@@ -0,0 +1,233 @@
+package adaptive
+
+import (
+	"math"
+	"time"
+)
+
+// bounds clamps a limit between a minimum and maximum.
+type bounds struct {
+	min, max int
+}
+
+func newBounds(minLimit, maxLimit int) bounds {
+	b := bounds{min: max(minLimit, 1), max: maxLimit}
+	if b.max <= 0 {
+		b.max = 1000
+	}
+	b.max = max(b.max, b.min)
+	return b
+}
+
+func (b bounds) clamp(limit float64) float64 {
+	return math.Min(math.Max(limit, float64(b.min)), float64(b.max))
+}
+
+// appLimited reports whether too few requests were in flight for the
+// sample to say anything about a higher limit.
+func appLimited(s Sample, limit float64) bool {
+	return float64(s.InFlight)*2 < limit
+}
+
+// AIMDConfig configures NewAIMD. Zero fields take the defaults shown.
+type AIMDConfig struct {
+	Initial int           // 20
+	Min     int           // 1
+	Max     int           // 1000
+	Backoff float64       // 0.9, the factor applied on a drop
+	Timeout time.Duration // RTTs above this count as drops; 0 disables
+}
+
+// AIMD is additive-increase, multiplicative-decrease.
+type AIMD struct {
+	cfg   AIMDConfig
+	b     bounds
+	limit float64
+}
+
+// NewAIMD returns an AIMD algorithm.
+func NewAIMD(cfg AIMDConfig) *AIMD {
+	if cfg.Initial <= 0 {
+		cfg.Initial = 20
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = 0.9
+	}
+	b := newBounds(cfg.Min, cfg.Max)
+	return &AIMD{cfg: cfg, b: b, limit: b.clamp(float64(cfg.Initial))}
+}
+
+// Limit implements Algorithm.
+func (a *AIMD) Limit() int { return int(a.limit) }
+
+// Update implements Algorithm.
+func (a *AIMD) Update(s Sample) int {
+	switch {
+	case s.Dropped || (a.cfg.Timeout > 0 && s.RTT > a.cfg.Timeout):
+		a.limit *= a.cfg.Backoff
+	case !appLimited(s, a.limit):
+		// One more per limit's worth of samples: one per round trip.
+		a.limit += 1 / a.limit
+	}
+	a.limit = a.b.clamp(a.limit)
+	return int(a.limit)
+}
+
+// VegasConfig configures NewVegas. Zero fields take the defaults shown.
+type VegasConfig struct {
+	Initial int // 20
+	Min     int // 1
+	Max     int // 1000
+	// ProbeEvery resets the minimum RTT after this many limits' worth of
+	// samples so that a permanently slower downstream is noticed. 30.
+	ProbeEvery int
+}
+
+// Vegas estimates the downstream queue as limit × (1 − minRTT/RTT) and
+// keeps it between alpha and beta, which grow with log10 of the limit.
+type Vegas struct {
+	cfg     VegasConfig
+	b       bounds
+	limit   float64
+	minRTT  time.Duration
+	samples int
+}
+
+// NewVegas returns a Vegas algorithm.
+func NewVegas(cfg VegasConfig) *Vegas {
+	if cfg.Initial <= 0 {
+		cfg.Initial = 20
+	}
+	if cfg.ProbeEvery <= 0 {
+		cfg.ProbeEvery = 30
+	}
+	b := newBounds(cfg.Min, cfg.Max)
+	return &Vegas{cfg: cfg, b: b, limit: b.clamp(float64(cfg.Initial))}
+}
+
+// Limit implements Algorithm.
+func (v *Vegas) Limit() int { return int(v.limit) }
+
+// Update implements Algorithm.
+func (v *Vegas) Update(s Sample) int {
+	v.samples++
+	if v.samples > v.cfg.ProbeEvery*int(v.limit) {
+		v.samples = 0
+		v.minRTT = 0
+	}
+	if s.RTT > 0 && (v.minRTT == 0 || s.RTT < v.minRTT) {
+		v.minRTT = s.RTT
+	}
+
+	step := math.Max(1, math.Log10(v.limit))
+	alpha, beta := 3*step, 6*step
+	switch {
+	case s.Dropped:
+		v.limit -= step
+	case v.minRTT > 0 && s.RTT > 0:
+		queue := v.limit * (1 - float64(v.minRTT)/float64(s.RTT))
+		switch {
+		case queue > beta:
+			v.limit -= step
+		case queue < alpha && !appLimited(s, v.limit):
+			v.limit += step
+		}
+	}
+	v.limit = v.b.clamp(v.limit)
+	return int(v.limit)
+}
+
+// GradientConfig configures NewGradient. Zero fields take the defaults
+// shown.
+type GradientConfig struct {
+	Initial int // 20
+	Min     int // 1
+	Max     int // 1000
+	// Tolerance is how much the short-term RTT may exceed the long-term
+	// RTT before the limit shrinks. 1.5.
+	Tolerance float64
+	// Smoothing weights each new limit estimate. 0.2.
+	Smoothing float64
+	// LongWindow is how many round trips the baseline RTT takes to
+	// follow a slower downstream. 3000.
+	LongWindow int
+}
+
+// Gradient multiplies the limit by baseline RTT × tolerance ÷ current
+// RTT, clamped to [0.5, 1], and adds √limit of headroom for queueing.
+// Samples are batched into round trips of limit samples each, and the
+// current RTT is a batch's average. The baseline drops to any faster
+// round trip at once but rises towards slower ones only gradually, so
+// queueing delay shows up as a gradient below one instead of being
+// absorbed into the baseline.
+type Gradient struct {
+	cfg      GradientConfig
+	b        bounds
+	limit    float64
+	baseline float64
+
+	// current batch
+	n          int
+	sum        float64
+	dropped    bool
+	appLimited bool
+}
+
+// NewGradient returns a Gradient algorithm.
+func NewGradient(cfg GradientConfig) *Gradient {
+	if cfg.Initial <= 0 {
+		cfg.Initial = 20
+	}
+	if cfg.Tolerance < 1 {
+		cfg.Tolerance = 1.5
+	}
+	if cfg.Smoothing <= 0 || cfg.Smoothing > 1 {
+		cfg.Smoothing = 0.2
+	}
+	if cfg.LongWindow <= 0 {
+		cfg.LongWindow = 3000
+	}
+	b := newBounds(cfg.Min, cfg.Max)
+	return &Gradient{
+		cfg:        cfg,
+		b:          b,
+		limit:      b.clamp(float64(cfg.Initial)),
+		appLimited: true,
+	}
+}
+
+// Limit implements Algorithm.
+func (g *Gradient) Limit() int { return int(g.limit) }
+
+// Update implements Algorithm.
+func (g *Gradient) Update(s Sample) int {
+	g.n++
+	g.sum += float64(s.RTT)
+	g.dropped = g.dropped || s.Dropped
+	g.appLimited = g.appLimited && appLimited(s, g.limit)
+	if g.n < int(g.limit) {
+		return int(g.limit)
+	}
+
+	rtt := g.sum / float64(g.n)
+	dropped, limited := g.dropped, g.appLimited
+	g.n, g.sum, g.dropped, g.appLimited = 0, 0, false, true
+
+	if g.baseline == 0 || rtt < g.baseline {
+		g.baseline = rtt
+	} else {
+		g.baseline += (rtt - g.baseline) / float64(g.cfg.LongWindow)
+	}
+
+	gradient := 0.5
+	if !dropped && rtt > 0 {
+		gradient = math.Max(0.5, math.Min(1, g.cfg.Tolerance*g.baseline/rtt))
+	}
+	if limited && gradient == 1 {
+		return int(g.limit)
+	}
+
+	estimate := g.limit*gradient + math.Sqrt(g.limit)
+	g.limit = g.b.clamp(g.limit*(1-g.cfg.Smoothing) + estimate*g.cfg.Smoothing)
+	return int(g.limit)
+}
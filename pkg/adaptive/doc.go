@@ -0,0 +1,25 @@
+// Package adaptive limits concurrency to what a downstream can sustain,
+// discovering that limit from measured round-trip times instead of a
+// fixed setting. It generalises the AdaptiveLimiter idea from the rate
+// limiting chapter from request rate to requests in flight.
+//
+// A Limiter hands out Tokens up to its current limit. Each finished
+// request reports its outcome on the token, and the limiter's Algorithm
+// turns those samples into a new limit:
+//
+//   - AIMD grows the limit by one per round trip and cuts it by a
+//     factor on drops or timeouts, like TCP Reno
+//   - Vegas compares RTTs with the best seen RTT to estimate the queue
+//     building up downstream, like TCP Vegas
+//   - Gradient scales the limit by the ratio of baseline to current RTT,
+//     following Netflix's gradient limiters
+//
+// Middleware sheds load on a server with 503 responses, and Transport
+// limits an http.Client's outgoing requests:
+//
+//	lim := adaptive.New(adaptive.NewGradient(adaptive.GradientConfig{}))
+//	http.ListenAndServe(":8080", adaptive.Middleware(lim)(mux))
+//
+// The loadsim subpackage drives a Limiter against a simulated overloaded
+// backend to show how each algorithm converges.
+package adaptive
@@ -0,0 +1,108 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Middleware sheds requests with 503 Service Unavailable once lim is
+// full. Responses with status 503 or 504 from next count as drops, so
+// overload further down the stack lowers the limit too.
+func Middleware(lim *Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tok, ok := lim.TryAcquire()
+			if !ok {
+				http.Error(w, "server overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				switch {
+				case r.Context().Err() != nil:
+					tok.Ignore()
+				case overloaded(rec.status):
+					tok.Dropped()
+				default:
+					tok.Success()
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Transport is an http.RoundTripper that limits concurrent requests to
+// Base. It waits for a slot, or fails fast with ErrLimitExceeded if
+// FailFast is set. Timeouts, connection errors and 429, 503 and 504
+// responses count as drops.
+type Transport struct {
+	Base     http.RoundTripper // http.DefaultTransport if nil
+	Limiter  *Limiter
+	FailFast bool
+}
+
+// NewTransport returns a Transport that limits base with lim.
+func NewTransport(base http.RoundTripper, lim *Limiter) *Transport {
+	return &Transport{Base: base, Limiter: lim}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var tok *Token
+	if t.FailFast {
+		var ok bool
+		if tok, ok = t.Limiter.TryAcquire(); !ok {
+			return nil, ErrLimitExceeded
+		}
+	} else {
+		var err error
+		if tok, err = t.Limiter.Acquire(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		tok.Ignore()
+	case err != nil:
+		var ne net.Error
+		if errors.As(err, &ne) || errors.Is(err, context.DeadlineExceeded) {
+			tok.Dropped()
+		} else {
+			tok.Ignore()
+		}
+	case overloaded(resp.StatusCode) || resp.StatusCode == http.StatusTooManyRequests:
+		tok.Dropped()
+	default:
+		// The RTT is measured to the response headers, which is what the
+		// downstream's queueing affects.
+		tok.Success()
+	}
+	return resp, err
+}
+
+func overloaded(status int) bool {
+	return status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
@@ -0,0 +1,187 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+// ErrLimitExceeded is returned when a request is shed because the limit
+// is reached.
+var ErrLimitExceeded = errors.New("adaptive: concurrency limit exceeded")
+
+// Sample describes one finished request.
+type Sample struct {
+	RTT      time.Duration
+	InFlight int  // requests in flight when this one started, itself included
+	Dropped  bool // the request failed in a way that signals overload
+}
+
+// Algorithm computes a concurrency limit from samples. The Limiter
+// serialises calls, so implementations need no locking.
+type Algorithm interface {
+	// Update returns the new limit after a sample.
+	Update(s Sample) int
+	// Limit returns the current limit.
+	Limit() int
+}
+
+type options struct {
+	now func() time.Time
+}
+
+// Option configures a Limiter.
+type Option func(*options)
+
+// WithClock replaces time.Now, which is useful in simulations.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Limiter admits requests while fewer than the algorithm's limit are in
+// flight.
+type Limiter struct {
+	alg Algorithm
+	now func() time.Time
+
+	mu       sync.Mutex
+	limit    int
+	inflight int
+	waiters  containers.Queue[*waiter]
+}
+
+type waiter struct {
+	ready chan struct{}
+	token *Token
+	gone  bool // gave up before being admitted
+}
+
+// New returns a Limiter driven by alg.
+func New(alg Algorithm, opts ...Option) *Limiter {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Limiter{alg: alg, now: o.now, limit: alg.Limit()}
+}
+
+// Limit returns the current concurrency limit.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// InFlight returns the number of outstanding tokens.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// TryAcquire returns a token if the limit allows another request now.
+func (l *Limiter) TryAcquire() (*Token, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= l.limit || l.waiters.Len() > 0 {
+		return nil, false
+	}
+	return l.admitLocked(), true
+}
+
+// Acquire waits in FIFO order until the limit allows another request or
+// ctx ends.
+func (l *Limiter) Acquire(ctx context.Context) (*Token, error) {
+	l.mu.Lock()
+	if l.inflight < l.limit && l.waiters.Len() == 0 {
+		t := l.admitLocked()
+		l.mu.Unlock()
+		return t, nil
+	}
+	w := &waiter{ready: make(chan struct{})}
+	l.waiters.Enqueue(w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return w.token, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Admitted while giving up; hand the slot back unused.
+			l.releaseLocked(nil)
+		default:
+			w.gone = true
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (l *Limiter) admitLocked() *Token {
+	l.inflight++
+	return &Token{l: l, start: l.now(), inflight: l.inflight}
+}
+
+// releaseLocked ends a request, feeds its sample to the algorithm and
+// admits waiters that now fit.
+func (l *Limiter) releaseLocked(s *Sample) {
+	l.inflight--
+	if s != nil {
+		l.limit = max(l.alg.Update(*s), 1)
+	}
+	for l.inflight < l.limit {
+		w, ok := l.waiters.Dequeue()
+		if !ok {
+			return
+		}
+		if w.gone {
+			continue
+		}
+		w.token = l.admitLocked()
+		close(w.ready)
+	}
+}
+
+// Token is permission for one request. Exactly one of Success, Dropped or
+// Ignore must be called when the request ends; later calls are no-ops.
+type Token struct {
+	l        *Limiter
+	start    time.Time
+	inflight int
+	once     sync.Once
+}
+
+// Success records a completed request and its RTT.
+func (t *Token) Success() { t.finish(false, true) }
+
+// Dropped records a request that failed because the downstream is
+// overloaded, such as a timeout or a 503.
+func (t *Token) Dropped() { t.finish(true, true) }
+
+// Ignore releases the token without a sample, for requests whose RTT
+// says nothing about load, such as validation failures.
+func (t *Token) Ignore() { t.finish(false, false) }
+
+func (t *Token) finish(dropped, sample bool) {
+	t.once.Do(func() {
+		l := t.l
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !sample {
+			l.releaseLocked(nil)
+			return
+		}
+		l.releaseLocked(&Sample{
+			RTT:      l.now().Sub(t.start),
+			InFlight: t.inflight,
+			Dropped:  dropped,
+		})
+	})
+}
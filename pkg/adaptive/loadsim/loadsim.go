@@ -0,0 +1,278 @@
+// Package loadsim drives an adaptive.Limiter against a simulated backend
+// that is offered more load than it can serve, to check that the limit
+// converges near the backend's real capacity.
+//
+// The simulation is a deterministic discrete-event model running on
+// virtual time, so minutes of traffic take milliseconds and a given seed
+// always produces the same report. The backend has a fixed number of
+// servers and an unbounded FIFO queue, and it keeps working on requests
+// whose clients have already timed out. Without a limiter the queue grows
+// until nearly every request times out: congestion collapse.
+package loadsim
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/adaptive"
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+// Config describes a simulation run. Zero fields take the defaults shown.
+type Config struct {
+	Servers     int           // parallel servers in the backend, 20
+	ServiceTime time.Duration // mean service time, uniform ±25%, 50ms
+	ArrivalRate float64       // offered requests per second, Poisson, 600
+	Timeout     time.Duration // client timeout, counted as a drop, 500ms
+	Duration    time.Duration // simulated time, 60s
+	Interval    time.Duration // report granularity, 5s
+	Seed        uint64
+}
+
+// Interval summarises one slice of the run.
+type Interval struct {
+	End       time.Duration
+	Limit     int // at the end of the interval; 0 without a limiter
+	Completed int // responses received before the client timed out
+	TimedOut  int
+	Rejected  int // shed by the limiter
+	P99       time.Duration
+}
+
+// Report is the outcome of Run.
+type Report struct {
+	Config    Config
+	Intervals []Interval
+	Completed int
+	TimedOut  int
+	Rejected  int
+	P50, P99  time.Duration
+}
+
+// Goodput is the rate of successful responses per second.
+func (r Report) Goodput() float64 {
+	return float64(r.Completed) / r.Config.Duration.Seconds()
+}
+
+// Converged reports whether the limit stayed within [lo, hi] for the
+// second half of the run.
+func (r Report) Converged(lo, hi int) bool {
+	for _, iv := range r.Intervals[len(r.Intervals)/2:] {
+		if iv.Limit < lo || iv.Limit > hi {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report as a table.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%8s %6s %9s %9s %9s %9s\n", "time", "limit", "completed", "timed out", "rejected", "p99")
+	for _, iv := range r.Intervals {
+		fmt.Fprintf(&b, "%8v %6d %9d %9d %9d %9v\n", iv.End, iv.Limit, iv.Completed, iv.TimedOut, iv.Rejected, iv.P99)
+	}
+	fmt.Fprintf(&b, "goodput %.0f/s, p50 %v, p99 %v, %d timed out, %d rejected\n",
+		r.Goodput(), r.P50, r.P99, r.TimedOut, r.Rejected)
+	return b.String()
+}
+
+// Capacity is the backend's maximum throughput in requests per second.
+func (c Config) Capacity() float64 {
+	return float64(c.Servers) / c.ServiceTime.Seconds()
+}
+
+func (c Config) withDefaults() Config {
+	if c.Servers <= 0 {
+		c.Servers = 20
+	}
+	if c.ServiceTime <= 0 {
+		c.ServiceTime = 50 * time.Millisecond
+	}
+	if c.ArrivalRate <= 0 {
+		c.ArrivalRate = 600
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 500 * time.Millisecond
+	}
+	if c.Duration <= 0 {
+		c.Duration = 60 * time.Second
+	}
+	if c.Interval <= 0 {
+		c.Interval = 5 * time.Second
+	}
+	return c
+}
+
+type eventKind int
+
+const (
+	arrive eventKind = iota
+	complete
+	timeout
+	tick
+)
+
+type event struct {
+	at   time.Duration
+	seq  int // breaks ties in scheduling order
+	kind eventKind
+	req  *request
+}
+
+type request struct {
+	start   time.Duration
+	token   *adaptive.Token
+	settled bool // the client has seen a response or given up
+}
+
+type sim struct {
+	cfg    Config
+	rng    *rand.Rand
+	now    time.Duration
+	seq    int
+	events *containers.PriorityQueue[event]
+	lim    *adaptive.Limiter
+
+	busy  int
+	queue containers.Queue[*request]
+
+	cur       Interval
+	latencies []time.Duration
+	all       []time.Duration
+	report    Report
+}
+
+// Run simulates cfg with the limiter built by newLimiter, which receives
+// the simulation's clock and must pass it to adaptive.WithClock. A nil
+// newLimiter runs without limiting.
+func Run(cfg Config, newLimiter func(now func() time.Time) *adaptive.Limiter) Report {
+	cfg = cfg.withDefaults()
+	s := &sim{
+		cfg: cfg,
+		rng: rand.New(rand.NewPCG(cfg.Seed, 0)),
+		events: containers.NewPriorityQueue(func(a, b event) bool {
+			if a.at != b.at {
+				return a.at < b.at
+			}
+			return a.seq < b.seq
+		}),
+		report: Report{Config: cfg},
+	}
+	epoch := time.Unix(0, 0)
+	if newLimiter != nil {
+		s.lim = newLimiter(func() time.Time { return epoch.Add(s.now) })
+	}
+
+	s.schedule(0, arrive, nil)
+	s.schedule(cfg.Interval, tick, nil)
+	for {
+		ev, ok := s.events.Pop()
+		if !ok || ev.at > cfg.Duration {
+			break
+		}
+		s.now = ev.at
+		switch ev.kind {
+		case arrive:
+			s.arrive()
+		case complete:
+			s.complete(ev.req)
+		case timeout:
+			s.timeout(ev.req)
+		case tick:
+			s.flush()
+			s.schedule(s.now+cfg.Interval, tick, nil)
+		}
+	}
+	s.report.P50 = percentile(s.all, 0.50)
+	s.report.P99 = percentile(s.all, 0.99)
+	return s.report
+}
+
+func (s *sim) schedule(at time.Duration, kind eventKind, req *request) {
+	s.seq++
+	s.events.Push(event{at: at, seq: s.seq, kind: kind, req: req})
+}
+
+func (s *sim) arrive() {
+	gap := time.Duration(s.rng.ExpFloat64() / s.cfg.ArrivalRate * float64(time.Second))
+	s.schedule(s.now+gap, arrive, nil)
+
+	req := &request{start: s.now}
+	if s.lim != nil {
+		tok, ok := s.lim.TryAcquire()
+		if !ok {
+			s.cur.Rejected++
+			s.report.Rejected++
+			return
+		}
+		req.token = tok
+	}
+	s.schedule(s.now+s.cfg.Timeout, timeout, req)
+	if s.busy < s.cfg.Servers {
+		s.serve(req)
+	} else {
+		s.queue.Enqueue(req)
+	}
+}
+
+func (s *sim) serve(req *request) {
+	s.busy++
+	jitter := 0.75 + 0.5*s.rng.Float64()
+	s.schedule(s.now+time.Duration(float64(s.cfg.ServiceTime)*jitter), complete, req)
+}
+
+func (s *sim) complete(req *request) {
+	s.busy--
+	if !req.settled {
+		req.settled = true
+		if req.token != nil {
+			req.token.Success()
+		}
+		latency := s.now - req.start
+		s.latencies = append(s.latencies, latency)
+		s.all = append(s.all, latency)
+		s.cur.Completed++
+		s.report.Completed++
+	}
+	// The backend cannot tell that a queued request's client has gone,
+	// so it serves it anyway.
+	if next, ok := s.queue.Dequeue(); ok {
+		s.serve(next)
+	}
+}
+
+func (s *sim) timeout(req *request) {
+	if req.settled {
+		return
+	}
+	req.settled = true
+	if req.token != nil {
+		req.token.Dropped()
+	}
+	s.cur.TimedOut++
+	s.report.TimedOut++
+}
+
+func (s *sim) flush() {
+	s.cur.End = s.now
+	if s.lim != nil {
+		s.cur.Limit = s.lim.Limit()
+	}
+	s.cur.P99 = percentile(s.latencies, 0.99)
+	s.report.Intervals = append(s.report.Intervals, s.cur)
+	s.cur = Interval{}
+	s.latencies = s.latencies[:0]
+}
+
+func percentile(d []time.Duration, p float64) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(d)
+	slices.Sort(sorted)
+	return sorted[int(p*float64(len(sorted)-1))].Round(time.Millisecond)
+}
@@ -0,0 +1,65 @@
+package loadsim_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/adaptive"
+	"github.com/thanhnamdk2710/go-handbook/pkg/adaptive/loadsim"
+)
+
+func limiter(alg func() adaptive.Algorithm) func(func() time.Time) *adaptive.Limiter {
+	return func(now func() time.Time) *adaptive.Limiter {
+		return adaptive.New(alg(), adaptive.WithClock(now))
+	}
+}
+
+func TestWithoutLimiterCollapses(t *testing.T) {
+	cfg := loadsim.Config{Seed: 1}
+	r := loadsim.Run(cfg, nil)
+	if r.Goodput() > 0.1*r.Config.Capacity() {
+		t.Fatalf("goodput %.0f/s without a limiter; the scenario no longer overloads the backend\n%v", r.Goodput(), r)
+	}
+}
+
+func TestAlgorithmsConverge(t *testing.T) {
+	algorithms := []struct {
+		name string
+		new  func() adaptive.Algorithm
+	}{
+		{"AIMD", func() adaptive.Algorithm {
+			return adaptive.NewAIMD(adaptive.AIMDConfig{Timeout: 100 * time.Millisecond})
+		}},
+		{"Vegas", func() adaptive.Algorithm { return adaptive.NewVegas(adaptive.VegasConfig{}) }},
+		{"Gradient", func() adaptive.Algorithm { return adaptive.NewGradient(adaptive.GradientConfig{}) }},
+	}
+	for _, a := range algorithms {
+		t.Run(a.name, func(t *testing.T) {
+			for _, seed := range []uint64{1, 2, 3} {
+				r := loadsim.Run(loadsim.Config{Seed: seed}, limiter(a.new))
+				servers := r.Config.Servers
+
+				// The backend is saturated with Servers requests in flight;
+				// allow room for the queueing each algorithm probes with.
+				if lo, hi := servers/2, servers*3; !r.Converged(lo, hi) {
+					t.Errorf("seed %d: limit left [%d, %d] in the second half\n%v", seed, lo, hi, r)
+				}
+				if want := 0.85 * r.Config.Capacity(); r.Goodput() < want {
+					t.Errorf("seed %d: goodput %.0f/s, want at least %.0f/s\n%v", seed, r.Goodput(), want, r)
+				}
+				if r.TimedOut > r.Completed/100 {
+					t.Errorf("seed %d: %d of %d requests timed out\n%v", seed, r.TimedOut, r.Completed, r)
+				}
+			}
+		})
+	}
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	newLimiter := limiter(func() adaptive.Algorithm { return adaptive.NewVegas(adaptive.VegasConfig{}) })
+	a := loadsim.Run(loadsim.Config{Seed: 7, Duration: 10 * time.Second}, newLimiter)
+	b := loadsim.Run(loadsim.Config{Seed: 7, Duration: 10 * time.Second}, newLimiter)
+	if a.String() != b.String() {
+		t.Fatalf("same seed produced different reports:\n%v\n%v", a, b)
+	}
+}
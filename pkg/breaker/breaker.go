@@ -0,0 +1,344 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+var (
+	// ErrOpen is matched by the errors returned for calls rejected by an
+	// open or fully probing breaker.
+	ErrOpen = errors.New("breaker: circuit open")
+	// ErrBulkheadFull is returned when the concurrency cap is reached.
+	ErrBulkheadFull = errors.New("breaker: bulkhead full")
+)
+
+// OpenError reports a call rejected by the breaker. It matches ErrOpen.
+type OpenError struct {
+	Name  string
+	State State
+	// RetryAfter is how long the breaker stays open, or zero when it is
+	// half-open and all probe slots are taken.
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	if e.State == HalfOpen {
+		return fmt.Sprintf("breaker: circuit %q is half-open and probing", e.Name)
+	}
+	return fmt.Sprintf("breaker: circuit %q is open, retry after %v", e.Name, e.RetryAfter)
+}
+
+func (e *OpenError) Unwrap() error { return ErrOpen }
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Metrics is a snapshot of a Breaker.
+type Metrics struct {
+	State       State
+	Calls       int // calls in the window, or probes finished when half-open
+	Failures    int
+	SlowCalls   int
+	FailureRate float64
+	SlowRate    float64
+	Concurrent  int // calls holding a bulkhead slot
+}
+
+// Breaker is a circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	name string
+	o    options
+	sem  chan struct{}
+
+	mu         sync.Mutex
+	state      State
+	gen        uint64 // bumped on every transition so stale outcomes are dropped
+	openedAt   time.Time
+	win        window
+	probesOut  int // probes admitted in the current half-open period
+	probesDone int
+	probe      counts
+}
+
+// New returns a closed Breaker. The name identifies it in errors and
+// state change hooks.
+func New(name string, opts ...Option) *Breaker {
+	o := options{
+		countWindow: 100,
+		minCalls:    10,
+		failureRate: 0.5,
+		openTimeout: 30 * time.Second,
+		probes:      3,
+		isFailure:   defaultIsFailure,
+		clock:       clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.minCalls = max(o.minCalls, 1)
+	o.probes = max(o.probes, 1)
+
+	b := &Breaker{name: name, o: o}
+	if o.timeWindow > 0 {
+		b.win = newTimeWindow(o.timeWindow)
+	} else {
+		b.win = newCountWindow(o.countWindow)
+	}
+	if o.bulkhead > 0 {
+		b.sem = make(chan struct{}, o.bulkhead)
+	}
+	return b
+}
+
+// Name returns the breaker's name.
+func (b *Breaker) Name() string { return b.name }
+
+// State returns the current state. An open breaker reports Open until the
+// first call after its timeout moves it to HalfOpen.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Metrics returns a snapshot of the breaker's counters.
+func (b *Breaker) Metrics() Metrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.win.totals(b.o.clock.Now())
+	if b.state == HalfOpen {
+		c = b.probe
+	}
+	m := Metrics{State: b.state, Calls: c.calls, Failures: c.failures, SlowCalls: c.slow}
+	if c.calls > 0 {
+		m.FailureRate = float64(c.failures) / float64(c.calls)
+		m.SlowRate = float64(c.slow) / float64(c.calls)
+	}
+	if b.sem != nil {
+		m.Concurrent = len(b.sem)
+	}
+	return m
+}
+
+// Reset closes the breaker and forgets recorded outcomes.
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.transition(Closed, b.o.clock.Now())
+}
+
+// Permit is an admitted call. Exactly one of Done or Ignore must be
+// called when the call finishes; later calls are no-ops.
+type Permit struct {
+	b        *Breaker
+	gen      uint64
+	start    time.Time
+	finished atomic.Bool
+}
+
+// Done records the call's outcome and releases its bulkhead slot.
+func (p *Permit) Done(err error) {
+	p.finish(p.b.o.isFailure(err))
+}
+
+func (p *Permit) finish(failed bool) {
+	if !p.finished.CompareAndSwap(false, true) {
+		return
+	}
+	b := p.b
+	elapsed := b.o.clock.Since(p.start)
+	b.record(p.gen, outcome{
+		failed: failed,
+		slow:   b.o.slowThreshold > 0 && elapsed > b.o.slowThreshold,
+	})
+	b.release()
+}
+
+// Ignore releases the call without recording an outcome, for calls that
+// say nothing about the dependency's health.
+func (p *Permit) Ignore() {
+	if !p.finished.CompareAndSwap(false, true) {
+		return
+	}
+	p.b.unadmit(p.gen)
+	p.b.release()
+}
+
+// Allow admits a call or returns an *OpenError, ErrBulkheadFull or the
+// context's error. Use it when the call cannot be expressed as a
+// function, such as in a RoundTripper; otherwise prefer Execute.
+func (b *Breaker) Allow(ctx context.Context) (*Permit, error) {
+	gen, err := b.admit()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.acquire(ctx); err != nil {
+		b.unadmit(gen)
+		return nil, err
+	}
+	return &Permit{b: b, gen: gen, start: b.o.clock.Now()}, nil
+}
+
+// Execute runs fn if the breaker admits it and records the result. A
+// panic in fn counts as a failure and is re-raised.
+func (b *Breaker) Execute(ctx context.Context, fn func(context.Context) error) error {
+	_, err := Execute(ctx, b, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// Execute runs fn through b and returns its result.
+func Execute[T any](ctx context.Context, b *Breaker, fn func(context.Context) (T, error)) (T, error) {
+	p, err := b.Allow(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.Done(fmt.Errorf("breaker: panic: %v", r))
+			panic(r)
+		}
+	}()
+	v, err := fn(ctx)
+	p.Done(err)
+	return v, err
+}
+
+func (b *Breaker) admit() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.o.clock.Now()
+	if b.state == Open {
+		if wait := b.o.openTimeout - now.Sub(b.openedAt); wait > 0 {
+			return 0, &OpenError{Name: b.name, State: Open, RetryAfter: wait}
+		}
+		b.transition(HalfOpen, now)
+	}
+	if b.state == HalfOpen {
+		if b.probesOut >= b.o.probes {
+			return 0, &OpenError{Name: b.name, State: HalfOpen}
+		}
+		b.probesOut++
+	}
+	return b.gen, nil
+}
+
+// unadmit gives back a probe slot taken by a call that never counted.
+func (b *Breaker) unadmit(gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen == b.gen && b.state == HalfOpen {
+		b.probesOut--
+	}
+}
+
+func (b *Breaker) record(gen uint64, o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.gen {
+		return
+	}
+	now := b.o.clock.Now()
+	switch b.state {
+	case Closed:
+		b.win.record(now, o)
+		if c := b.win.totals(now); c.calls >= b.o.minCalls && b.tripped(c) {
+			b.transition(Open, now)
+		}
+	case HalfOpen:
+		b.probesDone++
+		b.probe.add(o, 1)
+		if b.probesDone >= b.o.probes {
+			if b.tripped(b.probe) {
+				b.transition(Open, now)
+			} else {
+				b.transition(Closed, now)
+			}
+		}
+	}
+}
+
+func (b *Breaker) tripped(c counts) bool {
+	if c.calls == 0 {
+		return false
+	}
+	calls := float64(c.calls)
+	return (b.o.failureRate > 0 && float64(c.failures)/calls >= b.o.failureRate) ||
+		(b.o.slowRate > 0 && float64(c.slow)/calls >= b.o.slowRate)
+}
+
+// transition must be called with b.mu held.
+func (b *Breaker) transition(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.gen++
+	switch to {
+	case Closed:
+		b.win.reset()
+	case Open:
+		b.openedAt = now
+	case HalfOpen:
+		b.probesOut, b.probesDone, b.probe = 0, 0, counts{}
+	}
+	if b.o.onStateChange != nil && from != to {
+		b.o.onStateChange(b.name, from, to)
+	}
+}
+
+func (b *Breaker) acquire(ctx context.Context) error {
+	if b.sem == nil {
+		return nil
+	}
+	select {
+	case b.sem <- struct{}{}:
+		return nil
+	default:
+	}
+	if b.o.bulkheadWait <= 0 {
+		return ErrBulkheadFull
+	}
+	t := b.o.clock.NewTimer(b.o.bulkheadWait)
+	defer t.Stop()
+	select {
+	case b.sem <- struct{}{}:
+		return nil
+	case <-t.C():
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Breaker) release() {
+	if b.sem != nil {
+		<-b.sem
+	}
+}
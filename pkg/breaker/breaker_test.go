@@ -0,0 +1,276 @@
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/breaker"
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+var errBoom = errors.New("boom")
+
+// call runs one call through b that returns err.
+func call(b *breaker.Breaker, err error) error {
+	return b.Execute(context.Background(), func(context.Context) error { return err })
+}
+
+// transitions returns an option recording state changes and a function
+// returning them.
+func transitions() (breaker.Option, func() []string) {
+	var log []string
+	opt := breaker.WithOnStateChange(func(_ string, from, to breaker.State) {
+		log = append(log, fmt.Sprintf("%v->%v", from, to))
+	})
+	return opt, func() []string { return slices.Clone(log) }
+}
+
+func TestStateMachine(t *testing.T) {
+	fc := clock.NewFake(time.Unix(1000, 0))
+	onChange, changes := transitions()
+	b := breaker.New("db",
+		breaker.WithClock(fc),
+		breaker.WithCountWindow(4),
+		breaker.WithMinCalls(4),
+		breaker.WithOpenTimeout(10*time.Second),
+		breaker.WithProbes(2),
+		onChange,
+	)
+
+	for _, err := range []error{nil, nil, errBoom} {
+		call(b, err)
+	}
+	if b.State() != breaker.Closed {
+		t.Fatal("opened before the window held MinCalls calls")
+	}
+	call(b, errBoom)
+	if b.State() != breaker.Open {
+		t.Fatalf("State = %v at a 50%% failure rate, want open", b.State())
+	}
+
+	fc.Advance(4 * time.Second)
+	ran := false
+	err := b.Execute(context.Background(), func(context.Context) error { ran = true; return nil })
+	var oe *breaker.OpenError
+	if !errors.As(err, &oe) || !errors.Is(err, breaker.ErrOpen) || oe.RetryAfter != 6*time.Second || ran {
+		t.Fatalf("call while open = %v (ran %v), want an OpenError to retry after 6s", err, ran)
+	}
+
+	// After the timeout the next call probes; only WithProbes may be out.
+	fc.Advance(6 * time.Second)
+	var probes []*breaker.Permit
+	for range 2 {
+		p, err := b.Allow(context.Background())
+		if err != nil {
+			t.Fatalf("probe rejected: %v", err)
+		}
+		probes = append(probes, p)
+	}
+	if _, err := b.Allow(context.Background()); !errors.As(err, &oe) || oe.State != breaker.HalfOpen || oe.RetryAfter != 0 {
+		t.Fatalf("third probe = %v, want a half-open OpenError", err)
+	}
+	probes[0].Done(nil)
+	if m := b.Metrics(); m.State != breaker.HalfOpen || m.Calls != 1 {
+		t.Fatalf("Metrics mid-probe = %+v", m)
+	}
+	probes[1].Done(nil)
+
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if got := changes(); !slices.Equal(got, want) {
+		t.Fatalf("transitions %v, want %v", got, want)
+	}
+	if m := b.Metrics(); m.Calls != 0 {
+		t.Fatalf("closing kept old outcomes: %+v", m)
+	}
+}
+
+func TestFailedProbeReopens(t *testing.T) {
+	fc := clock.NewFake(time.Unix(1000, 0))
+	b := breaker.New("db", breaker.WithClock(fc), breaker.WithMinCalls(1), breaker.WithProbes(1))
+	call(b, errBoom)
+	fc.Advance(30 * time.Second)
+
+	// An ignored probe gives its slot back.
+	p, err := b.Allow(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Ignore()
+	if err := call(b, errBoom); !errors.Is(err, errBoom) {
+		t.Fatalf("probe after an ignored one = %v", err)
+	}
+	if b.State() != breaker.Open {
+		t.Fatalf("State after a failed probe = %v, want open", b.State())
+	}
+	if err := call(b, nil); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("call after reopening = %v, want a full new timeout", err)
+	}
+}
+
+func TestCountWindowSlides(t *testing.T) {
+	b := breaker.New("db", breaker.WithCountWindow(4), breaker.WithMinCalls(4), breaker.WithFailureRate(0.75))
+	for i, err := range []error{errBoom, errBoom, nil, nil, errBoom, errBoom} {
+		call(b, err)
+		if b.State() != breaker.Closed {
+			t.Fatalf("opened after call %d", i)
+		}
+	}
+	// The window is now nil, nil, boom, boom.
+	if m := b.Metrics(); m.Calls != 4 || m.Failures != 2 || m.FailureRate != 0.5 {
+		t.Fatalf("Metrics = %+v, want the last 4 calls", m)
+	}
+	call(b, errBoom)
+	if b.State() != breaker.Open {
+		t.Fatal("did not open at 3 failures in the last 4 calls")
+	}
+}
+
+func TestTimeWindowExpires(t *testing.T) {
+	fc := clock.NewFake(time.Unix(1000, 0))
+	b := breaker.New("db", breaker.WithClock(fc), breaker.WithTimeWindow(10*time.Second), breaker.WithMinCalls(3))
+
+	call(b, errBoom)
+	call(b, errBoom)
+	fc.Advance(11 * time.Second)
+	call(b, nil)
+	if m := b.Metrics(); m.Calls != 1 || m.Failures != 0 {
+		t.Fatalf("Metrics = %+v, want the old failures expired", m)
+	}
+
+	call(b, errBoom)
+	fc.Advance(5 * time.Second)
+	if b.State() != breaker.Closed {
+		t.Fatal("opened below MinCalls")
+	}
+	call(b, errBoom)
+	if b.State() != breaker.Open {
+		t.Fatalf("State = %v with 2 of 3 calls failed in the window, want open", b.State())
+	}
+}
+
+func TestSlowCalls(t *testing.T) {
+	fc := clock.NewFake(time.Unix(1000, 0))
+	b := breaker.New("db",
+		breaker.WithClock(fc),
+		breaker.WithMinCalls(3),
+		breaker.WithFailureRate(0),
+		breaker.WithSlowCalls(100*time.Millisecond, 0.6),
+	)
+	slow := func(context.Context) error {
+		fc.Advance(200 * time.Millisecond)
+		return nil
+	}
+
+	call(b, nil)
+	b.Execute(context.Background(), slow)
+	if m := b.Metrics(); m.SlowCalls != 1 || m.SlowRate != 0.5 || m.State != breaker.Closed {
+		t.Fatalf("Metrics = %+v, want 1 of 2 calls slow", m)
+	}
+	b.Execute(context.Background(), slow)
+	if b.State() != breaker.Open {
+		t.Fatalf("State = %v with 2 of 3 calls slow, want open", b.State())
+	}
+
+	// A zero failure rate disables that check.
+	b.Reset()
+	for range 4 {
+		call(b, errBoom)
+	}
+	if b.State() != breaker.Closed {
+		t.Fatal("failures opened a breaker with the failure rate disabled")
+	}
+}
+
+func TestBulkhead(t *testing.T) {
+	b := breaker.New("db", breaker.WithBulkhead(1, 0))
+	held, err := b.Allow(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Allow(context.Background()); !errors.Is(err, breaker.ErrBulkheadFull) {
+		t.Fatalf("Allow with the bulkhead full = %v", err)
+	}
+	if m := b.Metrics(); m.Concurrent != 1 || m.Calls != 0 {
+		t.Fatalf("Metrics = %+v; a rejected call must not count", m)
+	}
+	held.Done(nil)
+	held.Done(errBoom) // no-op
+	if m := b.Metrics(); m.Concurrent != 0 || m.Calls != 1 || m.Failures != 0 {
+		t.Fatalf("Metrics after Done = %+v", m)
+	}
+}
+
+func TestBulkheadWait(t *testing.T) {
+	fc := clock.NewFake(time.Unix(1000, 0))
+	b := breaker.New("db", breaker.WithClock(fc), breaker.WithBulkhead(1, time.Second))
+	held, _ := b.Allow(context.Background())
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := b.Allow(context.Background())
+		result <- err
+	}()
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	if err := <-result; !errors.Is(err, breaker.ErrBulkheadFull) {
+		t.Fatalf("Allow after waiting out maxWait = %v", err)
+	}
+
+	go func() {
+		p, err := b.Allow(context.Background())
+		if err == nil {
+			p.Done(nil)
+		}
+		result <- err
+	}()
+	fc.BlockUntil(1)
+	held.Done(nil)
+	if err := <-result; err != nil {
+		t.Fatalf("Allow once a slot freed = %v", err)
+	}
+
+	held, _ = b.Allow(context.Background())
+	defer held.Done(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_, err := b.Allow(ctx)
+		result <- err
+	}()
+	fc.BlockUntil(1)
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Allow = %v", err)
+	}
+}
+
+func TestOutcomesNotCounted(t *testing.T) {
+	b := breaker.New("db", breaker.WithMinCalls(1))
+	call(b, context.Canceled)
+	if m := b.Metrics(); m.Calls != 1 || m.Failures != 0 {
+		t.Fatalf("Metrics = %+v; context.Canceled must not be a failure", m)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "bad" {
+				t.Fatalf("recovered %v, want the panic re-raised", r)
+			}
+		}()
+		b.Execute(context.Background(), func(context.Context) error { panic("bad") })
+	}()
+	if b.State() != breaker.Open {
+		t.Fatal("a panic did not count as a failure")
+	}
+}
+
+func TestExecuteResult(t *testing.T) {
+	b := breaker.New("db")
+	v, err := breaker.Execute(context.Background(), b, func(context.Context) (int, error) { return 42, nil })
+	if v != 42 || err != nil {
+		t.Fatalf("Execute = %d, %v", v, err)
+	}
+}
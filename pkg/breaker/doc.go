@@ -0,0 +1,27 @@
+// Package breaker implements the circuit breaker pattern sketched in the
+// error handling chapter: stop calling a dependency that keeps failing,
+// give it time to recover, then let a few probe calls through before
+// trusting it again.
+//
+// A Breaker is Closed while calls succeed. Outcomes are kept in a sliding
+// window of the last N calls or the last D of time, and once the window
+// holds enough calls and the failure rate or the slow-call rate crosses
+// its threshold, the breaker opens. Open breakers reject calls with
+// ErrOpen until the open timeout passes, then turn HalfOpen and admit a
+// limited number of probes. If the probes do well the breaker closes,
+// otherwise it opens again.
+//
+//	b := breaker.New("payments",
+//	    breaker.WithTimeWindow(time.Minute),
+//	    breaker.WithFailureRate(0.5),
+//	    breaker.WithSlowCalls(2*time.Second, 0.8),
+//	    breaker.WithBulkhead(20, 0),
+//	)
+//	charge, err := breaker.Execute(ctx, b, func(ctx context.Context) (*Charge, error) {
+//	    return gateway.Charge(ctx, req)
+//	})
+//
+// Transport and WrapConnector apply a breaker to HTTP clients and
+// database/sql connections. WithBulkhead additionally caps concurrent
+// calls so a slow dependency cannot tie up every goroutine.
+package breaker
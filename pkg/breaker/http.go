@@ -0,0 +1,51 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Transport is an http.RoundTripper that sends requests to Base through
+// Breaker. Rejected requests fail with the breaker's error without
+// reaching the network.
+type Transport struct {
+	Base    http.RoundTripper // http.DefaultTransport if nil
+	Breaker *Breaker
+
+	// IsFailure classifies a round trip. By default transport errors,
+	// judged by the breaker's WithIsFailure, and 5xx responses are
+	// failures.
+	IsFailure func(resp *http.Response, err error) bool
+}
+
+// NewTransport returns a Transport that guards base with b.
+func NewTransport(base http.RoundTripper, b *Breaker) *Transport {
+	return &Transport{Base: base, Breaker: b}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p, err := t.Breaker.Allow(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	switch {
+	case t.IsFailure != nil:
+		p.finish(t.IsFailure(resp, err))
+	case err != nil && errors.Is(err, context.Canceled):
+		p.Ignore()
+	case err != nil:
+		p.Done(err)
+	default:
+		// Timing stops at the response headers; reading the body is up
+		// to the caller.
+		p.finish(resp.StatusCode >= http.StatusInternalServerError)
+	}
+	return resp, err
+}
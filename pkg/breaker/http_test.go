@@ -0,0 +1,72 @@
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/breaker"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransport(t *testing.T) {
+	status, sent := http.StatusOK, 0
+	var failure error
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		sent++
+		if failure != nil {
+			return nil, failure
+		}
+		return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+	})
+	b := breaker.New("api", breaker.WithMinCalls(4))
+	tr := breaker.NewTransport(base, b)
+	get := func() error {
+		_, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://api/", nil))
+		return err
+	}
+
+	get()
+	status = http.StatusNotFound
+	get()
+	failure = context.Canceled
+	get()
+	if m := b.Metrics(); m.Calls != 2 || m.Failures != 0 {
+		t.Fatalf("Metrics = %+v; 4xx must succeed and cancellation not count", m)
+	}
+
+	failure = errors.New("connection reset")
+	get()
+	failure, status = nil, http.StatusBadGateway
+	get()
+	if m := b.Metrics(); m.State != breaker.Open || m.Failures != 2 {
+		t.Fatalf("Metrics = %+v, want open after a transport error and a 502", m)
+	}
+
+	before := sent
+	if err := get(); !errors.Is(err, breaker.ErrOpen) || sent != before {
+		t.Fatalf("request while open = %v, sent %d; want ErrOpen without sending", err, sent-before)
+	}
+}
+
+func TestTransportIsFailure(t *testing.T) {
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody}, nil
+	})
+	b := breaker.New("api", breaker.WithMinCalls(1))
+	tr := breaker.NewTransport(base, b)
+	tr.IsFailure = func(resp *http.Response, err error) bool {
+		return err != nil || resp.StatusCode == http.StatusTooManyRequests
+	}
+	if _, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://api/", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if b.State() != breaker.Open {
+		t.Fatal("IsFailure not consulted")
+	}
+}
@@ -0,0 +1,123 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+type options struct {
+	countWindow   int
+	timeWindow    time.Duration
+	minCalls      int
+	failureRate   float64
+	slowThreshold time.Duration
+	slowRate      float64
+	openTimeout   time.Duration
+	probes        int
+	bulkhead      int
+	bulkheadWait  time.Duration
+	isFailure     func(error) bool
+	onStateChange func(name string, from, to State)
+	clock         clock.Clock
+}
+
+// Option configures a Breaker.
+type Option func(*options)
+
+// WithCountWindow evaluates the last n calls. This is the default, with
+// n = 100.
+func WithCountWindow(n int) Option {
+	return func(o *options) {
+		o.countWindow, o.timeWindow = n, 0
+	}
+}
+
+// WithTimeWindow evaluates the calls of the last d, in ten buckets of
+// d/10 each.
+func WithTimeWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.timeWindow, o.countWindow = d, 0
+	}
+}
+
+// WithMinCalls sets how many calls the window must hold before rates are
+// evaluated. The default is 10.
+func WithMinCalls(n int) Option {
+	return func(o *options) {
+		o.minCalls = n
+	}
+}
+
+// WithFailureRate opens the breaker when at least rate of the calls in
+// the window failed. The default is 0.5.
+func WithFailureRate(rate float64) Option {
+	return func(o *options) {
+		o.failureRate = rate
+	}
+}
+
+// WithSlowCalls counts calls taking longer than threshold as slow and
+// opens the breaker when at least rate of the calls in the window were
+// slow. Slow calls are not counted by default.
+func WithSlowCalls(threshold time.Duration, rate float64) Option {
+	return func(o *options) {
+		o.slowThreshold, o.slowRate = threshold, rate
+	}
+}
+
+// WithOpenTimeout sets how long the breaker stays open before probing.
+// The default is 30 seconds.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = d
+	}
+}
+
+// WithProbes sets how many calls the half-open state admits. The
+// breaker decides whether to close once all of them have finished. The
+// default is 3.
+func WithProbes(n int) Option {
+	return func(o *options) {
+		o.probes = n
+	}
+}
+
+// WithBulkhead caps concurrent calls at n. A call waits up to maxWait for
+// a free slot before failing with ErrBulkheadFull; zero fails at once.
+func WithBulkhead(n int, maxWait time.Duration) Option {
+	return func(o *options) {
+		o.bulkhead, o.bulkheadWait = n, maxWait
+	}
+}
+
+// WithIsFailure decides which errors count as failures. By default every
+// error except context.Canceled does, since a caller giving up says
+// nothing about the dependency.
+func WithIsFailure(fn func(error) bool) Option {
+	return func(o *options) {
+		o.isFailure = fn
+	}
+}
+
+// WithOnStateChange calls fn after every state transition. It runs
+// synchronously and must not call back into the breaker.
+func WithOnStateChange(fn func(name string, from, to State)) Option {
+	return func(o *options) {
+		o.onStateChange = fn
+	}
+}
+
+// WithClock sets the clock used for durations and timeouts. The default
+// is clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+func defaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
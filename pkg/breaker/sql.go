@@ -0,0 +1,281 @@
+package breaker
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+)
+
+// WrapConnector returns a connector whose connections run through b.
+// Connecting, pinging, preparing, beginning transactions and executing
+// statements are guarded; reading rows and committing are not.
+//
+//	db := sql.OpenDB(breaker.WrapConnector(connector, b))
+//
+// driver.ErrBadConn counts as a failure like any other error, while
+// driver.ErrSkip, which only asks database/sql to take a fallback path,
+// is not recorded.
+func WrapConnector(c driver.Connector, b *Breaker) driver.Connector {
+	return &connector{Connector: c, b: b}
+}
+
+// WrapDriver returns a driver whose connections run through b, for use
+// with sql.Register. Prefer WrapConnector when the driver exposes one.
+func WrapDriver(d driver.Driver, b *Breaker) driver.Driver {
+	return &wrappedDriver{d: d, b: b}
+}
+
+// guard runs fn through b, leaving driver.ErrSkip unrecorded.
+func guard[T any](ctx context.Context, b *Breaker, fn func() (T, error)) (T, error) {
+	p, err := b.Allow(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	v, err := fn()
+	if errors.Is(err, driver.ErrSkip) {
+		p.Ignore()
+	} else {
+		p.Done(err)
+	}
+	return v, err
+}
+
+type wrappedDriver struct {
+	d driver.Driver
+	b *Breaker
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	return guard(context.Background(), d.b, func() (driver.Conn, error) {
+		c, err := d.d.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return &conn{c: c, b: d.b}, nil
+	})
+}
+
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.d.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{Connector: c, b: d.b, drv: d}, nil
+	}
+	return &connector{Connector: dsnConnector{name: name, d: d.d}, b: d.b, drv: d}, nil
+}
+
+type dsnConnector struct {
+	name string
+	d    driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.name) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.d }
+
+type connector struct {
+	driver.Connector
+	b   *Breaker
+	drv driver.Driver // reported by Driver when set
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return guard(ctx, c.b, func() (driver.Conn, error) {
+		cn, err := c.Connector.Connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &conn{c: cn, b: c.b}, nil
+	})
+}
+
+func (c *connector) Driver() driver.Driver {
+	if c.drv != nil {
+		return c.drv
+	}
+	return &wrappedDriver{d: c.Connector.Driver(), b: c.b}
+}
+
+// conn implements every optional connection interface and falls back,
+// or returns driver.ErrSkip, when the wrapped connection does not.
+type conn struct {
+	c driver.Conn
+	b *Breaker
+}
+
+var (
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return guard(ctx, c.b, func() (driver.Stmt, error) {
+		var s driver.Stmt
+		var err error
+		if pc, ok := c.c.(driver.ConnPrepareContext); ok {
+			s, err = pc.PrepareContext(ctx, query)
+		} else {
+			s, err = c.c.Prepare(query)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &stmt{s: s, b: c.b}, nil
+	})
+}
+
+func (c *conn) Close() error { return c.c.Close() }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return guard(ctx, c.b, func() (driver.Tx, error) {
+		if bt, ok := c.c.(driver.ConnBeginTx); ok {
+			return bt.BeginTx(ctx, opts)
+		}
+		if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+			return nil, errors.New("breaker: driver does not support transaction options")
+		}
+		return c.c.Begin()
+	})
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.c.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return guard(ctx, c.b, func() (driver.Result, error) {
+		return ec.ExecContext(ctx, query, args)
+	})
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.c.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return guard(ctx, c.b, func() (driver.Rows, error) {
+		return qc.QueryContext(ctx, query, args)
+	})
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	p, ok := c.c.(driver.Pinger)
+	if !ok {
+		return nil
+	}
+	_, err := guard(ctx, c.b, func() (struct{}, error) {
+		return struct{}{}, p.Ping(ctx)
+	})
+	return err
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.c.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.c.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.c.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	s driver.Stmt
+	b *Breaker
+}
+
+var (
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+)
+
+func (s *stmt) Close() error  { return s.s.Close() }
+func (s *stmt) NumInput() int { return s.s.NumInput() }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesToNamed(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return guard(ctx, s.b, func() (driver.Result, error) {
+		if ec, ok := s.s.(driver.StmtExecContext); ok {
+			return ec.ExecContext(ctx, args)
+		}
+		values, err := namedToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.s.Exec(values)
+	})
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return guard(ctx, s.b, func() (driver.Rows, error) {
+		if qc, ok := s.s.(driver.StmtQueryContext); ok {
+			return qc.QueryContext(ctx, args)
+		}
+		values, err := namedToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.s.Query(values)
+	})
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.s.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func valuesToNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func namedToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("breaker: driver does not support named parameters")
+		}
+		values[i] = a.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,141 @@
+package breaker_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/breaker"
+)
+
+// fakeDB is an in-memory driver whose statements fail while err is set.
+// Its connections implement ExecerContext but not QueryerContext, so
+// queries go through Prepare.
+type fakeDB struct {
+	mu       sync.Mutex
+	err      error
+	connects int
+	execs    int
+}
+
+func (d *fakeDB) fail(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.err = err
+}
+
+func (d *fakeDB) failure() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+func (d *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.connects++
+	return &fakeConn{db: d}, nil
+}
+
+func (d *fakeDB) Driver() driver.Driver { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if err := c.db.failure(); err != nil {
+		return nil, err
+	}
+	return fakeStmt{}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if err := c.db.failure(); err != nil {
+		return nil, err
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.execs++
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+
+type fakeRows struct{ done bool }
+
+func (*fakeRows) Columns() []string { return []string{"n"} }
+func (*fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestWrapConnector(t *testing.T) {
+	ctx := context.Background()
+	fdb := &fakeDB{}
+	b := breaker.New("db", breaker.WithMinCalls(6), breaker.WithFailureRate(0.25))
+	db := sql.OpenDB(breaker.WrapConnector(fdb, b))
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(ctx, "UPDATE t SET n = 1"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT n FROM t").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("query = %d, %v", n, err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	// Connect, exec, prepare, query and begin each count; the query's
+	// ErrSkip from the connection does not.
+	if m := b.Metrics(); m.Calls != 5 || m.Failures != 0 {
+		t.Fatalf("Metrics = %+v, want 5 successful calls", m)
+	}
+
+	errDown := errors.New("database down")
+	fdb.fail(errDown)
+	for range 2 {
+		if _, err := db.ExecContext(ctx, "UPDATE t SET n = 2"); !errors.Is(err, errDown) {
+			t.Fatalf("Exec = %v, want %v", err, errDown)
+		}
+	}
+	if b.State() != breaker.Open {
+		t.Fatalf("Metrics = %+v, want open", b.Metrics())
+	}
+
+	fdb.fail(nil)
+	if _, err := db.ExecContext(ctx, "UPDATE t SET n = 3"); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("Exec while open = %v, want ErrOpen", err)
+	}
+	if err := db.QueryRowContext(ctx, "SELECT n FROM t").Scan(&n); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("query while open = %v, want ErrOpen", err)
+	}
+	if fdb.connects != 1 || fdb.execs != 1 {
+		t.Fatalf("driver saw %d connects and %d execs, want 1 each", fdb.connects, fdb.execs)
+	}
+}
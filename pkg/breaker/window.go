@@ -0,0 +1,111 @@
+package breaker
+
+import "time"
+
+// outcome is one finished call.
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+// counts aggregates outcomes.
+type counts struct {
+	calls    int
+	failures int
+	slow     int
+}
+
+func (c *counts) add(o outcome, sign int) {
+	c.calls += sign
+	if o.failed {
+		c.failures += sign
+	}
+	if o.slow {
+		c.slow += sign
+	}
+}
+
+// window keeps the outcomes the breaker evaluates.
+type window interface {
+	record(now time.Time, o outcome)
+	totals(now time.Time) counts
+	reset()
+}
+
+// countWindow holds the last len(ring) outcomes.
+type countWindow struct {
+	ring  []outcome
+	next  int
+	full  bool
+	total counts
+}
+
+func newCountWindow(n int) *countWindow {
+	return &countWindow{ring: make([]outcome, max(n, 1))}
+}
+
+func (w *countWindow) record(_ time.Time, o outcome) {
+	if w.full {
+		w.total.add(w.ring[w.next], -1)
+	}
+	w.ring[w.next] = o
+	w.total.add(o, 1)
+	w.next = (w.next + 1) % len(w.ring)
+	w.full = w.full || w.next == 0
+}
+
+func (w *countWindow) totals(time.Time) counts { return w.total }
+
+func (w *countWindow) reset() {
+	clear(w.ring)
+	w.next, w.full, w.total = 0, false, counts{}
+}
+
+// timeBuckets is the number of buckets a time window is split into.
+const timeBuckets = 10
+
+// timeWindow holds outcomes from the last span of time in buckets, so
+// old outcomes expire a bucket at a time.
+type timeWindow struct {
+	width   time.Duration
+	buckets [timeBuckets]timeBucket
+}
+
+type timeBucket struct {
+	index int64 // which width-sized slot since the zero time it covers
+	counts
+}
+
+func newTimeWindow(span time.Duration) *timeWindow {
+	return &timeWindow{width: max(span/timeBuckets, time.Millisecond)}
+}
+
+func (w *timeWindow) slot(now time.Time) int64 {
+	return now.UnixNano() / int64(w.width)
+}
+
+func (w *timeWindow) record(now time.Time, o outcome) {
+	idx := w.slot(now)
+	b := &w.buckets[idx%timeBuckets]
+	if b.index != idx {
+		*b = timeBucket{index: idx}
+	}
+	b.add(o, 1)
+}
+
+func (w *timeWindow) totals(now time.Time) counts {
+	idx := w.slot(now)
+	var c counts
+	for _, b := range w.buckets {
+		if idx-b.index < timeBuckets {
+			c.calls += b.calls
+			c.failures += b.failures
+			c.slow += b.slow
+		}
+	}
+	return c
+}
+
+func (w *timeWindow) reset() {
+	w.buckets = [timeBuckets]timeBucket{}
+}
@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/internal/hashkey"
+)
+
+// ErrNoLoader is returned by GetOrLoad when neither the call nor the
+// cache provides a loader.
+var ErrNoLoader = errors.New("cache: no loader configured")
+
+// Cache is a concurrency-safe generic cache. Create it with New.
+type Cache[K comparable, V any] struct {
+	opts    options
+	shards  []*shard[K, V]
+	cost    func(K, V) int64
+	loader  Loader[K, V]
+	onEvict func(K, V, Reason)
+	hash    func(K) uint64
+	loads   group[K, V]
+	stats   counters
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// New creates a cache. Without WithMaxEntries or WithMaxCost the cache
+// is unbounded and only shrinks through expiry and Delete.
+func New[K comparable, V any](opts ...Option) *Cache[K, V] {
+	o := options{shards: 1, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.shards = max(o.shards, 1)
+
+	c := &Cache[K, V]{
+		opts:   o,
+		shards: make([]*shard[K, V], o.shards),
+		cost:   typed[func(K, V) int64](o.cost, "WithCost"),
+		loader: typed[Loader[K, V]](o.loader, "WithLoader"),
+		hash:   typed[func(K) uint64](o.hasher, "WithHasher"),
+		stop:   make(chan struct{}),
+	}
+	c.onEvict = typed[func(K, V, Reason)](o.onEvict, "WithOnEvict")
+	if c.hash == nil {
+		c.hash = hashkey.Of[K]
+	}
+
+	maxEntries := ceilDiv(o.maxEntries, o.shards)
+	maxCost := ceilDiv(o.maxCost, int64(o.shards))
+	for i := range c.shards {
+		c.shards[i] = newShard[K, V](o.policy, maxEntries, maxCost)
+	}
+
+	if o.cleanupInterval > 0 {
+		c.wg.Add(1)
+		go c.janitor(o.cleanupInterval)
+	}
+	return c
+}
+
+// Get returns the cached value for key.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	v, ok, expired := c.shard(key).get(key, c.opts.now().UnixNano())
+	c.notify(expired)
+	if ok {
+		c.stats.hits.Add(1)
+	} else {
+		c.stats.misses.Add(1)
+	}
+	return v, ok
+}
+
+// Set stores value using the default TTL. It returns false if the entry
+// alone is more expensive than the shard's cost limit.
+func (c *Cache[K, V]) Set(key K, value V) bool {
+	return c.SetWithTTL(key, value, c.opts.ttl)
+}
+
+// SetWithTTL stores value with its own time to live. A ttl of zero or
+// less keeps the entry until it is evicted or deleted.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	cost := int64(1)
+	if c.cost != nil {
+		cost = c.cost(key, value)
+	}
+	ok, evicted := c.shard(key).set(key, value, cost, expiry(c.opts.now(), ttl))
+	c.notify(evicted)
+	return ok
+}
+
+// Delete removes key and reports whether it was present.
+func (c *Cache[K, V]) Delete(key K) bool {
+	return c.shard(key).delete(key)
+}
+
+// GetOrLoad returns the cached value for key or loads it. Concurrent
+// misses for the same key share a single load. A nil load uses the
+// loader configured with WithLoader. The loaded value is only stored if
+// key is still absent when the load finishes; if a Set stored a value in
+// the meantime, that value wins and is returned.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load Loader[K, V]) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+	if load == nil {
+		load = c.loader
+	}
+	if load == nil {
+		var zero V
+		return zero, ErrNoLoader
+	}
+
+	v, err, _ := c.loads.do(ctx, key, func(ctx context.Context) (V, error) {
+		c.stats.loads.Add(1)
+		v, err := load(ctx, key)
+		if err != nil {
+			c.stats.loadErrors.Add(1)
+			return v, err
+		}
+		// A Set that ran during the load holds a newer value; keep it.
+		cost := int64(1)
+		if c.cost != nil {
+			cost = c.cost(key, v)
+		}
+		now := c.opts.now()
+		v, evicted := c.shard(key).add(key, v, cost, expiry(now, c.opts.ttl), now.UnixNano())
+		c.notify(evicted)
+		return v, nil
+	})
+	return v, err
+}
+
+// DeleteExpired removes all expired entries now instead of waiting for
+// them to be read or for the background cleanup.
+func (c *Cache[K, V]) DeleteExpired() {
+	now := c.opts.now().UnixNano()
+	for _, s := range c.shards {
+		c.notify(s.expire(now))
+	}
+}
+
+// Purge removes every entry without invoking the eviction callback.
+func (c *Cache[K, V]) Purge() {
+	for _, s := range c.shards {
+		s.purge(c.opts.policy)
+	}
+}
+
+// Len returns the number of entries, including expired entries that
+// have not been removed yet.
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		entries, _ := s.size()
+		n += entries
+	}
+	return n
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache[K, V]) Stats() Stats {
+	st := Stats{
+		Hits:        c.stats.hits.Load(),
+		Misses:      c.stats.misses.Load(),
+		Evictions:   c.stats.evictions.Load(),
+		Expirations: c.stats.expirations.Load(),
+		Loads:       c.stats.loads.Load(),
+		LoadErrors:  c.stats.loadErrors.Load(),
+	}
+	for _, s := range c.shards {
+		entries, cost := s.size()
+		st.Entries += entries
+		st.Cost += cost
+	}
+	return st
+}
+
+// Close stops the background cleanup goroutine. The cache remains usable.
+func (c *Cache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+	c.wg.Wait()
+}
+
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[c.hash(key)%uint64(len(c.shards))]
+}
+
+func (c *Cache[K, V]) notify(evicted []eviction[K, V]) {
+	for _, e := range evicted {
+		if e.reason == Expired {
+			c.stats.expirations.Add(1)
+		} else {
+			c.stats.evictions.Add(1)
+		}
+		if c.onEvict != nil {
+			c.onEvict(e.key, e.value, e.reason)
+		}
+	}
+}
+
+func (c *Cache[K, V]) janitor(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// typed asserts a function stored by a generic option. A mismatch means
+// the option was built for different key or value types.
+func typed[F any](fn any, option string) F {
+	var zero F
+	if fn == nil {
+		return zero
+	}
+	f, ok := fn.(F)
+	if !ok {
+		panic("cache: " + option + " does not match the cache's key and value types")
+	}
+	return f
+}
+
+func ceilDiv[T int | int64](a, b T) T {
+	if a <= 0 {
+		return 0
+	}
+	return (a + b - 1) / b
+}
@@ -0,0 +1,297 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/cache"
+)
+
+// evictions returns an option recording evicted keys and where they go.
+func evictions[K comparable, V any]() (cache.Option, func() []K) {
+	var mu sync.Mutex
+	var keys []K
+	opt := cache.WithOnEvict(func(k K, _ V, _ cache.Reason) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, k)
+	})
+	return opt, func() []K {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(keys)
+	}
+}
+
+func TestEvictionOrder(t *testing.T) {
+	tests := []struct {
+		policy cache.PolicyKind
+		// Steps run on a cache holding 3 entries; "+k" sets k, "k" gets it.
+		steps []string
+		want  []string
+	}{
+		{cache.LRU, []string{"+a", "+b", "+c", "a", "+d", "+e"}, []string{"b", "c"}},
+		{cache.LRU, []string{"+a", "+b", "+c", "+a", "+d"}, []string{"b"}},
+		// b and c are read more often than a; d is new and never a victim
+		// of its own insertion.
+		{cache.LFU, []string{"+a", "+b", "+c", "b", "b", "c", "+d", "+e"}, []string{"a", "d"}},
+		{cache.LFU, []string{"+a", "+b", "+c", "a", "b", "c", "+d"}, []string{"a"}},
+		// a is read twice and moves to ARC's frequent list; the recent
+		// list is evicted first.
+		{cache.ARC, []string{"+a", "+b", "+c", "a", "+d", "+e"}, []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		onEvict, evicted := evictions[string, int]()
+		c := cache.New[string, int](cache.WithPolicy(tt.policy), cache.WithMaxEntries(3), onEvict)
+		for _, step := range tt.steps {
+			if key, ok := cutPlus(step); ok {
+				c.Set(key, 0)
+			} else {
+				c.Get(step)
+			}
+		}
+		if got := evicted(); !slices.Equal(got, tt.want) {
+			t.Errorf("%v %v: evicted %v, want %v", tt.policy, tt.steps, got, tt.want)
+		}
+		if c.Len() != 3 {
+			t.Errorf("%v: Len = %d, want 3", tt.policy, c.Len())
+		}
+	}
+}
+
+func cutPlus(step string) (string, bool) {
+	if len(step) > 0 && step[0] == '+' {
+		return step[1:], true
+	}
+	return step, false
+}
+
+func TestARCGhostHitAdapts(t *testing.T) {
+	onEvict, evicted := evictions[string, int]()
+	c := cache.New[string, int](cache.WithPolicy(cache.ARC), cache.WithMaxEntries(2), onEvict)
+	c.Set("a", 0)
+	c.Set("b", 0)
+	c.Get("a") // a is frequent
+	c.Set("c", 0)
+	// b was evicted to the recent ghost list. Setting it again is a ghost
+	// hit that grows the recent target, so the frequent list gives up a.
+	c.Set("b", 0)
+
+	if got := evicted(); !slices.Equal(got, []string{"b", "a"}) {
+		t.Fatalf("evicted %v, want [b a]", got)
+	}
+	for _, k := range []string{"b", "c"} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("%s missing after ghost hit", k)
+		}
+	}
+}
+
+func TestMaxCost(t *testing.T) {
+	onEvict, evicted := evictions[string, string]()
+	c := cache.New[string, string](
+		cache.WithMaxCost(10),
+		cache.WithCost(func(_ string, v string) int64 { return int64(len(v)) }),
+		onEvict,
+	)
+	c.Set("a", "aaaa")
+	c.Set("b", "bbbb")
+	if c.Set("huge", "xxxxxxxxxxxx") {
+		t.Fatal("entry larger than the cost limit was stored")
+	}
+	c.Set("c", "cccc")
+	if got := evicted(); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("evicted %v, want [a]", got)
+	}
+	if st := c.Stats(); st.Cost != 8 || st.Entries != 2 || st.Evictions != 1 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	var reasons []cache.Reason
+	c := cache.New[string, int](
+		cache.WithTTL(time.Minute),
+		cache.WithClock(func() time.Time { return now }),
+		cache.WithOnEvict(func(_ string, _ int, r cache.Reason) { reasons = append(reasons, r) }),
+	)
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, time.Hour)
+	c.SetWithTTL("forever", 3, 0)
+
+	now = now.Add(time.Minute - time.Nanosecond)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a expired early")
+	}
+	now = now.Add(time.Nanosecond)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("a outlived its TTL")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d before cleanup, want expired b still counted", c.Len())
+	}
+	c.DeleteExpired()
+	if _, ok := c.Get("forever"); !ok || c.Len() != 1 {
+		t.Fatalf("Len = %d after DeleteExpired, want only forever", c.Len())
+	}
+	if !slices.Equal(reasons, []cache.Reason{cache.Expired, cache.Expired}) {
+		t.Fatalf("reasons = %v", reasons)
+	}
+	if st := c.Stats(); st.Expirations != 2 || st.Hits != 2 || st.Misses != 1 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestGetOrLoadDeduplicates(t *testing.T) {
+	c := cache.New[string, int]()
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context, string) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make(chan int, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "k", load)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- v
+		}()
+	}
+	// Give the callers time to pile up on the in-flight load.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != 42 {
+			t.Fatalf("GetOrLoad = %d, want 42", v)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader ran %d times, want 1", n)
+	}
+	if v, ok := c.Get("k"); !ok || v != 42 {
+		t.Fatalf("Get after load = %d, %v", v, ok)
+	}
+}
+
+func TestGetOrLoadKeepsNewerSet(t *testing.T) {
+	c := cache.New[string, int]()
+	v, err := c.GetOrLoad(context.Background(), "k", func(context.Context, string) (int, error) {
+		c.Set("k", 2) // a writer races the load
+		return 1, nil
+	})
+	if err != nil || v != 2 {
+		t.Fatalf("GetOrLoad = %d, %v; want the newer 2", v, err)
+	}
+	if got, _ := c.Get("k"); got != 2 {
+		t.Fatalf("load overwrote a newer Set: Get = %d", got)
+	}
+}
+
+func TestGetOrLoadErrors(t *testing.T) {
+	c := cache.New[string, int]()
+	if _, err := c.GetOrLoad(context.Background(), "k", nil); !errors.Is(err, cache.ErrNoLoader) {
+		t.Fatalf("GetOrLoad without loader = %v", err)
+	}
+
+	failure := errors.New("backend down")
+	c = cache.New[string, int](cache.WithLoader(func(context.Context, string) (int, error) {
+		return 0, failure
+	}))
+	if _, err := c.GetOrLoad(context.Background(), "k", nil); !errors.Is(err, failure) {
+		t.Fatalf("GetOrLoad = %v, want %v", err, failure)
+	}
+	if c.Len() != 0 || c.Stats().LoadErrors != 1 {
+		t.Fatalf("failed load cached: Len %d, Stats %+v", c.Len(), c.Stats())
+	}
+}
+
+func TestPointerAndStructKeys(t *testing.T) {
+	type point struct{ x, y int }
+	c := cache.New[*point, string](cache.WithShards(16))
+	p, q := &point{1, 2}, &point{1, 2}
+	c.Set(p, "p")
+	c.Set(q, "q")
+	if v, _ := c.Get(p); v != "p" {
+		t.Fatalf("Get(p) = %q", v)
+	}
+	if v, _ := c.Get(q); v != "q" {
+		t.Fatalf("Get(q) = %q", v)
+	}
+
+	s := cache.New[point, int](cache.WithShards(16))
+	for i := range 100 {
+		s.Set(point{i, -i}, i)
+	}
+	for i := range 100 {
+		if v, ok := s.Get(point{i, -i}); !ok || v != i {
+			t.Fatalf("Get(%d) = %d, %v", i, v, ok)
+		}
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	for _, policy := range []cache.PolicyKind{cache.LRU, cache.LFU, cache.ARC} {
+		c := cache.New[int, int](cache.WithPolicy(policy), cache.WithShards(4), cache.WithMaxEntries(64))
+		var wg sync.WaitGroup
+		for g := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 2000 {
+					k := (i * (g + 1)) % 200
+					switch i % 4 {
+					case 0:
+						c.Set(k, i)
+					case 1:
+						c.Delete(k)
+					default:
+						c.Get(k)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if n := c.Len(); n > 64 {
+			t.Errorf("%v: Len = %d, over the limit", policy, n)
+		}
+	}
+}
+
+func BenchmarkCacheGet(b *testing.B) {
+	for _, policy := range []cache.PolicyKind{cache.LRU, cache.LFU, cache.ARC} {
+		b.Run(fmt.Sprint(policy), func(b *testing.B) {
+			c := cache.New[int, int](cache.WithPolicy(policy), cache.WithShards(16), cache.WithMaxEntries(1024))
+			for i := range 2048 {
+				c.Set(i, i)
+			}
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Get(i % 2048)
+					i++
+				}
+			})
+		})
+	}
+}
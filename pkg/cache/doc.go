@@ -0,0 +1,24 @@
+// Package cache provides a generic in-memory cache that replaces the
+// Cache, GlobalCache, ShardedCache and ConcurrentCache examples from the
+// maps, mutexes and synchronization chapters.
+//
+// A Cache combines:
+//
+//   - pluggable eviction (LRU, LFU or ARC)
+//   - per-entry TTL with lazy expiry on read and optional background cleanup
+//   - limits on entry count and on total cost computed by a cost function
+//   - sharding to reduce lock contention
+//   - hit, miss, eviction and load statistics
+//   - loaders with singleflight de-duplication of concurrent misses
+//
+// Usage:
+//
+//	c := cache.New[string, []byte](
+//	    cache.WithPolicy(cache.LFU),
+//	    cache.WithMaxCost(64<<20),
+//	    cache.WithCost(func(key string, value []byte) int64 { return int64(len(value)) }),
+//	    cache.WithTTL(5*time.Minute),
+//	    cache.WithShards(16),
+//	)
+//	defer c.Close()
+package cache
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// PolicyKind selects the eviction algorithm.
+type PolicyKind int
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU PolicyKind = iota
+	// LFU evicts the least frequently used entry, oldest first on ties.
+	LFU
+	// ARC balances recency and frequency with adaptive replacement.
+	ARC
+)
+
+func (k PolicyKind) String() string {
+	switch k {
+	case LRU:
+		return "LRU"
+	case LFU:
+		return "LFU"
+	case ARC:
+		return "ARC"
+	default:
+		return "unknown"
+	}
+}
+
+// Loader fetches a value that is missing from the cache.
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+type options struct {
+	policy          PolicyKind
+	maxEntries      int
+	maxCost         int64
+	ttl             time.Duration
+	cleanupInterval time.Duration
+	shards          int
+	now             func() time.Time
+
+	// The following hold typed functions and are asserted in New.
+	cost    any
+	loader  any
+	onEvict any
+	hasher  any
+}
+
+// Option configures a Cache.
+type Option func(*options)
+
+// WithPolicy sets the eviction policy. The default is LRU.
+func WithPolicy(kind PolicyKind) Option {
+	return func(o *options) {
+		o.policy = kind
+	}
+}
+
+// WithMaxEntries limits the number of entries across all shards.
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxEntries = n
+	}
+}
+
+// WithMaxCost limits the total cost of all entries. Each entry costs 1
+// unless WithCost is given.
+func WithMaxCost(cost int64) Option {
+	return func(o *options) {
+		o.maxCost = cost
+	}
+}
+
+// WithCost sets the function that computes the cost of an entry.
+func WithCost[K comparable, V any](fn func(key K, value V) int64) Option {
+	return func(o *options) {
+		o.cost = fn
+	}
+}
+
+// WithTTL sets the default time to live for entries added with Set.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithCleanupInterval starts a background goroutine that removes expired
+// entries at the given interval. Call Close to stop it.
+func WithCleanupInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.cleanupInterval = interval
+	}
+}
+
+// WithShards splits the cache into n independently locked shards.
+// Limits are divided evenly between shards.
+func WithShards(n int) Option {
+	return func(o *options) {
+		o.shards = n
+	}
+}
+
+// WithLoader sets the default loader used by GetOrLoad.
+func WithLoader[K comparable, V any](fn Loader[K, V]) Option {
+	return func(o *options) {
+		o.loader = fn
+	}
+}
+
+// WithOnEvict registers a callback invoked when an entry is evicted to
+// respect a limit or removed because it expired. It runs without the
+// shard lock held.
+func WithOnEvict[K comparable, V any](fn func(key K, value V, reason Reason)) Option {
+	return func(o *options) {
+		o.onEvict = fn
+	}
+}
+
+// WithHasher sets the function that maps keys to shards. The default
+// hashes strings and integers directly and walks other keys with
+// reflect, following ==: pointers, channels and interfaces holding them
+// hash by identity and structs and arrays by their fields.
+func WithHasher[K comparable](fn func(key K) uint64) Option {
+	return func(o *options) {
+		o.hasher = fn
+	}
+}
+
+// WithClock replaces time.Now, which is useful in tests.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Reason explains why an entry left the cache.
+type Reason int
+
+const (
+	// Evicted means the entry was removed to respect a size limit.
+	Evicted Reason = iota
+	// Expired means the entry's TTL elapsed.
+	Expired
+)
+
+func (r Reason) String() string {
+	if r == Expired {
+		return "expired"
+	}
+	return "evicted"
+}
@@ -0,0 +1,275 @@
+package cache
+
+import "container/list"
+
+// policy tracks the eviction order of keys in one shard. Implementations
+// are not safe for concurrent use; the shard lock protects them.
+type policy[K comparable] interface {
+	// Add records a newly inserted key.
+	Add(key K)
+	// Access records a read or update of an existing key.
+	Access(key K)
+	// Remove forgets a key that was deleted or expired.
+	Remove(key K)
+	// Evict picks and forgets the next victim.
+	Evict() (K, bool)
+}
+
+func newPolicy[K comparable](kind PolicyKind, capacity int) policy[K] {
+	switch kind {
+	case LFU:
+		return newLFU[K]()
+	case ARC:
+		return newARC[K](capacity)
+	default:
+		return newLRU[K]()
+	}
+}
+
+type lru[K comparable] struct {
+	order *list.List
+	items map[K]*list.Element
+}
+
+func newLRU[K comparable]() *lru[K] {
+	return &lru[K]{
+		order: list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+func (p *lru[K]) Add(key K) {
+	if e, ok := p.items[key]; ok {
+		p.order.MoveToFront(e)
+		return
+	}
+	p.items[key] = p.order.PushFront(key)
+}
+
+func (p *lru[K]) Access(key K) {
+	if e, ok := p.items[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *lru[K]) Remove(key K) {
+	if e, ok := p.items[key]; ok {
+		p.order.Remove(e)
+		delete(p.items, key)
+	}
+}
+
+func (p *lru[K]) Evict() (K, bool) {
+	e := p.order.Back()
+	if e == nil {
+		var zero K
+		return zero, false
+	}
+	key := p.order.Remove(e).(K)
+	delete(p.items, key)
+	return key, true
+}
+
+// lfu implements O(1) LFU: keys are grouped in per-frequency lists and
+// the minimum frequency is tracked so the victim is found directly.
+type lfu[K comparable] struct {
+	items   map[K]*list.Element
+	freqs   map[int]*list.List
+	minFreq int
+}
+
+type lfuEntry[K comparable] struct {
+	key  K
+	freq int
+}
+
+func newLFU[K comparable]() *lfu[K] {
+	return &lfu[K]{
+		items: make(map[K]*list.Element),
+		freqs: make(map[int]*list.List),
+	}
+}
+
+func (p *lfu[K]) Add(key K) {
+	if _, ok := p.items[key]; ok {
+		p.Access(key)
+		return
+	}
+	p.items[key] = p.bucket(1).PushFront(&lfuEntry[K]{key: key, freq: 1})
+	p.minFreq = 1
+}
+
+func (p *lfu[K]) Access(key K) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+
+	entry := e.Value.(*lfuEntry[K])
+	p.unlink(e, entry.freq)
+	if p.minFreq == entry.freq && p.freqs[entry.freq] == nil {
+		p.minFreq++
+	}
+	entry.freq++
+	p.items[key] = p.bucket(entry.freq).PushFront(entry)
+}
+
+func (p *lfu[K]) Remove(key K) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+	p.unlink(e, e.Value.(*lfuEntry[K]).freq)
+	delete(p.items, key)
+	if len(p.items) == 0 {
+		p.minFreq = 0
+	}
+}
+
+func (p *lfu[K]) Evict() (K, bool) {
+	if len(p.items) == 0 {
+		var zero K
+		return zero, false
+	}
+	for p.freqs[p.minFreq] == nil {
+		// minFreq can lag behind after Remove; catch up.
+		p.minFreq++
+	}
+
+	e := p.freqs[p.minFreq].Back()
+	key := e.Value.(*lfuEntry[K]).key
+	p.Remove(key)
+	return key, true
+}
+
+func (p *lfu[K]) bucket(freq int) *list.List {
+	l, ok := p.freqs[freq]
+	if !ok {
+		l = list.New()
+		p.freqs[freq] = l
+	}
+	return l
+}
+
+func (p *lfu[K]) unlink(e *list.Element, freq int) {
+	l := p.freqs[freq]
+	l.Remove(e)
+	if l.Len() == 0 {
+		delete(p.freqs, freq)
+	}
+}
+
+// arc implements Adaptive Replacement Cache. t1 holds keys seen once
+// recently and t2 keys seen at least twice; b1 and b2 are ghost lists of
+// keys recently evicted from each. Hits on ghosts shift the target size
+// p of t1 towards whichever side would have avoided the miss.
+type arc[K comparable] struct {
+	capacity int
+	p        int
+
+	t1, t2, b1, b2 *list.List
+	where          map[K]*list.Element
+	lists          map[*list.Element]*list.List
+}
+
+func newARC[K comparable](capacity int) *arc[K] {
+	return &arc[K]{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		where:    make(map[K]*list.Element),
+		lists:    make(map[*list.Element]*list.List),
+	}
+}
+
+func (p *arc[K]) Add(key K) {
+	e, ok := p.where[key]
+	if !ok {
+		p.push(p.t1, key)
+		p.trimGhosts()
+		return
+	}
+
+	switch p.lists[e] {
+	case p.t1, p.t2:
+		p.Access(key)
+		return
+	case p.b1:
+		delta := max(p.b2.Len()/max(p.b1.Len(), 1), 1)
+		p.p = min(p.p+delta, p.size())
+	case p.b2:
+		delta := max(p.b1.Len()/max(p.b2.Len(), 1), 1)
+		p.p = max(p.p-delta, 0)
+	}
+	p.unlink(e)
+	p.push(p.t2, key)
+}
+
+func (p *arc[K]) Access(key K) {
+	e, ok := p.where[key]
+	if !ok {
+		return
+	}
+	if l := p.lists[e]; l == p.t1 || l == p.t2 {
+		p.unlink(e)
+		p.push(p.t2, key)
+	}
+}
+
+func (p *arc[K]) Remove(key K) {
+	if e, ok := p.where[key]; ok {
+		p.unlink(e)
+	}
+}
+
+func (p *arc[K]) Evict() (K, bool) {
+	from, ghost := p.t2, p.b2
+	if p.t1.Len() > 0 && (p.t1.Len() > p.p || p.t2.Len() == 0) {
+		from, ghost = p.t1, p.b1
+	}
+
+	e := from.Back()
+	if e == nil {
+		var zero K
+		return zero, false
+	}
+	key := e.Value.(K)
+	p.unlink(e)
+	p.push(ghost, key)
+	p.trimGhosts()
+	return key, true
+}
+
+// size is the adaptation bound c. When the cache is limited by cost
+// rather than entry count, the resident size is used instead.
+func (p *arc[K]) size() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return max(p.t1.Len()+p.t2.Len(), 1)
+}
+
+func (p *arc[K]) trimGhosts() {
+	c := p.size()
+	for p.b1.Len() > 0 && p.t1.Len()+p.b1.Len() > c {
+		p.unlink(p.b1.Back())
+	}
+	for p.b2.Len() > 0 && p.t2.Len()+p.b2.Len() > 2*c {
+		p.unlink(p.b2.Back())
+	}
+}
+
+func (p *arc[K]) push(l *list.List, key K) {
+	e := l.PushFront(key)
+	p.where[key] = e
+	p.lists[e] = l
+}
+
+func (p *arc[K]) unlink(e *list.Element) {
+	l := p.lists[e]
+	delete(p.lists, e)
+	delete(p.where, e.Value.(K))
+	l.Remove(e)
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type item[V any] struct {
+	value   V
+	cost    int64
+	expires int64 // Unix nanoseconds; 0 means never
+}
+
+func (it *item[V]) expired(now int64) bool {
+	return it.expires != 0 && now >= it.expires
+}
+
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason Reason
+}
+
+type shard[K comparable, V any] struct {
+	mu         sync.Mutex
+	items      map[K]*item[V]
+	policy     policy[K]
+	cost       int64
+	maxCost    int64
+	maxEntries int
+}
+
+func newShard[K comparable, V any](kind PolicyKind, maxEntries int, maxCost int64) *shard[K, V] {
+	return &shard[K, V]{
+		items:      make(map[K]*item[V]),
+		policy:     newPolicy[K](kind, maxEntries),
+		maxCost:    maxCost,
+		maxEntries: maxEntries,
+	}
+}
+
+// get returns the live value for key. An expired entry is removed and
+// reported through evicted.
+func (s *shard[K, V]) get(key K, now int64) (v V, ok bool, evicted []eviction[K, V]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, found := s.items[key]
+	if !found {
+		return v, false, nil
+	}
+	if it.expired(now) {
+		s.remove(key, it)
+		return v, false, []eviction[K, V]{{key, it.value, Expired}}
+	}
+	s.policy.Access(key)
+	return it.value, true, nil
+}
+
+// set stores the entry and evicts others until the shard fits its
+// limits. It returns false if the entry alone exceeds the cost limit.
+func (s *shard[K, V]) set(key K, value V, cost, expires int64) (bool, []eviction[K, V]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxCost > 0 && cost > s.maxCost {
+		return false, nil
+	}
+	s.store(key, value, cost, expires)
+	return true, s.evict(key)
+}
+
+// add stores the entry only if key has no live entry, and otherwise
+// returns the value already cached.
+func (s *shard[K, V]) add(key K, value V, cost, expires, now int64) (V, []eviction[K, V]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if it, found := s.items[key]; found && !it.expired(now) {
+		s.policy.Access(key)
+		return it.value, nil
+	}
+	if s.maxCost > 0 && cost > s.maxCost {
+		return value, nil
+	}
+	s.store(key, value, cost, expires)
+	return value, s.evict(key)
+}
+
+// store inserts or updates an entry without enforcing the limits.
+func (s *shard[K, V]) store(key K, value V, cost, expires int64) {
+	if it, found := s.items[key]; found {
+		s.cost += cost - it.cost
+		it.value, it.cost, it.expires = value, cost, expires
+		s.policy.Access(key)
+		return
+	}
+	s.items[key] = &item[V]{value: value, cost: cost, expires: expires}
+	s.cost += cost
+	// The policy sees the key before choosing victims, so ARC can adapt
+	// to a hit on its ghost lists.
+	s.policy.Add(key)
+}
+
+func (s *shard[K, V]) delete(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, found := s.items[key]
+	if found {
+		s.remove(key, it)
+	}
+	return found
+}
+
+// expire removes every entry whose TTL has elapsed.
+func (s *shard[K, V]) expire(now int64) []eviction[K, V] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []eviction[K, V]
+	for key, it := range s.items {
+		if it.expired(now) {
+			s.remove(key, it)
+			expired = append(expired, eviction[K, V]{key, it.value, Expired})
+		}
+	}
+	return expired
+}
+
+func (s *shard[K, V]) purge(kind PolicyKind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[K]*item[V])
+	s.policy = newPolicy[K](kind, s.maxEntries)
+	s.cost = 0
+}
+
+func (s *shard[K, V]) size() (int, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items), s.cost
+}
+
+func (s *shard[K, V]) remove(key K, it *item[V]) {
+	delete(s.items, key)
+	s.cost -= it.cost
+	s.policy.Remove(key)
+}
+
+// evict removes entries until the shard fits its limits. keep, the
+// entry just stored, is never chosen: if the policy picks it, it is
+// re-added as a fresh entry once the others have made room.
+func (s *shard[K, V]) evict(keep K) []eviction[K, V] {
+	var evicted []eviction[K, V]
+	skipped := false
+	for (s.maxEntries > 0 && len(s.items) > s.maxEntries) ||
+		(s.maxCost > 0 && s.cost > s.maxCost) {
+		victim, ok := s.policy.Evict()
+		if !ok {
+			break
+		}
+		if victim == keep {
+			skipped = true
+			continue
+		}
+		it := s.items[victim]
+		delete(s.items, victim)
+		s.cost -= it.cost
+		evicted = append(evicted, eviction[K, V]{victim, it.value, Evicted})
+	}
+	if skipped {
+		s.policy.Remove(keep) // drop any ghost entry Evict left behind
+		s.policy.Add(keep)
+	}
+	return evicted
+}
+
+func expiry(now time.Time, ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return now.Add(ttl).UnixNano()
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// group de-duplicates concurrent loads of the same key.
+type group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// do runs fn once per key at a time. Callers that arrive while a load is
+// in flight wait for its result or for their own context to end. The
+// load itself runs detached from any single caller's cancellation so one
+// impatient caller cannot fail the others.
+func (g *group[K, V]) do(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error, bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	c, inFlight := g.calls[key]
+	if !inFlight {
+		c = &call[V]{done: make(chan struct{})}
+		g.calls[key] = c
+		go func() {
+			c.value, c.err = fn(context.WithoutCancel(ctx))
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err, inFlight
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err(), inFlight
+	}
+}
@@ -0,0 +1,33 @@
+package cache
+
+import "sync/atomic"
+
+// Stats is a snapshot of cache counters.
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Loads       uint64
+	LoadErrors  uint64
+	Entries     int
+	Cost        int64
+}
+
+// HitRatio returns hits divided by lookups, or 0 before any lookup.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type counters struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+	loads       atomic.Uint64
+	loadErrors  atomic.Uint64
+}
@@ -0,0 +1,30 @@
+// Package clock abstracts time so that time-dependent code can be tested
+// without sleeping. It grows the TimeProvider and MockTime example from
+// the testing chapter into a full replacement for the time functions used
+// by the timeout, rate limiting and session chapters.
+//
+// Code takes a Clock and uses it wherever it would call the time package:
+//
+//	type Sessions struct {
+//	    clock clock.Clock
+//	}
+//
+//	func (s *Sessions) expired(sess Session) bool {
+//	    return s.clock.Since(sess.LastSeen) > s.ttl
+//	}
+//
+// Production code passes clock.Real(). Tests pass a Fake, which only
+// moves when told to. Advance and Set fire due timers, tickers, AfterFunc
+// callbacks and context deadlines in order of their due time, breaking
+// ties by creation order, so runs are deterministic. BlockUntil lets a
+// test wait until the code under test has started waiting on the clock
+// before advancing it:
+//
+//	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	go worker(fake) // sleeps for a minute between runs
+//	fake.BlockUntil(1)
+//	fake.Advance(time.Minute)
+//
+// Fake satisfies the smaller clock interfaces declared by ratelimit and,
+// through its Now method, the time.Now hooks of cache and adaptive.
+package clock
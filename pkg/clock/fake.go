@@ -0,0 +1,303 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+// Fake is a Clock that only moves when Advance or Set is called. It is
+// safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // signalled when waiters grows
+	now     time.Time
+	seq     uint64
+	queue   *containers.PriorityQueue[entry]
+	waiters int
+}
+
+// entry schedules a timer. Stopping or resetting a timer leaves its old
+// entries in the queue; they are recognised as stale by their seq.
+type entry struct {
+	when time.Time
+	seq  uint64
+	t    *fakeTimer
+}
+
+// NewFake returns a fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{
+		now: start,
+		queue: containers.NewPriorityQueue(func(a, b entry) bool {
+			if !a.when.Equal(b.when) {
+				return a.when.Before(b.when)
+			}
+			return a.seq < b.seq
+		}),
+	}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration { return f.Now().Sub(t) }
+
+// Until returns the fake time remaining until t.
+func (f *Fake) Until(t time.Time) time.Duration { return t.Sub(f.Now()) }
+
+// Sleep blocks until the fake time has advanced by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.NewTimer(d).C()
+}
+
+// After returns a channel that receives the fake time once it has
+// advanced by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer returns a timer that fires once the fake time has advanced by
+// d. A non-positive d fires immediately.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1)}
+	f.schedule(t, d)
+	return t
+}
+
+// NewTicker returns a ticker that ticks every d of fake time. Like
+// time.Ticker it drops ticks for slow receivers.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1), period: d}
+	f.schedule(t, d)
+	return fakeTicker{t}
+}
+
+// AfterFunc calls fn from Advance or Set once the fake time has advanced
+// by d.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &fakeTimer{f: f, fn: fn}
+	f.schedule(t, d)
+	return t
+}
+
+// WithTimeout is WithDeadline(parent, Now()+d).
+func (f *Fake) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return f.WithDeadline(parent, f.Now().Add(d))
+}
+
+// WithDeadline returns a context that is cancelled with
+// context.DeadlineExceeded once the fake time reaches deadline, or with
+// its parent's error if the parent ends first.
+func (f *Fake) WithDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	ctx := &deadlineCtx{parent: parent, deadline: deadline, done: make(chan struct{})}
+	stopParent := context.AfterFunc(parent, func() { ctx.cancel(parent.Err()) })
+	t := f.AfterFunc(f.Until(deadline), func() { ctx.cancel(context.DeadlineExceeded) })
+	return ctx, func() {
+		t.Stop()
+		stopParent()
+		ctx.cancel(context.Canceled)
+	}
+}
+
+// Advance moves the fake time forward by d, firing everything that falls
+// due on the way, each at its own due time.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	target := f.now.Add(d)
+	f.mu.Unlock()
+	f.Set(target)
+}
+
+// Set moves the fake time to t. Moving forward fires everything that
+// falls due on the way; moving backward fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for {
+		e, ok := f.queue.Peek()
+		if !ok || e.when.After(t) {
+			break
+		}
+		f.queue.Pop()
+		if e.seq != e.t.seq || !e.t.active {
+			continue
+		}
+		if e.when.After(f.now) {
+			f.now = e.when
+		}
+		fire := e.t.expireLocked()
+		f.mu.Unlock()
+		fire(e.when)
+		f.mu.Lock()
+	}
+	f.now = t
+}
+
+// Waiters returns the number of pending timers, tickers, AfterFunc
+// callbacks, sleepers and context deadlines.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.waiters
+}
+
+// BlockUntil blocks until at least n waiters are pending.
+func (f *Fake) BlockUntil(n int) {
+	f.BlockUntilContext(context.Background(), n)
+}
+
+// BlockUntilContext blocks until at least n waiters are pending or ctx
+// ends, and returns ctx's error in the latter case.
+func (f *Fake) BlockUntilContext(ctx context.Context, n int) error {
+	stop := context.AfterFunc(ctx, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.changed.Broadcast()
+	})
+	defer stop()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.waiters < n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f.changed.Wait()
+	}
+	return nil
+}
+
+// schedule arms t to fire after d.
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) (wasActive bool) {
+	f.mu.Lock()
+	wasActive = t.active
+	if !wasActive {
+		t.active = true
+		f.waiters++
+		f.changed.Broadcast()
+	}
+	f.seq++
+	t.seq = f.seq
+	when := f.now.Add(d)
+	f.queue.Push(entry{when: when, seq: t.seq, t: t})
+	f.mu.Unlock()
+
+	if d <= 0 {
+		// Due already; fire without waiting for the next Advance.
+		f.Set(f.Now())
+	}
+	return wasActive
+}
+
+// stop disarms t and reports whether it was armed.
+func (f *Fake) stop(t *fakeTimer) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !t.active {
+		return false
+	}
+	t.active = false
+	f.waiters--
+	return true
+}
+
+type fakeTimer struct {
+	f      *Fake
+	ch     chan time.Time
+	fn     func()
+	period time.Duration // non-zero for tickers
+
+	// guarded by f.mu
+	seq    uint64
+	active bool
+}
+
+// expireLocked updates t for firing at its due time and returns the
+// action to run once f.mu is released. The caller holds f.mu.
+func (t *fakeTimer) expireLocked() func(now time.Time) {
+	f := t.f
+	if t.period > 0 {
+		f.seq++
+		t.seq = f.seq
+		f.queue.Push(entry{when: f.now.Add(t.period), seq: t.seq, t: t})
+	} else {
+		t.active = false
+		f.waiters--
+	}
+	if t.fn != nil {
+		return func(time.Time) { t.fn() }
+	}
+	return func(now time.Time) {
+		select {
+		case t.ch <- now:
+		default:
+		}
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool { return t.f.stop(t) }
+
+func (t *fakeTimer) Reset(d time.Duration) bool { return t.f.schedule(t, d) }
+
+type fakeTicker struct{ t *fakeTimer }
+
+func (t fakeTicker) C() <-chan time.Time { return t.t.ch }
+
+func (t fakeTicker) Stop() { t.t.f.stop(t.t) }
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.t.f.mu.Lock()
+	t.t.period = d
+	t.t.f.mu.Unlock()
+	t.t.f.schedule(t.t, d)
+}
+
+// deadlineCtx reports a fake deadline. It has its own Done channel
+// rather than embedding a cancelable context, so that contexts derived
+// from it see its error and not the embedded one's.
+type deadlineCtx struct {
+	parent   context.Context
+	deadline time.Time
+	done     chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *deadlineCtx) Deadline() (time.Time, bool) { return c.deadline, true }
+func (c *deadlineCtx) Done() <-chan struct{}       { return c.done }
+func (c *deadlineCtx) Value(key any) any           { return c.parent.Value(key) }
+
+func (c *deadlineCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// cancel ends c with err unless it has already ended.
+func (c *deadlineCtx) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
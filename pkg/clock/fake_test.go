@@ -0,0 +1,217 @@
+package clock_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+var start = time.Unix(1000, 0)
+
+// fired reports whether ch holds a value, and which.
+func fired(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFiringOrder(t *testing.T) {
+	fc := clock.NewFake(start)
+	var order []string
+	var at []time.Duration
+	record := func(name string) func() {
+		return func() {
+			order = append(order, name)
+			at = append(at, fc.Since(start))
+		}
+	}
+	fc.AfterFunc(2*time.Second, record("b"))
+	fc.AfterFunc(time.Second, record("a1"))
+	fc.AfterFunc(time.Second, record("a2"))
+	// Scheduled from a callback, due within the same Advance.
+	fc.AfterFunc(1500*time.Millisecond, func() { fc.AfterFunc(time.Second, record("c")) })
+
+	fc.Advance(3 * time.Second)
+	if want := []string{"a1", "a2", "b", "c"}; !slices.Equal(order, want) {
+		t.Fatalf("fired %v, want %v", order, want)
+	}
+	if want := []time.Duration{time.Second, time.Second, 2 * time.Second, 2500 * time.Millisecond}; !slices.Equal(at, want) {
+		t.Fatalf("fired at %v, want each at its due time %v", at, want)
+	}
+	if got := fc.Since(start); got != 3*time.Second {
+		t.Fatalf("Now is %v after Advance, want 3s", got)
+	}
+}
+
+func TestTimerStopReset(t *testing.T) {
+	fc := clock.NewFake(start)
+	tm := fc.NewTimer(time.Second)
+	if fc.Waiters() != 1 {
+		t.Fatalf("Waiters = %d", fc.Waiters())
+	}
+	if !tm.Stop() || tm.Stop() {
+		t.Fatal("Stop should report true only while the timer is armed")
+	}
+	fc.Advance(time.Second)
+	if _, ok := fired(tm.C()); ok || fc.Waiters() != 0 {
+		t.Fatal("stopped timer fired")
+	}
+
+	if tm.Reset(time.Second) {
+		t.Fatal("Reset of a stopped timer reported it was active")
+	}
+	if !tm.Reset(2 * time.Second) {
+		t.Fatal("Reset of an armed timer reported it was stopped")
+	}
+	fc.Advance(time.Second)
+	if _, ok := fired(tm.C()); ok {
+		t.Fatal("timer fired at its superseded time")
+	}
+	fc.Advance(time.Second)
+	if got, ok := fired(tm.C()); !ok || !got.Equal(start.Add(3*time.Second)) {
+		t.Fatalf("reset timer fired %v at %v, want at 3s", ok, got)
+	}
+	if tm.Stop() {
+		t.Fatal("Stop after firing reported the timer armed")
+	}
+
+	now := fc.NewTimer(0)
+	if _, ok := fired(now.C()); !ok || fc.Waiters() != 0 {
+		t.Fatal("a zero timer did not fire immediately")
+	}
+}
+
+func TestTickerDropsTicks(t *testing.T) {
+	fc := clock.NewFake(start)
+	tk := fc.NewTicker(time.Second)
+
+	// Nobody reads during five periods: only the first tick is kept.
+	fc.Advance(5 * time.Second)
+	if got, ok := fired(tk.C()); !ok || !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("first tick = %v at %v, want at 1s", ok, got)
+	}
+	if _, ok := fired(tk.C()); ok {
+		t.Fatal("ticker buffered more than one tick")
+	}
+	fc.Advance(time.Second)
+	if got, ok := fired(tk.C()); !ok || !got.Equal(start.Add(6*time.Second)) {
+		t.Fatalf("tick after draining = %v at %v, want at 6s", ok, got)
+	}
+
+	tk.Reset(2 * time.Second)
+	fc.Advance(time.Second)
+	if _, ok := fired(tk.C()); ok {
+		t.Fatal("ticker kept its old period after Reset")
+	}
+	fc.Advance(time.Second)
+	if _, ok := fired(tk.C()); !ok {
+		t.Fatal("no tick at the new period")
+	}
+
+	tk.Stop()
+	fc.Advance(10 * time.Second)
+	if _, ok := fired(tk.C()); ok || fc.Waiters() != 0 {
+		t.Fatal("stopped ticker still ticks")
+	}
+}
+
+func TestAfterFunc(t *testing.T) {
+	fc := clock.NewFake(start)
+	calls := 0
+	tm := fc.AfterFunc(time.Second, func() { calls++ })
+	if tm.C() != nil {
+		t.Fatal("AfterFunc timer has a channel")
+	}
+	fc.Advance(time.Second)
+	// Advance calls fn itself, so it has run by the time Advance returns.
+	if calls != 1 {
+		t.Fatalf("fn ran %d times, want 1", calls)
+	}
+
+	stopped := fc.AfterFunc(time.Second, func() { t.Error("stopped AfterFunc ran") })
+	stopped.Stop()
+	fc.Advance(time.Second)
+}
+
+func TestBlockUntil(t *testing.T) {
+	fc := clock.NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		fc.Sleep(time.Second)
+		close(done)
+	}()
+
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	<-done
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := fc.BlockUntilContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("BlockUntilContext with no waiters = %v, want context.Canceled", err)
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	type key struct{}
+	fc := clock.NewFake(start)
+	parent := context.WithValue(context.Background(), key{}, "v")
+	ctx, cancel := fc.WithTimeout(parent, time.Second)
+	defer cancel()
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(start.Add(time.Second)) {
+		t.Fatalf("Deadline = %v, %v", d, ok)
+	}
+	if child.Value(key{}) != "v" {
+		t.Fatal("values not inherited")
+	}
+	fc.Advance(time.Second - 1)
+	if ctx.Err() != nil {
+		t.Fatal("context ended before its deadline")
+	}
+	fc.Advance(1)
+	<-child.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) || !errors.Is(child.Err(), context.DeadlineExceeded) {
+		t.Fatalf("Err = %v, child Err = %v; want DeadlineExceeded for both", ctx.Err(), child.Err())
+	}
+}
+
+func TestWithDeadlineCancel(t *testing.T) {
+	fc := clock.NewFake(start)
+
+	ctx, cancel := fc.WithTimeout(context.Background(), time.Second)
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	cancel()
+	<-child.Done()
+	if !errors.Is(ctx.Err(), context.Canceled) || !errors.Is(child.Err(), context.Canceled) {
+		t.Fatalf("Err after cancel = %v, child %v", ctx.Err(), child.Err())
+	}
+	if fc.Waiters() != 0 {
+		t.Fatal("cancel left the deadline timer armed")
+	}
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel = fc.WithTimeout(parent, time.Second)
+	defer cancel()
+	cancelParent()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("Err after the parent was cancelled = %v", ctx.Err())
+	}
+
+	ctx, cancel = fc.WithDeadline(context.Background(), start.Add(-time.Second))
+	defer cancel()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("Err with a past deadline = %v", ctx.Err())
+	}
+}
@@ -0,0 +1,306 @@
+package containers_test
+
+import (
+	"container/heap"
+	"slices"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+func TestStack(t *testing.T) {
+	s := containers.NewStack[int](0)
+	for i := range 5 {
+		s.Push(i)
+	}
+	if got := slices.Collect(s.All()); !slices.Equal(got, []int{4, 3, 2, 1, 0}) {
+		t.Fatalf("All = %v, want top first", got)
+	}
+	for want := 4; want >= 0; want-- {
+		if got, ok := s.Pop(); !ok || got != want {
+			t.Fatalf("Pop = %d, %v; want %d, true", got, ok, want)
+		}
+	}
+	if _, ok := s.Pop(); ok {
+		t.Fatal("Pop on empty stack succeeded")
+	}
+}
+
+func TestQueueZeroValue(t *testing.T) {
+	var q containers.Queue[string]
+	q.Enqueue("a")
+	q.Enqueue("b")
+	if got, _ := q.Peek(); got != "a" {
+		t.Fatalf("Peek = %q, want a", got)
+	}
+	if got, _ := q.Dequeue(); got != "a" {
+		t.Fatalf("Dequeue = %q, want a", got)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", q.Len())
+	}
+}
+
+func TestDequeGrowth(t *testing.T) {
+	d := containers.NewDeque[int](0)
+	// Alternate ends so the ring wraps before every resize.
+	var want []int
+	for i := range 100 {
+		if i%2 == 0 {
+			d.PushBack(i)
+			want = append(want, i)
+		} else {
+			d.PushFront(i)
+			want = slices.Insert(want, 0, i)
+		}
+	}
+	if got := slices.Collect(d.All()); !slices.Equal(got, want) {
+		t.Fatalf("All = %v, want %v", got, want)
+	}
+	for i, w := range want {
+		if got := d.At(i); got != w {
+			t.Fatalf("At(%d) = %d, want %d", i, got, w)
+		}
+	}
+	slices.Reverse(want)
+	if got := slices.Collect(d.Backward()); !slices.Equal(got, want) {
+		t.Fatalf("Backward = %v, want %v", got, want)
+	}
+
+	front, _ := d.PopFront()
+	back, _ := d.PopBack()
+	if front != want[len(want)-1] || back != want[0] || d.Len() != 98 {
+		t.Fatalf("PopFront, PopBack = %d, %d with Len %d", front, back, d.Len())
+	}
+	d.Clear()
+	if _, ok := d.Front(); ok || d.Len() != 0 {
+		t.Fatal("deque not empty after Clear")
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	tests := []struct {
+		policy containers.OverflowPolicy
+		want   []int
+		writes []bool
+	}{
+		{containers.Reject, []int{0, 1, 2}, []bool{true, true, true, false, false}},
+		{containers.Overwrite, []int{2, 3, 4}, []bool{true, true, true, true, true}},
+	}
+	for _, tt := range tests {
+		r := containers.NewRingBuffer[int](3, tt.policy)
+		for i, want := range tt.writes {
+			if got := r.Write(i); got != want {
+				t.Errorf("policy %d: Write(%d) = %v, want %v", tt.policy, i, got, want)
+			}
+		}
+		if !r.IsFull() || r.Len() != 3 || r.Cap() != 3 {
+			t.Errorf("policy %d: Len, Cap = %d, %d; want full at 3", tt.policy, r.Len(), r.Cap())
+		}
+		if got := slices.Collect(r.All()); !slices.Equal(got, tt.want) {
+			t.Errorf("policy %d: All = %v, want %v", tt.policy, got, tt.want)
+		}
+		if got, _ := r.Read(); got != tt.want[0] {
+			t.Errorf("policy %d: Read = %d, want %d", tt.policy, got, tt.want[0])
+		}
+		if !r.Write(9) {
+			t.Errorf("policy %d: Write after Read rejected", tt.policy)
+		}
+	}
+}
+
+func TestNewRingBufferPanicsOnZeroSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewRingBuffer(0) did not panic")
+		}
+	}()
+	containers.NewRingBuffer[int](0, containers.Reject)
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	type task struct {
+		name     string
+		priority int
+	}
+	pq := containers.NewPriorityQueue(func(a, b task) bool { return a.priority > b.priority })
+	for _, tk := range []task{{"low", 1}, {"urgent", 9}, {"mid", 5}, {"high", 7}, {"idle", 0}} {
+		pq.Push(tk)
+	}
+	if top, _ := pq.Peek(); top.name != "urgent" {
+		t.Fatalf("Peek = %q, want urgent", top.name)
+	}
+	var got []string
+	for tk := range pq.Drain() {
+		got = append(got, tk.name)
+	}
+	if want := []string{"urgent", "high", "mid", "low", "idle"}; !slices.Equal(got, want) {
+		t.Fatalf("Drain = %v, want %v", got, want)
+	}
+	if pq.Len() != 0 {
+		t.Fatalf("Len after Drain = %d", pq.Len())
+	}
+}
+
+// The slice-based versions below are the straightforward implementations
+// the containers replace.
+
+type sliceQueue[T any] []T
+
+func (q *sliceQueue[T]) enqueue(v T) { *q = append(*q, v) }
+
+func (q *sliceQueue[T]) dequeue() T {
+	v := (*q)[0]
+	*q = (*q)[1:]
+	return v
+}
+
+type sliceDeque[T any] []T
+
+func (d *sliceDeque[T]) pushFront(v T) { *d = slices.Insert(*d, 0, v) }
+
+func (d *sliceDeque[T]) popBack() T {
+	v := (*d)[len(*d)-1]
+	*d = (*d)[:len(*d)-1]
+	return v
+}
+
+type intHeap []int
+
+func (h intHeap) Len() int           { return len(h) }
+func (h intHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h intHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *intHeap) Push(x any)        { *h = append(*h, x.(int)) }
+
+func (h *intHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+const benchItems = 1024
+
+func BenchmarkStack(b *testing.B) {
+	b.Run("containers", func(b *testing.B) {
+		s := containers.NewStack[int](benchItems)
+		for range b.N {
+			for i := range benchItems {
+				s.Push(i)
+			}
+			for range benchItems {
+				s.Pop()
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		s := make([]int, 0, benchItems)
+		for range b.N {
+			for i := range benchItems {
+				s = append(s, i)
+			}
+			for range benchItems {
+				s = s[:len(s)-1]
+			}
+		}
+	})
+}
+
+func BenchmarkQueue(b *testing.B) {
+	b.Run("containers", func(b *testing.B) {
+		q := containers.NewQueue[int](benchItems)
+		for range b.N {
+			for i := range benchItems {
+				q.Enqueue(i)
+			}
+			for range benchItems {
+				q.Dequeue()
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		var q sliceQueue[int]
+		for range b.N {
+			for i := range benchItems {
+				q.enqueue(i)
+			}
+			for range benchItems {
+				q.dequeue()
+			}
+		}
+	})
+}
+
+func BenchmarkDequePushFront(b *testing.B) {
+	b.Run("containers", func(b *testing.B) {
+		d := containers.NewDeque[int](benchItems)
+		for range b.N {
+			for i := range benchItems {
+				d.PushFront(i)
+			}
+			for range benchItems {
+				d.PopBack()
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		var d sliceDeque[int]
+		for range b.N {
+			for i := range benchItems {
+				d.pushFront(i)
+			}
+			for range benchItems {
+				d.popBack()
+			}
+		}
+	})
+}
+
+func BenchmarkRingBuffer(b *testing.B) {
+	b.Run("containers", func(b *testing.B) {
+		r := containers.NewRingBuffer[int](benchItems/4, containers.Overwrite)
+		for range b.N {
+			for i := range benchItems {
+				r.Write(i)
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		// Keep the last n items by shifting the slice down.
+		n := benchItems / 4
+		r := make([]int, 0, n)
+		for range b.N {
+			for i := range benchItems {
+				if len(r) == n {
+					r = append(r[:0], r[1:]...)
+				}
+				r = append(r, i)
+			}
+		}
+	})
+}
+
+func BenchmarkPriorityQueue(b *testing.B) {
+	b.Run("containers", func(b *testing.B) {
+		pq := containers.NewPriorityQueue(func(a, b int) bool { return a < b })
+		for range b.N {
+			for i := range benchItems {
+				pq.Push((i * 7919) % benchItems)
+			}
+			for range benchItems {
+				pq.Pop()
+			}
+		}
+	})
+	b.Run("container-heap", func(b *testing.B) {
+		h := &intHeap{}
+		for range b.N {
+			for i := range benchItems {
+				heap.Push(h, (i*7919)%benchItems)
+			}
+			for range benchItems {
+				heap.Pop(h)
+			}
+		}
+	})
+}
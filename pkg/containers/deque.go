@@ -0,0 +1,147 @@
+package containers
+
+import "iter"
+
+const minDequeCapacity = 8
+
+// Deque is a double-ended queue backed by a growable ring buffer.
+// Pushes and pops at either end run in amortized O(1).
+type Deque[T any] struct {
+	buf   []T
+	head  int
+	count int
+}
+
+// NewDeque creates a deque with room for at least capacity items.
+func NewDeque[T any](capacity int) *Deque[T] {
+	size := minDequeCapacity
+	for size < capacity {
+		size <<= 1
+	}
+	return &Deque[T]{buf: make([]T, size)}
+}
+
+// PushBack adds an item to the back.
+func (d *Deque[T]) PushBack(item T) {
+	d.grow()
+	d.buf[d.index(d.count)] = item
+	d.count++
+}
+
+// PushFront adds an item to the front.
+func (d *Deque[T]) PushFront(item T) {
+	d.grow()
+	d.head = d.index(len(d.buf) - 1)
+	d.buf[d.head] = item
+	d.count++
+}
+
+// PopFront removes and returns the front item.
+func (d *Deque[T]) PopFront() (T, bool) {
+	var zero T
+	if d.count == 0 {
+		return zero, false
+	}
+
+	item := d.buf[d.head]
+	d.buf[d.head] = zero
+	d.head = d.index(1)
+	d.count--
+	return item, true
+}
+
+// PopBack removes and returns the back item.
+func (d *Deque[T]) PopBack() (T, bool) {
+	var zero T
+	if d.count == 0 {
+		return zero, false
+	}
+
+	i := d.index(d.count - 1)
+	item := d.buf[i]
+	d.buf[i] = zero
+	d.count--
+	return item, true
+}
+
+// Front returns the front item without removing it.
+func (d *Deque[T]) Front() (T, bool) {
+	if d.count == 0 {
+		var zero T
+		return zero, false
+	}
+	return d.buf[d.head], true
+}
+
+// Back returns the back item without removing it.
+func (d *Deque[T]) Back() (T, bool) {
+	if d.count == 0 {
+		var zero T
+		return zero, false
+	}
+	return d.buf[d.index(d.count-1)], true
+}
+
+// At returns the item at position i counted from the front.
+func (d *Deque[T]) At(i int) T {
+	if i < 0 || i >= d.count {
+		panic("containers: deque index out of range")
+	}
+	return d.buf[d.index(i)]
+}
+
+// Len returns the number of items in the deque.
+func (d *Deque[T]) Len() int {
+	return d.count
+}
+
+// Clear removes all items and keeps the allocated buffer.
+func (d *Deque[T]) Clear() {
+	clear(d.buf)
+	d.head = 0
+	d.count = 0
+}
+
+// All iterates from front to back.
+func (d *Deque[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := 0; i < d.count; i++ {
+			if !yield(d.buf[d.index(i)]) {
+				return
+			}
+		}
+	}
+}
+
+// Backward iterates from back to front.
+func (d *Deque[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := d.count - 1; i >= 0; i-- {
+			if !yield(d.buf[d.index(i)]) {
+				return
+			}
+		}
+	}
+}
+
+// index maps a logical offset from head to a buffer position.
+// The buffer length is always a power of two.
+func (d *Deque[T]) index(offset int) int {
+	return (d.head + offset) & (len(d.buf) - 1)
+}
+
+func (d *Deque[T]) grow() {
+	if d.buf == nil {
+		d.buf = make([]T, minDequeCapacity)
+		return
+	}
+	if d.count < len(d.buf) {
+		return
+	}
+
+	buf := make([]T, len(d.buf)*2)
+	n := copy(buf, d.buf[d.head:])
+	copy(buf[n:], d.buf[:d.head])
+	d.buf = buf
+	d.head = 0
+}
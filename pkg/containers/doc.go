@@ -0,0 +1,7 @@
+// Package containers provides generic Stack, Queue, Deque, RingBuffer and
+// PriorityQueue types that replace the interface{}-based versions shown in
+// the arrays and slices chapters.
+//
+// All containers can be ranged over with the iterators returned by their
+// All method. None of them are safe for concurrent use.
+package containers
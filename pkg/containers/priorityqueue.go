@@ -0,0 +1,109 @@
+package containers
+
+import "iter"
+
+// PriorityQueue is a binary heap ordered by a caller-supplied less
+// function. The item for which less reports true against every other
+// item is popped first.
+type PriorityQueue[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+// NewPriorityQueue creates an empty priority queue.
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{less: less}
+}
+
+// Push adds an item in O(log n).
+func (pq *PriorityQueue[T]) Push(item T) {
+	pq.items = append(pq.items, item)
+	pq.up(len(pq.items) - 1)
+}
+
+// Pop removes and returns the highest-priority item in O(log n).
+func (pq *PriorityQueue[T]) Pop() (T, bool) {
+	var zero T
+	n := len(pq.items)
+	if n == 0 {
+		return zero, false
+	}
+
+	item := pq.items[0]
+	pq.items[0] = pq.items[n-1]
+	pq.items[n-1] = zero
+	pq.items = pq.items[:n-1]
+	if len(pq.items) > 0 {
+		pq.down(0)
+	}
+	return item, true
+}
+
+// Peek returns the highest-priority item without removing it.
+func (pq *PriorityQueue[T]) Peek() (T, bool) {
+	if len(pq.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return pq.items[0], true
+}
+
+// Len returns the number of queued items.
+func (pq *PriorityQueue[T]) Len() int {
+	return len(pq.items)
+}
+
+// All iterates over the items in heap order, which is not sorted order.
+// Use Drain to consume items by priority.
+func (pq *PriorityQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range pq.items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Drain pops items in priority order until the queue is empty or the
+// loop stops early.
+func (pq *PriorityQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for len(pq.items) > 0 {
+			item, _ := pq.Pop()
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+func (pq *PriorityQueue[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !pq.less(pq.items[i], pq.items[parent]) {
+			break
+		}
+		pq.items[i], pq.items[parent] = pq.items[parent], pq.items[i]
+		i = parent
+	}
+}
+
+func (pq *PriorityQueue[T]) down(i int) {
+	n := len(pq.items)
+	for {
+		smallest := i
+		left, right := 2*i+1, 2*i+2
+		if left < n && pq.less(pq.items[left], pq.items[smallest]) {
+			smallest = left
+		}
+		if right < n && pq.less(pq.items[right], pq.items[smallest]) {
+			smallest = right
+		}
+		if smallest == i {
+			return
+		}
+		pq.items[i], pq.items[smallest] = pq.items[smallest], pq.items[i]
+		i = smallest
+	}
+}
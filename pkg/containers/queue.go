@@ -0,0 +1,45 @@
+package containers
+
+import "iter"
+
+// Queue is a first-in, first-out collection. Unlike the slice-based
+// queue from the arrays chapter, dequeuing never leaks the underlying
+// array because storage is reused as a ring.
+type Queue[T any] struct {
+	items Deque[T]
+}
+
+// NewQueue creates a queue with room for at least capacity items.
+func NewQueue[T any](capacity int) *Queue[T] {
+	return &Queue[T]{items: *NewDeque[T](capacity)}
+}
+
+// Enqueue adds an item to the back of the queue.
+func (q *Queue[T]) Enqueue(item T) {
+	q.items.PushBack(item)
+}
+
+// Dequeue removes and returns the front item.
+func (q *Queue[T]) Dequeue() (T, bool) {
+	return q.items.PopFront()
+}
+
+// Peek returns the front item without removing it.
+func (q *Queue[T]) Peek() (T, bool) {
+	return q.items.Front()
+}
+
+// Len returns the number of items in the queue.
+func (q *Queue[T]) Len() int {
+	return q.items.Len()
+}
+
+// Clear removes all items.
+func (q *Queue[T]) Clear() {
+	q.items.Clear()
+}
+
+// All iterates from the front of the queue to the back.
+func (q *Queue[T]) All() iter.Seq[T] {
+	return q.items.All()
+}
@@ -0,0 +1,98 @@
+package containers
+
+import "iter"
+
+// OverflowPolicy decides what a full RingBuffer does with a new item.
+type OverflowPolicy int
+
+const (
+	// Reject refuses new items while the buffer is full.
+	Reject OverflowPolicy = iota
+	// Overwrite drops the oldest item to make room for the new one.
+	Overwrite
+)
+
+// RingBuffer is a fixed-capacity FIFO buffer.
+type RingBuffer[T any] struct {
+	data   []T
+	head   int
+	count  int
+	policy OverflowPolicy
+}
+
+// NewRingBuffer creates a ring buffer holding at most size items.
+func NewRingBuffer[T any](size int, policy OverflowPolicy) *RingBuffer[T] {
+	if size <= 0 {
+		panic("containers: ring buffer size must be positive")
+	}
+	return &RingBuffer[T]{
+		data:   make([]T, size),
+		policy: policy,
+	}
+}
+
+// Write adds an item. It returns false if the buffer is full and the
+// policy is Reject.
+func (r *RingBuffer[T]) Write(item T) bool {
+	if r.count == len(r.data) {
+		if r.policy == Reject {
+			return false
+		}
+		r.data[r.head] = item
+		r.head = (r.head + 1) % len(r.data)
+		return true
+	}
+
+	r.data[(r.head+r.count)%len(r.data)] = item
+	r.count++
+	return true
+}
+
+// Read removes and returns the oldest item.
+func (r *RingBuffer[T]) Read() (T, bool) {
+	var zero T
+	if r.count == 0 {
+		return zero, false
+	}
+
+	item := r.data[r.head]
+	r.data[r.head] = zero
+	r.head = (r.head + 1) % len(r.data)
+	r.count--
+	return item, true
+}
+
+// Peek returns the oldest item without removing it.
+func (r *RingBuffer[T]) Peek() (T, bool) {
+	if r.count == 0 {
+		var zero T
+		return zero, false
+	}
+	return r.data[r.head], true
+}
+
+// Len returns the number of buffered items.
+func (r *RingBuffer[T]) Len() int {
+	return r.count
+}
+
+// Cap returns the fixed capacity.
+func (r *RingBuffer[T]) Cap() int {
+	return len(r.data)
+}
+
+// IsFull reports whether the buffer is at capacity.
+func (r *RingBuffer[T]) IsFull() bool {
+	return r.count == len(r.data)
+}
+
+// All iterates from the oldest item to the newest.
+func (r *RingBuffer[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := 0; i < r.count; i++ {
+			if !yield(r.data[(r.head+i)%len(r.data)]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package containers
+
+import "iter"
+
+// Stack is a last-in, first-out collection backed by a slice.
+type Stack[T any] struct {
+	items []T
+}
+
+// NewStack creates a stack with room for capacity items.
+func NewStack[T any](capacity int) *Stack[T] {
+	return &Stack[T]{items: make([]T, 0, capacity)}
+}
+
+// Push adds an item to the top of the stack.
+func (s *Stack[T]) Push(item T) {
+	s.items = append(s.items, item)
+}
+
+// Pop removes and returns the top item.
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+
+	last := len(s.items) - 1
+	item := s.items[last]
+	s.items[last] = zero // Release the reference for the GC
+	s.items = s.items[:last]
+	return item, true
+}
+
+// Peek returns the top item without removing it.
+func (s *Stack[T]) Peek() (T, bool) {
+	if len(s.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+// Len returns the number of items on the stack.
+func (s *Stack[T]) Len() int {
+	return len(s.items)
+}
+
+// Clear removes all items.
+func (s *Stack[T]) Clear() {
+	clear(s.items)
+	s.items = s.items[:0]
+}
+
+// All iterates from the top of the stack to the bottom.
+func (s *Stack[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := len(s.items) - 1; i >= 0; i-- {
+			if !yield(s.items[i]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ctxutil
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBudgetExhausted is the cause of contexts from Reserve and Share
+// whose share of the deadline has run out.
+var ErrBudgetExhausted = errors.New("ctxutil: deadline budget exhausted")
+
+// Remaining returns the time left until ctx's deadline.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(d), true
+}
+
+// Reserve returns a child of ctx whose deadline is reserve earlier than
+// ctx's, leaving the caller that much time after the child expires. If
+// ctx has no deadline the child has none either; if less than reserve
+// remains the child is already done. The child's cause is
+// ErrBudgetExhausted when the earlier deadline passes.
+func Reserve(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadlineCause(ctx, d.Add(-reserve), ErrBudgetExhausted)
+}
+
+// Share returns a child of ctx with fraction of the remaining time, for
+// splitting a budget between sequential calls. A fraction of 1 or more,
+// or a ctx without deadline, leaves the deadline unchanged.
+func Share(ctx context.Context, fraction float64) (context.Context, context.CancelFunc) {
+	left, ok := Remaining(ctx)
+	if !ok || fraction >= 1 {
+		return context.WithCancel(ctx)
+	}
+	share := time.Duration(float64(left) * max(fraction, 0))
+	return context.WithDeadlineCause(ctx, time.Now().Add(share), ErrBudgetExhausted)
+}
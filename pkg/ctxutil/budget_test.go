@@ -0,0 +1,85 @@
+package ctxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/ctxutil"
+)
+
+// near reports whether got is within a second of want, which absorbs
+// the time that passes between computing want and the deadline.
+func near(got, want time.Time) bool {
+	d := got.Sub(want)
+	return d > -time.Second && d < time.Second
+}
+
+func TestReserve(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelParent()
+	pd, _ := parent.Deadline()
+
+	ctx, cancel := ctxutil.Reserve(parent, 10*time.Second)
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(pd.Add(-10*time.Second)) {
+		t.Fatalf("Deadline = %v, %v; want 10s before %v", d, ok, pd)
+	}
+	if left, ok := ctxutil.Remaining(ctx); !ok || left <= 49*time.Second || left > 50*time.Second {
+		t.Fatalf("Remaining = %v, %v; want about 50s", left, ok)
+	}
+
+	// Less left than the reserve: the child is already done.
+	short, cancelShort := ctxutil.Reserve(parent, time.Hour)
+	defer cancelShort()
+	<-short.Done()
+	if err := ctxutil.Err(short); !errors.Is(err, ctxutil.ErrBudgetExhausted) || !ctxutil.IsTimeout(err) {
+		t.Fatalf("Err = %v, want the budget exhausted", err)
+	}
+	if parent.Err() != nil {
+		t.Fatal("exhausting the budget ended the parent")
+	}
+
+	none, cancelNone := ctxutil.Reserve(context.Background(), time.Second)
+	defer cancelNone()
+	if _, ok := none.Deadline(); ok {
+		t.Fatal("Reserve gave a context without deadline one")
+	}
+	if _, ok := ctxutil.Remaining(none); ok {
+		t.Fatal("Remaining reported time left without a deadline")
+	}
+}
+
+func TestShare(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelParent()
+	pd, _ := parent.Deadline()
+
+	half, cancelHalf := ctxutil.Share(parent, 0.5)
+	defer cancelHalf()
+	if d, ok := half.Deadline(); !ok || !near(d, time.Now().Add(30*time.Second)) {
+		t.Fatalf("Deadline = %v, %v; want about 30s from now", d, ok)
+	}
+
+	for _, fraction := range []float64{1, 2} {
+		all, cancelAll := ctxutil.Share(parent, fraction)
+		if d, _ := all.Deadline(); !d.Equal(pd) {
+			t.Errorf("Share(%v) deadline = %v, want the parent's %v", fraction, d, pd)
+		}
+		cancelAll()
+	}
+
+	nothing, cancelNothing := ctxutil.Share(parent, -1)
+	defer cancelNothing()
+	<-nothing.Done()
+	if err := ctxutil.Err(nothing); !errors.Is(err, ctxutil.ErrBudgetExhausted) {
+		t.Fatalf("Share(-1) Err = %v, want the budget exhausted", err)
+	}
+
+	none, cancelNone := ctxutil.Share(context.Background(), 0.5)
+	defer cancelNone()
+	if _, ok := none.Deadline(); ok {
+		t.Fatal("Share gave a context without deadline one")
+	}
+}
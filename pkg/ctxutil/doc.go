@@ -0,0 +1,27 @@
+// Package ctxutil collects the context helpers that the context chapter's
+// RequestContext, RequestScope and GoodService examples call for:
+//
+//   - Key gives context values a type, so lookups need no assertion and
+//     keys from different packages cannot collide.
+//   - Reserve and Share budget a deadline: keep part of the remaining
+//     time for the caller's own work and hand the rest to downstream
+//     calls.
+//   - Merge combines two contexts' cancellation, such as a request's and
+//     a server's shutdown.
+//   - Detach keeps a context's values but not its cancellation, for work
+//     that must outlive a request.
+//   - Err reports why a context ended, including the cause given to
+//     context.WithCancelCause and friends.
+//   - Middleware and Transport carry request IDs and deadlines across
+//     HTTP hops.
+//
+// A handler that must answer within its client's deadline reserves time
+// for writing the response before calling a backend:
+//
+//	ctx, cancel := ctxutil.Reserve(r.Context(), 50*time.Millisecond)
+//	defer cancel()
+//	resp, err := backend.Do(req.WithContext(ctx))
+//	if err != nil {
+//	    log.Printf("request %s: %v", ctxutil.RequestID(r.Context()), ctxutil.Err(ctx))
+//	}
+package ctxutil
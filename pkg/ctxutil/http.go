@@ -0,0 +1,142 @@
+package ctxutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// HeaderRequestID carries the request ID.
+	HeaderRequestID = "X-Request-Id"
+	// HeaderTimeout carries the caller's remaining time as a Go duration
+	// such as "1.5s". A relative timeout, unlike an absolute deadline,
+	// is immune to clock skew between hosts.
+	HeaderTimeout = "X-Request-Timeout"
+)
+
+// ErrUpstreamDeadline is the cause of contexts ended by a deadline
+// received in HeaderTimeout.
+var ErrUpstreamDeadline = errors.New("ctxutil: upstream deadline exceeded")
+
+var requestIDKey = NewKey[string]("request-id")
+
+// WithRequestID returns a copy of ctx carrying id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return requestIDKey.With(ctx, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	return requestIDKey.ValueOr(ctx, "")
+}
+
+// NewRequestID returns a random 128-bit ID in hex.
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Inject writes ctx's request ID and remaining time to h.
+func Inject(ctx context.Context, h http.Header) {
+	if id := RequestID(ctx); id != "" {
+		h.Set(HeaderRequestID, id)
+	}
+	if left, ok := Remaining(ctx); ok {
+		h.Set(HeaderTimeout, max(left, 0).Round(time.Millisecond).String())
+	}
+}
+
+// Extract returns a child of ctx carrying the request ID from h and, if
+// h has a timeout, bounded by it with ErrUpstreamDeadline as the cause.
+// A malformed timeout is ignored.
+func Extract(ctx context.Context, h http.Header) (context.Context, context.CancelFunc) {
+	if id := h.Get(HeaderRequestID); id != "" {
+		ctx = WithRequestID(ctx, id)
+	}
+	d, ok := timeout(h)
+	return withTimeout(ctx, d, ok)
+}
+
+func timeout(h http.Header) (time.Duration, bool) {
+	d, err := time.ParseDuration(h.Get(HeaderTimeout))
+	return d, err == nil
+}
+
+func withTimeout(ctx context.Context, d time.Duration, ok bool) (context.Context, context.CancelFunc) {
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, d, ErrUpstreamDeadline)
+}
+
+type options struct {
+	maxTimeout time.Duration
+	newID      func() string
+}
+
+// Option configures Middleware.
+type Option func(*options)
+
+// WithMaxTimeout caps the timeout accepted from callers and applies it
+// to requests that arrive without one.
+func WithMaxTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.maxTimeout = d
+	}
+}
+
+// WithIDGenerator sets how IDs are made for requests that arrive
+// without one. The default is NewRequestID.
+func WithIDGenerator(fn func() string) Option {
+	return func(o *options) {
+		o.newID = fn
+	}
+}
+
+// Middleware puts the incoming request ID, or a new one, and the
+// caller's timeout into the request context, and echoes the ID in the
+// response header.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := options{newID: NewRequestID}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(HeaderRequestID)
+			if id == "" {
+				id = o.newID()
+			}
+			d, ok := timeout(r.Header)
+			if o.maxTimeout > 0 && (!ok || d > o.maxTimeout) {
+				d, ok = o.maxTimeout, true
+			}
+			ctx, cancel := withTimeout(WithRequestID(r.Context(), id), d, ok)
+			defer cancel()
+			w.Header().Set(HeaderRequestID, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Transport is an http.RoundTripper that sends the request context's ID
+// and remaining time to the next hop.
+type Transport struct {
+	Base http.RoundTripper // http.DefaultTransport if nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	r := req.Clone(req.Context())
+	Inject(req.Context(), r.Header)
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,146 @@
+package ctxutil_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/ctxutil"
+)
+
+func TestInjectExtract(t *testing.T) {
+	ctx, cancel := context.WithTimeout(ctxutil.WithRequestID(context.Background(), "req-1"), 2*time.Second)
+	defer cancel()
+	h := http.Header{}
+	ctxutil.Inject(ctx, h)
+	if got := h.Get(ctxutil.HeaderRequestID); got != "req-1" {
+		t.Fatalf("%s = %q", ctxutil.HeaderRequestID, got)
+	}
+	if d, err := time.ParseDuration(h.Get(ctxutil.HeaderTimeout)); err != nil || d <= time.Second || d > 2*time.Second {
+		t.Fatalf("%s = %q, want about 2s", ctxutil.HeaderTimeout, h.Get(ctxutil.HeaderTimeout))
+	}
+
+	got, cancelGot := ctxutil.Extract(context.Background(), h)
+	defer cancelGot()
+	if id := ctxutil.RequestID(got); id != "req-1" {
+		t.Fatalf("RequestID = %q", id)
+	}
+	if d, ok := got.Deadline(); !ok || !near(d, time.Now().Add(2*time.Second)) {
+		t.Fatalf("Deadline = %v, %v; want about 2s from now", d, ok)
+	}
+
+	// An exhausted budget is sent as zero and ends the next hop at once.
+	h.Set(ctxutil.HeaderTimeout, "0s")
+	expired, cancelExpired := ctxutil.Extract(context.Background(), h)
+	defer cancelExpired()
+	<-expired.Done()
+	if err := ctxutil.Err(expired); !errors.Is(err, ctxutil.ErrUpstreamDeadline) || !ctxutil.IsTimeout(err) {
+		t.Fatalf("Err = %v, want the upstream deadline", err)
+	}
+}
+
+func TestInjectNothing(t *testing.T) {
+	h := http.Header{}
+	ctxutil.Inject(context.Background(), h)
+	if len(h) != 0 {
+		t.Fatalf("Inject without ID or deadline wrote %v", h)
+	}
+
+	h.Set(ctxutil.HeaderTimeout, "soon")
+	ctx, cancel := ctxutil.Extract(context.Background(), h)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("malformed timeout set a deadline")
+	}
+	if id := ctxutil.RequestID(ctx); id != "" {
+		t.Fatalf("RequestID = %q without the header", id)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		opts    []ctxutil.Option
+		id      string
+		timeout string
+		wantID  string
+		want    time.Duration // 0 for no deadline
+	}{
+		{"propagated", nil, "req-1", "2s", "req-1", 2 * time.Second},
+		{"generated", []ctxutil.Option{ctxutil.WithIDGenerator(func() string { return "new" })}, "", "", "new", 0},
+		{"capped", []ctxutil.Option{ctxutil.WithMaxTimeout(time.Second)}, "req-1", "1h", "req-1", time.Second},
+		{"under the cap", []ctxutil.Option{ctxutil.WithMaxTimeout(time.Minute)}, "req-1", "2s", "req-1", 2 * time.Second},
+		{"cap as default", []ctxutil.Option{ctxutil.WithMaxTimeout(time.Second)}, "req-1", "", "req-1", time.Second},
+		{"malformed", []ctxutil.Option{ctxutil.WithMaxTimeout(time.Second)}, "req-1", "soon", "req-1", time.Second},
+	} {
+		var seen context.Context
+		h := ctxutil.Middleware(tt.opts...)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			seen = r.Context()
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.id != "" {
+			req.Header.Set(ctxutil.HeaderRequestID, tt.id)
+		}
+		if tt.timeout != "" {
+			req.Header.Set(ctxutil.HeaderTimeout, tt.timeout)
+		}
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		h.ServeHTTP(rec, req)
+
+		if id := ctxutil.RequestID(seen); id != tt.wantID {
+			t.Errorf("%s: RequestID = %q, want %q", tt.name, id, tt.wantID)
+		}
+		if got := rec.Header().Get(ctxutil.HeaderRequestID); got != tt.wantID {
+			t.Errorf("%s: response %s = %q, want %q", tt.name, ctxutil.HeaderRequestID, got, tt.wantID)
+		}
+		d, ok := seen.Deadline()
+		if ok != (tt.want != 0) || (ok && !near(d, start.Add(tt.want))) {
+			t.Errorf("%s: Deadline = %v, %v; want %v from the start", tt.name, d, ok, tt.want)
+		}
+		if seen.Err() == nil {
+			t.Errorf("%s: request context still live after the handler returned", tt.name)
+		}
+	}
+}
+
+func TestMiddlewareGeneratesIDs(t *testing.T) {
+	ids := map[string]bool{}
+	h := ctxutil.Middleware()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ids[ctxutil.RequestID(r.Context())] = true
+	}))
+	for range 3 {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	if len(ids) != 3 || ids[""] {
+		t.Fatalf("generated IDs %v, want three distinct ones", ids)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransport(t *testing.T) {
+	var sent http.Header
+	tr := &ctxutil.Transport{Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})}
+
+	ctx, cancel := context.WithTimeout(ctxutil.WithRequestID(context.Background(), "req-1"), time.Minute)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "http://backend/", nil).WithContext(ctx)
+	if _, err := tr.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Get(ctxutil.HeaderRequestID) != "req-1" || sent.Get(ctxutil.HeaderTimeout) == "" {
+		t.Fatalf("sent headers %v, want the ID and timeout", sent)
+	}
+	if len(req.Header) != 0 {
+		t.Fatalf("RoundTrip modified the caller's request: %v", req.Header)
+	}
+}
@@ -0,0 +1,51 @@
+package ctxutil
+
+import (
+	"context"
+	"fmt"
+)
+
+// Key is a typed context key. Keys are compared by identity, so two keys
+// with the same name never collide.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a new key. The name is only used in messages.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// With returns a copy of ctx carrying v under k.
+func (k *Key[T]) With(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// Value returns the value stored under k.
+func (k *Key[T]) Value(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// ValueOr returns the value stored under k, or def if there is none.
+func (k *Key[T]) ValueOr(ctx context.Context, def T) T {
+	if v, ok := k.Value(ctx); ok {
+		return v
+	}
+	return def
+}
+
+// MustValue returns the value stored under k and panics if there is
+// none, for values that middleware guarantees.
+func (k *Key[T]) MustValue(ctx context.Context) T {
+	v, ok := k.Value(ctx)
+	if !ok {
+		panic(fmt.Sprintf("ctxutil: no value for key %s", k.name))
+	}
+	return v
+}
+
+func (k *Key[T]) String() string {
+	var zero T
+	return fmt.Sprintf("ctxutil.Key[%T](%s)", zero, k.name)
+}
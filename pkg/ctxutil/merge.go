@@ -0,0 +1,138 @@
+package ctxutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Merge returns a context that is done when either a or b is, with the
+// error and cause of whichever ended first: when b's deadline passes,
+// Err reports context.DeadlineExceeded just as it would for a's, and so
+// do contexts derived from the merged one. Its deadline is the earlier
+// of the two and values are looked up in a, then b. Cancelling the
+// returned function releases the links to a and b.
+func Merge(a, b context.Context) (context.Context, context.CancelFunc) {
+	causeCtx, setCause := context.WithCancelCause(context.WithoutCancel(a))
+	m := &merged{a: a, b: b, causeCtx: causeCtx, setCause: setCause, done: make(chan struct{})}
+	switch {
+	case a.Err() != nil:
+		m.end(a)
+	case b.Err() != nil:
+		m.end(b)
+	}
+	stopA := context.AfterFunc(a, func() { m.end(a) })
+	stopB := context.AfterFunc(b, func() { m.end(b) })
+	return m, func() {
+		stopA()
+		stopB()
+		m.cancel(context.Canceled, context.Canceled)
+	}
+}
+
+// merged has its own Done channel rather than embedding a cancelable
+// context, so that contexts derived from it see the error it ended with
+// and not the embedded one's. causeCtx is never visible as a parent; it
+// only holds the cause for context.Cause, which finds it through Value.
+type merged struct {
+	a, b     context.Context
+	causeCtx context.Context
+	setCause context.CancelCauseFunc
+	done     chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// end ends m with parent's error and cause.
+func (m *merged) end(parent context.Context) {
+	m.cancel(parent.Err(), context.Cause(parent))
+}
+
+// cancel ends m with err and cause unless it has already ended.
+func (m *merged) cancel(err, cause error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err == nil {
+		m.err = err
+		m.setCause(cause)
+		close(m.done)
+	}
+}
+
+func (m *merged) Done() <-chan struct{} { return m.done }
+
+func (m *merged) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+func (m *merged) Deadline() (time.Time, bool) {
+	da, oka := m.a.Deadline()
+	db, okb := m.b.Deadline()
+	switch {
+	case !okb:
+		return da, oka
+	case !oka || db.Before(da):
+		return db, true
+	default:
+		return da, true
+	}
+}
+
+func (m *merged) Value(key any) any {
+	if v := m.causeCtx.Value(key); v != nil {
+		return v
+	}
+	return m.b.Value(key)
+}
+
+// Err returns why ctx ended, or nil if it has not. Unlike ctx.Err, the
+// result also carries the cause passed to a CancelCauseFunc or given to
+// WithTimeoutCause and WithDeadlineCause: errors.Is matches both
+// ctx.Err() and the cause, and the message names both.
+func Err(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	cause := context.Cause(ctx)
+	if cause == nil || cause == err {
+		return err
+	}
+	return &endedError{err: err, cause: cause}
+}
+
+type endedError struct {
+	err, cause error
+}
+
+func (e *endedError) Error() string {
+	return fmt.Sprintf("%v: %v", e.err, e.cause)
+}
+
+func (e *endedError) Unwrap() []error {
+	return []error{e.err, e.cause}
+}
+
+// IsTimeout reports whether err, as returned by Err, means a deadline
+// passed, including a budget from Reserve or Share.
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrBudgetExhausted)
+}
+
+// Detach returns a context with ctx's values but without its deadline
+// or cancellation, for work such as audit logging that must finish even
+// if the request that started it is cancelled.
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// DetachWithTimeout is Detach with a fresh timeout, so detached work is
+// still bounded.
+func DetachWithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), d)
+}
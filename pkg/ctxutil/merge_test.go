@@ -0,0 +1,149 @@
+package ctxutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/ctxutil"
+)
+
+func TestMergeDeadlineOfB(t *testing.T) {
+	a, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	b, cancelB := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelB()
+
+	ctx, cancel := ctxutil.Merge(a, b)
+	defer cancel()
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("Err = %v, want DeadlineExceeded", err)
+	}
+	if cause := context.Cause(ctx); cause != context.DeadlineExceeded {
+		t.Fatalf("Cause = %v, want DeadlineExceeded", cause)
+	}
+	if !ctxutil.IsTimeout(ctxutil.Err(ctx)) {
+		t.Fatalf("IsTimeout(%v) = false", ctxutil.Err(ctx))
+	}
+}
+
+func TestMergeEndedByEither(t *testing.T) {
+	budget := func() (context.Context, context.CancelFunc) {
+		parent, cancelParent := context.WithTimeout(context.Background(), time.Hour)
+		ctx, cancel := ctxutil.Reserve(parent, time.Hour) // already exhausted
+		return ctx, func() {
+			cancel()
+			cancelParent()
+		}
+	}
+	shutdown := errors.New("shutting down")
+
+	tests := []struct {
+		name      string
+		a, b      func() (context.Context, context.CancelFunc)
+		err, want error
+	}{
+		{"a's deadline", budget, background, context.DeadlineExceeded, ctxutil.ErrBudgetExhausted},
+		{"b's deadline", background, budget, context.DeadlineExceeded, ctxutil.ErrBudgetExhausted},
+		{"b cancelled", background, cancelledWith(shutdown), context.Canceled, shutdown},
+		{"b done before Merge", background, cancelledWith(context.Canceled), context.Canceled, context.Canceled},
+	}
+	for _, tt := range tests {
+		a, cancelA := tt.a()
+		b, cancelB := tt.b()
+		ctx, cancel := ctxutil.Merge(a, b)
+		<-ctx.Done()
+		if err := ctx.Err(); err != tt.err {
+			t.Errorf("%s: Err = %v, want %v", tt.name, err, tt.err)
+		}
+		if err := ctxutil.Err(ctx); !errors.Is(err, tt.want) {
+			t.Errorf("%s: ctxutil.Err = %v, want it to match %v", tt.name, err, tt.want)
+		}
+		cancel()
+		cancelA()
+		cancelB()
+	}
+}
+
+func background() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.Background())
+}
+
+func cancelledWith(cause error) func() (context.Context, context.CancelFunc) {
+	return func() (context.Context, context.CancelFunc) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(cause)
+		return ctx, func() {}
+	}
+}
+
+func TestMergeCancel(t *testing.T) {
+	key := ctxutil.NewKey[string]("k")
+	other := ctxutil.NewKey[int]("n")
+	soon := time.Now().Add(time.Minute)
+	a, cancelA := context.WithDeadline(key.With(context.Background(), "a"), soon.Add(time.Hour))
+	defer cancelA()
+	b, cancelB := context.WithDeadline(other.With(key.With(context.Background(), "b"), 7), soon)
+	defer cancelB()
+
+	ctx, cancel := ctxutil.Merge(a, b)
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(soon) {
+		t.Fatalf("Deadline = %v, %v; want the earlier %v", d, ok, soon)
+	}
+	if v, _ := key.Value(ctx); v != "a" {
+		t.Fatalf("value = %q, want a's", v)
+	}
+	if n, _ := other.Value(ctx); n != 7 {
+		t.Fatalf("value only in b = %d, want 7", n)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("Err before either ended = %v", ctx.Err())
+	}
+
+	cancel()
+	if err := ctx.Err(); err != context.Canceled {
+		t.Fatalf("Err after cancel = %v, want Canceled", err)
+	}
+	// Ending b afterwards changes nothing.
+	cancelB()
+	if err := ctx.Err(); err != context.Canceled {
+		t.Fatalf("Err after cancel and b's end = %v, want Canceled", err)
+	}
+}
+
+func TestMergeDerived(t *testing.T) {
+	shutdown := errors.New("shutting down")
+	for _, tt := range []struct {
+		name       string
+		b          func() (context.Context, context.CancelFunc)
+		err, cause error
+	}{
+		{"b's deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), time.Millisecond)
+		}, context.DeadlineExceeded, context.DeadlineExceeded},
+		{"b cancelled", cancelledWith(shutdown), context.Canceled, shutdown},
+	} {
+		a, cancelA := background()
+		b, cancelB := tt.b()
+		ctx, cancel := ctxutil.Merge(a, b)
+		child, cancelChild := context.WithCancel(ctx)
+		grandchild, cancelGrandchild := context.WithTimeout(child, time.Hour)
+
+		<-grandchild.Done()
+		for _, c := range []context.Context{ctx, child, grandchild} {
+			if err := c.Err(); err != tt.err {
+				t.Errorf("%s: Err = %v, want %v", tt.name, err, tt.err)
+			}
+			if cause := context.Cause(c); cause != tt.cause {
+				t.Errorf("%s: Cause = %v, want %v", tt.name, cause, tt.cause)
+			}
+		}
+		cancelGrandchild()
+		cancelChild()
+		cancel()
+		cancelA()
+		cancelB()
+	}
+}
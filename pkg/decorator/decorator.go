@@ -0,0 +1,92 @@
+package decorator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Invocation describes one call passing through an interceptor chain.
+type Invocation struct {
+	// Method is the name of the interface method being called.
+	Method string
+	// Args holds the call's arguments, excluding a leading context.
+	Args []any
+	// Results holds pointers to the call's non-error results. They are
+	// filled in once the wrapped method returns, and interceptors may
+	// write to them to replace the results, as Caching does.
+	Results []any
+}
+
+// Handler performs the rest of the call.
+type Handler func(ctx context.Context) error
+
+// Interceptor runs around a call. It must call next to reach the wrapped
+// implementation, or fill inv.Results itself to short-circuit it.
+type Interceptor func(ctx context.Context, inv *Invocation, next Handler) error
+
+// Chain composes interceptors so the first one is outermost.
+// A nil or empty chain calls next directly.
+func Chain(interceptors ...Interceptor) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Handler) error {
+		h := next
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], h
+			h = func(ctx context.Context) error {
+				return interceptor(ctx, inv, inner)
+			}
+		}
+		return h(ctx)
+	}
+}
+
+// Invoke runs call through interceptor. Generated decorators use it so
+// a nil interceptor costs nothing.
+func Invoke(ctx context.Context, interceptor Interceptor, inv *Invocation, call Handler) error {
+	if interceptor == nil {
+		return call(ctx)
+	}
+	return interceptor(ctx, inv, call)
+}
+
+// Snapshot copies the current values behind inv.Results.
+func (inv *Invocation) Snapshot() []any {
+	values := make([]any, len(inv.Results))
+	for i, ptr := range inv.Results {
+		values[i] = reflect.ValueOf(ptr).Elem().Interface()
+	}
+	return values
+}
+
+// Restore writes values into inv.Results. The values must have been
+// produced by Snapshot on an invocation of the same method.
+func (inv *Invocation) Restore(values []any) error {
+	if len(values) != len(inv.Results) {
+		return fmt.Errorf("decorator: %s has %d results, got %d values", inv.Method, len(inv.Results), len(values))
+	}
+	for i, ptr := range inv.Results {
+		dst := reflect.ValueOf(ptr).Elem()
+		if values[i] == nil {
+			dst.SetZero()
+			continue
+		}
+		src := reflect.ValueOf(values[i])
+		if !src.Type().AssignableTo(dst.Type()) {
+			return fmt.Errorf("decorator: %s result %d is %s, got %s", inv.Method, i, dst.Type(), src.Type())
+		}
+		dst.Set(src)
+	}
+	return nil
+}
+
+// String formats the invocation as Method(arg1, arg2).
+func (inv *Invocation) String() string {
+	s := inv.Method + "("
+	for i, arg := range inv.Args {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%v", arg)
+	}
+	return s + ")"
+}
@@ -0,0 +1,19 @@
+// Package decorator wraps interface implementations with reusable
+// interceptors such as logging, timing, retry and caching.
+//
+// The type embedding chapter builds decorators by hand: a Decorator
+// struct embeds a Component and a ConcreteDecorator overrides the
+// methods it cares about. The decorgen command automates the boring
+// part. Given an interface it emits a base struct whose methods route
+// every call through an Interceptor before reaching the wrapped value:
+//
+//	//go:generate go run github.com/thanhnamdk2710/go-handbook/cmd/decorgen -type UserRepository
+//
+//	repo := NewUserRepositoryDecorator(store,
+//	    decorator.Logging(logger),
+//	    decorator.Retry(retry.WithMaxAttempts(3)),
+//	)
+//
+// Embed the generated struct to override individual methods, exactly as
+// ConcreteDecorator embeds Decorator.
+package decorator
@@ -0,0 +1,86 @@
+package decorator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/cache"
+	"github.com/thanhnamdk2710/go-handbook/pkg/retry"
+)
+
+// Logging logs each call with its duration and error at debug level, or
+// at error level when the call fails.
+func Logging(logger *slog.Logger) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Handler) error {
+		start := time.Now()
+		err := next(ctx)
+
+		level := slog.LevelDebug
+		if err != nil {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(ctx, level, "call",
+			slog.String("method", inv.Method),
+			slog.Any("args", inv.Args),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err),
+		)
+		return err
+	}
+}
+
+// Timing reports the duration and outcome of each call to observe, for
+// example to record a histogram.
+func Timing(observe func(method string, d time.Duration, err error)) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Handler) error {
+		start := time.Now()
+		err := next(ctx)
+		observe(inv.Method, time.Since(start), err)
+		return err
+	}
+}
+
+// Retry retries failed calls with retry.Do, configured by opts. Errors
+// are classified by retry.Classify unless opts add a classifier, and a
+// method can stop retries by returning retry.MarkPermanent(err).
+func Retry(opts ...retry.Option) Interceptor {
+	return func(ctx context.Context, _ *Invocation, next Handler) error {
+		return retry.Do(ctx, next, opts...)
+	}
+}
+
+// Caching serves repeated calls from c. key builds the cache key for an
+// invocation and reports false for calls that must not be cached, such
+// as writes. Failed calls are never cached.
+func Caching(c *cache.Cache[string, []any], key func(inv *Invocation) (string, bool)) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Handler) error {
+		k, ok := key(inv)
+		if !ok {
+			return next(ctx)
+		}
+		if values, hit := c.Get(k); hit {
+			return inv.Restore(values)
+		}
+
+		if err := next(ctx); err != nil {
+			return err
+		}
+		c.Set(k, inv.Snapshot())
+		return nil
+	}
+}
+
+// MethodKey is a Caching key function that caches the listed methods by
+// name and formatted arguments.
+func MethodKey(methods ...string) func(inv *Invocation) (string, bool) {
+	return func(inv *Invocation) (string, bool) {
+		for _, m := range methods {
+			if m == inv.Method {
+				return fmt.Sprintf("%s%v", inv.Method, inv.Args), true
+			}
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,145 @@
+package deep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/deep"
+)
+
+type key struct{ n int }
+
+type record struct {
+	created time.Time
+	tags    map[string]any
+}
+
+type node struct {
+	Name string
+	Next *node
+}
+
+func TestDiffPaths(t *testing.T) {
+	type address struct{ City string }
+	type user struct {
+		Name      string
+		Addresses []address
+		Tags      map[string]int
+	}
+	a := user{Name: "Alice", Addresses: []address{{"Hanoi"}, {"Hanoi"}}, Tags: map[string]int{"go": 1}}
+	b := user{Name: "Alice", Addresses: []address{{"Hanoi"}, {"Saigon"}}, Tags: map[string]int{"go": 1, "rust": 3}}
+
+	got := deep.Report(deep.Diff(a, b))
+	want := ".Addresses[1].City: \"Hanoi\" → \"Saigon\"\n.Tags[\"rust\"]: <missing> → 3\n"
+	if got != want {
+		t.Fatalf("Diff:\n%s\nwant:\n%s", got, want)
+	}
+	if diffs := deep.Diff(a, b, deep.IgnorePaths(".Addresses[*].City", ".Tags")); len(diffs) != 0 {
+		t.Fatalf("ignored paths still reported: %v", diffs)
+	}
+}
+
+func TestMapKeysThatFormatAlike(t *testing.T) {
+	a := map[key]int{{1}: 1, {2}: 2}
+	b := map[key]int{{1}: 1, {2}: 99}
+	if diffs := deep.Diff(a, b, deep.AllowUnexported(key{})); len(diffs) != 1 {
+		t.Fatalf("Diff = %v, want one difference", diffs)
+	}
+
+	p1, p2 := &key{1}, &key{2}
+	pa := map[*key]int{p1: 1, p2: 2}
+	pb := map[*key]int{p1: 1, p2: 99}
+	if diffs := deep.Diff(pa, pb); len(diffs) != 1 {
+		t.Fatalf("Diff with pointer keys = %v, want one difference", diffs)
+	}
+	if got := strings.Count(deep.Sprint(a), ": "); got != 2 {
+		t.Fatalf("Sprint printed %d entries, want 2:\n%s", got, deep.Sprint(a))
+	}
+	if got := strings.Count(deep.Sprint(pa), ": "); got != 2 {
+		t.Fatalf("Sprint printed %d entries, want 2:\n%s", got, deep.Sprint(pa))
+	}
+}
+
+func TestEqualMethodOnUnexportedField(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	opt := deep.AllowUnexported(record{})
+
+	diffs := deep.Diff(record{created: t0}, record{created: t0.Add(time.Hour)}, opt)
+	if len(diffs) != 1 || diffs[0].Path != ".created" {
+		t.Fatalf("Diff = %v, want a difference at .created", diffs)
+	}
+	if !strings.Contains(diffs[0].B, "13:00:00") {
+		t.Errorf("difference does not format the time: %v", diffs[0])
+	}
+
+	// The same instant in another location is Equal.
+	if !deep.Equal(record{created: t0}, record{created: t0.In(time.FixedZone("ICT", 7*3600))}, opt) {
+		t.Error("Equal method not used for unexported time.Time")
+	}
+
+	// Reached through a map value, the field cannot be addressed and is
+	// compared with ==.
+	a := record{tags: map[string]any{"at": record{created: t0}}}
+	b := record{tags: map[string]any{"at": record{created: t0.Add(time.Second)}}}
+	if diffs := deep.Diff(a, b, opt); len(diffs) != 1 {
+		t.Fatalf("Diff through map = %v, want one difference", diffs)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	a := &node{Name: "a"}
+	a.Next = a
+	b := &node{Name: "a"}
+	b.Next = &node{Name: "a", Next: b}
+	if !deep.Equal(a, b) {
+		t.Error("equivalent cyclic lists reported different")
+	}
+	if got := deep.Sprint(a); !strings.Contains(got, "<cycle>") {
+		t.Errorf("Sprint of a cyclic list = %s", got)
+	}
+
+	m := map[string]any{}
+	m["self"] = m
+	n := map[string]any{}
+	n["self"] = n
+	if !deep.Equal(m, n) {
+		t.Error("self-referencing maps reported different")
+	}
+	if got := deep.Sprint(m); !strings.Contains(got, "<cycle>") {
+		t.Errorf("Sprint of a self-referencing map = %s", got)
+	}
+
+	s := []any{nil}
+	s[0] = s
+	u := []any{nil}
+	u[0] = u
+	if !deep.Equal(s, u) {
+		t.Error("self-referencing slices reported different")
+	}
+	if got := deep.Sprint(s); !strings.Contains(got, "<cycle>") {
+		t.Errorf("Sprint of a self-referencing slice = %s", got)
+	}
+}
+
+func TestSprintUnexported(t *testing.T) {
+	if got := deep.Sprint(key{3}); got != "key{}" {
+		t.Errorf("Sprint without AllowUnexported = %s", got)
+	}
+	if got, want := deep.Sprint(key{3}, deep.AllowUnexported(key{})), "key{\n\tn: 3,\n}"; got != want {
+		t.Errorf("Sprint = %q, want %q", got, want)
+	}
+
+	// Time values keep printing through String, and zero fields are left
+	// out, when every type's unexported fields are allowed.
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	want := "record{\n\tcreated: 2026-01-01 12:00:00 +0000 UTC,\n}"
+	if got := deep.Sprint(record{created: t0}, deep.AllowUnexported()); got != want {
+		t.Errorf("Sprint = %q, want %q", got, want)
+	}
+
+	diffs := deep.Diff([]key{{1}}, []key{{1}, {2}}, deep.AllowUnexported(key{}))
+	if len(diffs) != 1 || diffs[0].B != "key{n: 2}" {
+		t.Fatalf("Diff = %v, want the added key printed with its field", diffs)
+	}
+}
@@ -0,0 +1,330 @@
+package deep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// Difference is one mismatch between two values.
+type Difference struct {
+	Path string
+	A, B string // formatted values, or "<missing>"
+}
+
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("%s: %s → %s", path, d.A, d.B)
+}
+
+const missing = "<missing>"
+
+// Diff returns the differences between a and b.
+func Diff(a, b any, opts ...Option) []Difference {
+	d := &differ{cfg: newConfig(opts), visited: make(map[visit]bool)}
+	d.diff("", addressable(reflect.ValueOf(a)), addressable(reflect.ValueOf(b)))
+	return d.diffs
+}
+
+// Equal reports whether a and b have no differences.
+func Equal(a, b any, opts ...Option) bool {
+	return len(Diff(a, b, append(opts, MaxDiffs(1))...)) == 0
+}
+
+// Report formats differences one per line.
+func Report(diffs []Difference) string {
+	var b strings.Builder
+	for _, d := range diffs {
+		b.WriteString(d.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// TB is the subset of testing.TB used by Check.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Check reports a test error listing every difference between got and
+// want. It returns true when they are equal.
+func Check(t TB, got, want any, opts ...Option) bool {
+	t.Helper()
+	diffs := Diff(want, got, opts...)
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("mismatch (want → got):\n%s", Report(diffs))
+	return false
+}
+
+// visit records a pair of pointers, maps or slices already being
+// compared, so cycles terminate. Slices also record their lengths, since
+// two views of one array can differ.
+type visit struct {
+	a, b       uintptr
+	typ        reflect.Type
+	lenA, lenB int
+}
+
+type differ struct {
+	cfg     *config
+	visited map[visit]bool
+	diffs   []Difference
+}
+
+func (d *differ) done() bool {
+	return d.cfg.maxDiffs > 0 && len(d.diffs) >= d.cfg.maxDiffs
+}
+
+func (d *differ) report(path string, a, b reflect.Value) {
+	d.reportText(path, short(a, d.cfg), short(b, d.cfg))
+}
+
+func (d *differ) reportText(path, a, b string) {
+	if !d.done() {
+		d.diffs = append(d.diffs, Difference{Path: path, A: a, B: b})
+	}
+}
+
+func (d *differ) diff(path string, a, b reflect.Value) {
+	if d.done() || d.cfg.ignored(path) {
+		return
+	}
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.report(path, a, b)
+		}
+		return
+	}
+	if a.Type() != b.Type() {
+		d.reportText(path, a.Type().String()+"("+short(a, d.cfg)+")", b.Type().String()+"("+short(b, d.cfg)+")")
+		return
+	}
+
+	t := a.Type()
+	if d.cfg.ignoreTypes[t] {
+		return
+	}
+	if (a.Kind() == reflect.Struct || a.Kind() == reflect.Array) && !a.CanAddr() {
+		// Map values and interface contents: copy them so the fields
+		// below can be exported for Comparer and Equal methods.
+		a, b = addressable(a), addressable(b)
+	}
+	if cmp, ok := d.cfg.comparers[t]; ok {
+		ea, okA := exported(a)
+		eb, okB := exported(b)
+		switch {
+		case okA && okB:
+			if !cmp(ea, eb) {
+				d.report(path, a, b)
+			}
+		case !equalValue(a, b):
+			d.report(path, a, b)
+		}
+		return
+	}
+	if eq, ok := equalMethod(a, b); ok {
+		if !eq {
+			d.report(path, a, b)
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.report(path, a, b)
+			}
+			return
+		}
+		if a.Pointer() == b.Pointer() || d.seen(a, b) {
+			return
+		}
+		d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.report(path, a, b)
+			}
+			return
+		}
+		d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() && !d.cfg.showUnexported(t) {
+				continue
+			}
+			d.diff(path+"."+f.Name, a.Field(i), b.Field(i))
+		}
+
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice {
+			if d.cfg.equateEmpty && a.Len() == 0 && b.Len() == 0 {
+				return
+			}
+			if a.IsNil() != b.IsNil() {
+				d.report(path, a, b)
+				return
+			}
+			if a.Pointer() == b.Pointer() && a.Len() == b.Len() || d.seen(a, b) {
+				return
+			}
+		}
+		n := max(a.Len(), b.Len())
+		for i := 0; i < n; i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= a.Len():
+				if !d.cfg.ignored(p) {
+					d.reportText(p, missing, short(b.Index(i), d.cfg))
+				}
+			case i >= b.Len():
+				if !d.cfg.ignored(p) {
+					d.reportText(p, short(a.Index(i), d.cfg), missing)
+				}
+			default:
+				d.diff(p, a.Index(i), b.Index(i))
+			}
+		}
+
+	case reflect.Map:
+		if d.cfg.equateEmpty && a.Len() == 0 && b.Len() == 0 {
+			return
+		}
+		if a.IsNil() != b.IsNil() {
+			d.report(path, a, b)
+			return
+		}
+		if a.Pointer() == b.Pointer() || d.seen(a, b) {
+			return
+		}
+		keys := sortedKeys(append(a.MapKeys(), b.MapKeys()...))
+		for _, k := range keys {
+			p := fmt.Sprintf("%s[%s]", path, short(k, d.cfg))
+			av, bv := a.MapIndex(k), b.MapIndex(k)
+			switch {
+			case !av.IsValid():
+				if !d.cfg.ignored(p) {
+					d.reportText(p, missing, short(bv, d.cfg))
+				}
+			case !bv.IsValid():
+				if !d.cfg.ignored(p) {
+					d.reportText(p, short(av, d.cfg), missing)
+				}
+			default:
+				d.diff(p, av, bv)
+			}
+		}
+
+	case reflect.Func:
+		if !a.IsNil() || !b.IsNil() {
+			// Non-nil funcs are only equal to themselves, and even that is
+			// not observable; treat any non-nil func as different.
+			d.report(path, a, b)
+		}
+
+	default:
+		if !equalScalar(a, b) {
+			d.report(path, a, b)
+		}
+	}
+}
+
+// seen reports whether the pair of pointers, maps or slices has been
+// visited before, and marks it visited.
+func (d *differ) seen(a, b reflect.Value) bool {
+	v := visit{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+	if a.Kind() == reflect.Slice {
+		v.lenA, v.lenB = a.Len(), b.Len()
+	}
+	if d.visited[v] {
+		return true
+	}
+	d.visited[v] = true
+	return false
+}
+
+// addressable returns an addressable copy of v when v is not addressable
+// itself, so fields read from it can be exported. Values read from
+// unexported fields cannot be copied and are returned unchanged.
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// exported returns v in a form whose Interface method may be called.
+// Values read from unexported fields, which AllowUnexported lets the walk
+// reach, are re-read through their address. It reports false when v is
+// unexported and not addressable.
+func exported(v reflect.Value) (reflect.Value, bool) {
+	if v.CanInterface() {
+		return v, true
+	}
+	if !v.CanAddr() {
+		return v, false
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem(), true
+}
+
+// equalValue compares values that cannot be passed to a Comparer or an
+// Equal method with ==. Values that are not comparable are reported as
+// different rather than silently treated as equal.
+func equalValue(a, b reflect.Value) bool {
+	return a.Comparable() && a.Equal(b)
+}
+
+// equalMethod uses an Equal(T) bool method when the type has one.
+func equalMethod(a, b reflect.Value) (equal, ok bool) {
+	m, found := a.Type().MethodByName("Equal")
+	if !found {
+		return false, false
+	}
+	mt := m.Type
+	if mt.NumIn() != 2 || mt.In(1) != a.Type() || mt.NumOut() != 1 || mt.Out(0).Kind() != reflect.Bool {
+		return false, false
+	}
+	if a.Kind() == reflect.Pointer && (a.IsNil() || b.IsNil()) {
+		return false, false
+	}
+	ea, okA := exported(a)
+	eb, okB := exported(b)
+	if !okA || !okB {
+		return equalValue(a, b), true
+	}
+	return m.Func.Call([]reflect.Value{ea, eb})[0].Bool(), true
+}
+
+func equalScalar(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,16 @@
+// Package deep compares nested values and pretty-prints them for test
+// failures.
+//
+// Diff walks two values of the same type and reports every difference
+// with the path that leads to it:
+//
+//	.Addresses[1].City: "Hanoi" → "Saigon"
+//	.Tags["go"]: <missing> → 3
+//
+// Cycles are detected, map entries are visited in a stable order, and
+// unexported fields are skipped unless AllowUnexported is given. Types
+// with an Equal(T) bool method, such as time.Time, are compared with it.
+//
+// Sprint formats a value as indented Go-like syntax with sorted map keys,
+// so the same value always prints the same way.
+package deep
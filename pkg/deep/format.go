@@ -0,0 +1,312 @@
+package deep
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sprint formats v as indented Go-like syntax. Map keys are sorted and
+// cycles are printed as <cycle>, so output is stable across runs. Zero
+// fields are left out, as in a composite literal, and unexported fields
+// are printed only as AllowUnexported allows; no other option applies.
+func Sprint(v any, opts ...Option) string {
+	p := &printer{cfg: newConfig(opts), visiting: make(map[visit]bool)}
+	p.print(addressable(reflect.ValueOf(v)), 0, true)
+	return p.b.String()
+}
+
+type printer struct {
+	cfg      *config
+	b        strings.Builder
+	visiting map[visit]bool // pointers, maps and slices being printed
+	inline   bool           // single line, used for values inside a Difference
+}
+
+// enter marks v as being printed and reports false if it already is,
+// which means v contains itself.
+func (p *printer) enter(v reflect.Value) bool {
+	k := visit{a: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		k.lenA = v.Len()
+	}
+	if p.visiting[k] {
+		p.b.WriteString("<cycle>")
+		return false
+	}
+	p.visiting[k] = true
+	return true
+}
+
+func (p *printer) leave(v reflect.Value) {
+	k := visit{a: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		k.lenA = v.Len()
+	}
+	delete(p.visiting, k)
+}
+
+func (p *printer) print(v reflect.Value, depth int, withType bool) {
+	if !v.IsValid() {
+		p.b.WriteString("nil")
+		return
+	}
+	if s, ok := stringer(v, p.cfg); ok {
+		p.b.WriteString(s)
+		return
+	}
+
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			p.b.WriteString("nil")
+			return
+		}
+		if !p.enter(v) {
+			return
+		}
+		defer p.leave(v)
+		p.b.WriteByte('&')
+		p.print(v.Elem(), depth, true)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			p.b.WriteString("nil")
+			return
+		}
+		p.print(v.Elem(), depth, true)
+
+	case reflect.Struct:
+		if withType {
+			p.b.WriteString(typeName(t))
+		}
+		p.b.WriteByte('{')
+		n := 0
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if (!f.IsExported() && !p.cfg.showUnexported(t)) || v.Field(i).IsZero() {
+				continue
+			}
+			p.itemStart(depth+1, n)
+			p.b.WriteString(f.Name + ": ")
+			p.print(v.Field(i), depth+1, true)
+			p.itemEnd()
+			n++
+		}
+		p.close(depth, n)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			p.b.WriteString("nil")
+			return
+		}
+		if t.Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			fmt.Fprintf(&p.b, "[]byte(%q)", v.Bytes())
+			return
+		}
+		if v.Kind() == reflect.Slice {
+			if !p.enter(v) {
+				return
+			}
+			defer p.leave(v)
+		}
+		if withType {
+			p.b.WriteString(typeName(t))
+		}
+		p.b.WriteByte('{')
+		for i := 0; i < v.Len(); i++ {
+			p.itemStart(depth+1, i)
+			p.print(v.Index(i), depth+1, false)
+			p.itemEnd()
+		}
+		p.close(depth, v.Len())
+
+	case reflect.Map:
+		if v.IsNil() {
+			p.b.WriteString("nil")
+			return
+		}
+		if !p.enter(v) {
+			return
+		}
+		defer p.leave(v)
+		if withType {
+			p.b.WriteString(typeName(t))
+		}
+		p.b.WriteByte('{')
+		for i, k := range sortedKeys(v.MapKeys()) {
+			p.itemStart(depth+1, i)
+			p.print(k, depth+1, false)
+			p.b.WriteString(": ")
+			p.print(v.MapIndex(k), depth+1, false)
+			p.itemEnd()
+		}
+		p.close(depth, v.Len())
+
+	default:
+		p.b.WriteString(scalar(v))
+	}
+}
+
+// itemStart begins the i-th element of a composite literal.
+func (p *printer) itemStart(depth, i int) {
+	if p.inline {
+		if i > 0 {
+			p.b.WriteString(", ")
+		}
+		return
+	}
+	p.b.WriteByte('\n')
+	p.b.WriteString(strings.Repeat("\t", depth))
+}
+
+func (p *printer) itemEnd() {
+	if !p.inline {
+		p.b.WriteByte(',')
+	}
+}
+
+// close ends a composite literal holding n elements.
+func (p *printer) close(depth, n int) {
+	if !p.inline && n > 0 {
+		p.b.WriteByte('\n')
+		p.b.WriteString(strings.Repeat("\t", depth))
+	}
+	p.b.WriteByte('}')
+}
+
+// short formats a value on one line for use in a Difference.
+func short(v reflect.Value, cfg *config) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	if s, ok := stringer(v, cfg); ok {
+		return s
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		return short(v.Elem(), cfg)
+	case reflect.Pointer, reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+			return "nil"
+		}
+		p := &printer{cfg: cfg, visiting: make(map[visit]bool), inline: true}
+		p.print(v, 0, true)
+		return p.b.String()
+	default:
+		return scalar(v)
+	}
+}
+
+// stringer uses String or Error for types like time.Time that have no
+// exported fields to print. A type named in AllowUnexported prints its
+// fields instead.
+func stringer(v reflect.Value, cfg *config) (string, bool) {
+	v, ok := exported(v)
+	if !ok {
+		return "", false
+	}
+	if v.Kind() == reflect.Struct && (hasExportedFields(v.Type()) || cfg.unexported[v.Type()]) {
+		return "", false
+	}
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return "", false
+	}
+	switch s := v.Interface().(type) {
+	case error:
+		return strconv.Quote(s.Error()), true
+	case fmt.Stringer:
+		if v.Kind() == reflect.Struct {
+			return s.String(), true
+		}
+	}
+	return "", false
+}
+
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+func scalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, 128)
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if v.IsNil() {
+			return "nil"
+		}
+		return fmt.Sprintf("%s(%#x)", typeName(v.Type()), v.Pointer())
+	default:
+		return "<" + v.Kind().String() + ">"
+	}
+}
+
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
+
+// sortedKeys orders map keys naturally for numbers, strings and bools,
+// and by their formatted value otherwise. Keys that are == are merged so
+// the keys of two maps can be combined; distinct keys that format the
+// same are kept.
+func sortedKeys(keys []reflect.Value) []reflect.Value {
+	slices.SortStableFunc(keys, compareKeys)
+	out := keys[:0]
+	run := 0 // start of the kept keys that sort equal to the current one
+	for _, k := range keys {
+		if run < len(out) && compareKeys(out[run], k) != 0 {
+			run = len(out)
+		}
+		if !slices.ContainsFunc(out[run:], k.Equal) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func compareKeys(a, b reflect.Value) int {
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp.Compare(a.Int(), b.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return cmp.Compare(a.Uint(), b.Uint())
+		case reflect.Float32, reflect.Float64:
+			return cmp.Compare(a.Float(), b.Float())
+		case reflect.String:
+			return cmp.Compare(a.String(), b.String())
+		case reflect.Bool:
+			return cmp.Compare(strconv.FormatBool(a.Bool()), strconv.FormatBool(b.Bool()))
+		}
+	}
+	return cmp.Compare(short(a, keyOrder), short(b, keyOrder))
+}
+
+// keyOrder formats other keys with all their fields, so keys that differ
+// only in unexported fields still sort apart.
+var keyOrder = &config{allUnexported: true}
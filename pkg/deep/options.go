@@ -0,0 +1,149 @@
+package deep
+
+import (
+	"reflect"
+	"strings"
+)
+
+type config struct {
+	allUnexported bool
+	unexported    map[reflect.Type]bool
+	ignoreTypes   map[reflect.Type]bool
+	ignorePaths   []string
+	comparers     map[reflect.Type]func(a, b reflect.Value) bool
+	equateEmpty   bool
+	maxDiffs      int
+}
+
+// Option configures Diff, Equal and Sprint.
+type Option func(*config)
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		unexported:  make(map[reflect.Type]bool),
+		ignoreTypes: make(map[reflect.Type]bool),
+		comparers:   make(map[reflect.Type]func(a, b reflect.Value) bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// AllowUnexported compares and prints the unexported fields of the
+// struct types of the given values. With no arguments, unexported fields
+// of every struct are compared and printed.
+func AllowUnexported(types ...any) Option {
+	return func(c *config) {
+		if len(types) == 0 {
+			c.allUnexported = true
+		}
+		for _, t := range types {
+			c.unexported[indirectType(t)] = true
+		}
+	}
+}
+
+// showUnexported reports whether AllowUnexported covers the struct type t.
+func (c *config) showUnexported(t reflect.Type) bool {
+	return c.allUnexported || c.unexported[t]
+}
+
+// IgnoreTypes skips values whose type matches one of the given values.
+func IgnoreTypes(types ...any) Option {
+	return func(c *config) {
+		for _, t := range types {
+			c.ignoreTypes[indirectType(t)] = true
+		}
+	}
+}
+
+// IgnorePaths skips the given paths and everything below them. A path
+// is written as it appears in a Difference, and "[*]" matches any slice
+// index or map key: ".ID", ".Addresses[*].UpdatedAt".
+func IgnorePaths(paths ...string) Option {
+	return func(c *config) {
+		c.ignorePaths = append(c.ignorePaths, paths...)
+	}
+}
+
+// Comparer uses equal to compare values of type T instead of walking
+// into them.
+func Comparer[T any](equal func(a, b T) bool) Option {
+	t := reflect.TypeFor[T]()
+	return func(c *config) {
+		c.comparers[t] = func(a, b reflect.Value) bool {
+			return equal(a.Interface().(T), b.Interface().(T))
+		}
+	}
+}
+
+// EquateEmpty treats nil and empty slices and maps as equal.
+func EquateEmpty() Option {
+	return func(c *config) {
+		c.equateEmpty = true
+	}
+}
+
+// MaxDiffs stops the comparison after n differences.
+func MaxDiffs(n int) Option {
+	return func(c *config) {
+		c.maxDiffs = n
+	}
+}
+
+func (c *config) ignored(path string) bool {
+	for _, pattern := range c.ignorePaths {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath reports whether path equals pattern or lies below it, with
+// "[*]" in pattern matching any single index or key.
+func matchPath(pattern, path string) bool {
+	for {
+		star := strings.Index(pattern, "[*]")
+		if star < 0 {
+			return path == pattern || strings.HasPrefix(path, pattern+".") || strings.HasPrefix(path, pattern+"[")
+		}
+		if !strings.HasPrefix(path, pattern[:star]) {
+			return false
+		}
+		path = path[star:]
+		end := closingBracket(path)
+		if end < 0 {
+			return false
+		}
+		pattern, path = pattern[star+3:], path[end+1:]
+	}
+}
+
+// closingBracket finds the bracket closing path[0], skipping quoted keys.
+func closingBracket(path string) int {
+	if path == "" || path[0] != '[' {
+		return -1
+	}
+	quoted := false
+	for i := 1; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && quoted:
+			i++
+		case path[i] == '"':
+			quoted = !quoted
+		case path[i] == ']' && !quoted:
+			return i
+		}
+	}
+	return -1
+}
+
+func indirectType(v any) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
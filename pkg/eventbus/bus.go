@@ -0,0 +1,254 @@
+package eventbus
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+var (
+	// ErrClosed is returned by Publish and Subscribe after Close, and by
+	// Subscription.Err for subscriptions the bus closed.
+	ErrClosed = errors.New("eventbus: closed")
+	// ErrSlowConsumer is reported by Subscription.Err when the Disconnect
+	// policy removed the subscriber.
+	ErrSlowConsumer = errors.New("eventbus: slow consumer disconnected")
+	// ErrInvalidName is returned for malformed topic names and patterns.
+	ErrInvalidName = errors.New("eventbus: invalid topic name")
+)
+
+type options struct {
+	history int
+	clock   clock.Clock
+}
+
+// Option configures a Bus.
+type Option func(*options)
+
+// WithHistory keeps the last n published messages for replay. The
+// default is 0, which disables replay.
+func WithHistory(n int) Option {
+	return func(o *options) {
+		o.history = n
+	}
+}
+
+// WithClock sets the clock used for timestamps and acknowledgment
+// deadlines. The default is clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// envelope is a published message before it is typed for a subscriber.
+type envelope struct {
+	id      uint64
+	topic   string
+	payload any
+	time    time.Time
+}
+
+// subscriber is the untyped view of a Subscription the bus works with.
+type subscriber interface {
+	matches(topic string) bool
+	offer(ctx context.Context, env envelope) error
+	replay(env envelope)
+	setID(id uint64)
+	shut(err error)
+	metrics() SubscriberMetrics
+}
+
+// Bus routes messages from topics to subscriptions. It is safe for
+// concurrent use.
+type Bus struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	seq     uint64
+	nextSub uint64
+	subs    map[uint64]subscriber
+	history *containers.RingBuffer[envelope]
+	closed  bool
+}
+
+// New returns an empty Bus.
+func New(opts ...Option) *Bus {
+	o := options{clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	b := &Bus{clock: o.clock, subs: make(map[uint64]subscriber)}
+	if o.history > 0 {
+		b.history = containers.NewRingBuffer[envelope](o.history, containers.Overwrite)
+	}
+	return b
+}
+
+// Close disconnects every subscription with ErrClosed and makes later
+// publishes fail.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = nil
+	b.mu.Unlock()
+	for _, s := range subs {
+		s.shut(ErrClosed)
+	}
+}
+
+// Subscribers returns metrics for every live subscription, in
+// subscription order.
+func (b *Bus) Subscribers() []SubscriberMetrics {
+	b.mu.Lock()
+	subs := make([]subscriber, 0, len(b.subs))
+	for _, s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+
+	out := make([]SubscriberMetrics, len(subs))
+	for i, s := range subs {
+		out[i] = s.metrics()
+	}
+	slices.SortFunc(out, func(a, b SubscriberMetrics) int { return cmp.Compare(a.ID, b.ID) })
+	return out
+}
+
+// publish assigns env an ID, records it and offers it to matching
+// subscribers. Subscribers are snapshotted under the same lock that
+// appends history, so a new subscription sees each message exactly once.
+func (b *Bus) publish(ctx context.Context, topic string, payload any) (uint64, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return 0, ErrClosed
+	}
+	b.seq++
+	env := envelope{id: b.seq, topic: topic, payload: payload, time: b.clock.Now()}
+	if b.history != nil {
+		b.history.Write(env)
+	}
+	var targets []subscriber
+	for _, s := range b.subs {
+		if s.matches(topic) {
+			targets = append(targets, s)
+		}
+	}
+	b.mu.Unlock()
+
+	var errs []error
+	for _, s := range targets {
+		if err := s.offer(ctx, env); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return env.id, errors.Join(errs...)
+}
+
+// register gives s an ID, replays retained messages from ID from
+// onwards and adds it. The ID is set before s is published to
+// concurrent publishers.
+func (b *Bus) register(s subscriber, replay bool, from uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	b.nextSub++
+	id := b.nextSub
+	s.setID(id)
+	if replay && b.history != nil {
+		for env := range b.history.All() {
+			if env.id >= from && s.matches(env.topic) {
+				s.replay(env)
+			}
+		}
+	}
+	b.subs[id] = s
+	return nil
+}
+
+func (b *Bus) unregister(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, id)
+}
+
+// Topic publishes payloads of type T under one name.
+type Topic[T any] struct {
+	bus  *Bus
+	name string
+}
+
+// NewTopic returns the topic called name on b. Names are dot-separated,
+// non-empty segments without wildcards; NewTopic panics on a malformed
+// name, which is a programming error.
+func NewTopic[T any](b *Bus, name string) Topic[T] {
+	if err := validate(name, false); err != nil {
+		panic(err)
+	}
+	return Topic[T]{bus: b, name: name}
+}
+
+// Name returns the topic's name.
+func (t Topic[T]) Name() string { return t.name }
+
+// Publish sends payload to every matching subscription and returns the
+// message ID. It only waits for subscriptions with the Block policy,
+// and returns ctx's error if one of them is still full when ctx ends.
+func (t Topic[T]) Publish(ctx context.Context, payload T) (uint64, error) {
+	return t.bus.publish(ctx, t.name, payload)
+}
+
+// validate checks a topic name or, if wildcards is set, a pattern.
+func validate(name string, wildcards bool) error {
+	segs := strings.Split(name, ".")
+	for i, seg := range segs {
+		switch {
+		case seg == "":
+			return fmt.Errorf("%w: %q has an empty segment", ErrInvalidName, name)
+		case seg == "*" || seg == ">":
+			if !wildcards {
+				return fmt.Errorf("%w: %q contains a wildcard", ErrInvalidName, name)
+			}
+			if seg == ">" && i != len(segs)-1 {
+				return fmt.Errorf("%w: %q has \">\" before the last segment", ErrInvalidName, name)
+			}
+		case strings.ContainsAny(seg, "*>"):
+			return fmt.Errorf("%w: %q mixes a wildcard into a segment", ErrInvalidName, name)
+		}
+	}
+	return nil
+}
+
+// match reports whether topic matches the split pattern.
+func match(pattern []string, topic string) bool {
+	for i, p := range pattern {
+		if p == ">" {
+			return topic != ""
+		}
+		seg, rest, more := strings.Cut(topic, ".")
+		if topic == "" || (p != "*" && p != seg) {
+			return false
+		}
+		if !more {
+			return i == len(pattern)-1
+		}
+		topic = rest
+	}
+	return false
+}
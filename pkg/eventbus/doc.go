@@ -0,0 +1,37 @@
+// Package eventbus is an in-process publish/subscribe bus. It gives the
+// channel Producer of the channels chapter, the websocket Hub and Room
+// and the transactions chapter's event store one fan-out mechanism.
+//
+// Topics are dot-separated names such as "orders.eu.created" and carry a
+// payload type fixed by NewTopic. Subscribers use patterns in which "*"
+// matches one segment and ">" matches one or more trailing segments, so
+// "orders.*.created" and "orders.>" both match the topic above. A
+// subscription only receives messages whose payload has its type
+// parameter; Subscribe[any] receives everything.
+//
+//	bus := eventbus.New(eventbus.WithHistory(1000))
+//	created := eventbus.NewTopic[OrderCreated](bus, "orders.eu.created")
+//
+//	sub, _ := eventbus.Subscribe[OrderCreated](bus, "orders.>",
+//	    eventbus.WithBuffer(64),
+//	    eventbus.WithPolicy(eventbus.DropOldest),
+//	    eventbus.WithAck(30*time.Second, 5),
+//	)
+//	go func() {
+//	    for msg := range sub.C() {
+//	        if err := handle(msg.Payload); err == nil {
+//	            msg.Ack()
+//	        }
+//	    }
+//	}()
+//
+//	created.Publish(ctx, OrderCreated{ID: 42})
+//
+// Every subscription has its own bounded buffer, so a slow subscriber
+// never delays the others. What happens when it fills is the
+// subscription's Policy: drop the oldest queued message, block the
+// publisher, or disconnect the subscriber. With WithAck, delivery is
+// at-least-once: messages not acknowledged in time, or rejected with
+// Nack, are delivered again. WithReplay delivers retained history before
+// live messages, without gaps or duplicates.
+package eventbus
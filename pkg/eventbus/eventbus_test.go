@@ -0,0 +1,319 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/eventbus"
+)
+
+// receive returns the next message on sub, failing the test if none
+// arrives.
+func receive[T any](t *testing.T, sub *eventbus.Subscription[T]) eventbus.Message[T] {
+	t.Helper()
+	select {
+	case msg, ok := <-sub.C():
+		if !ok {
+			t.Fatalf("subscription ended: %v", sub.Err())
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return eventbus.Message[T]{}
+	}
+}
+
+// waitFor blocks until sub's metrics satisfy cond. Nothing observable
+// happens when the delivery goroutine takes a message off the queue, so
+// it yields rather than sleeping.
+func waitFor[T any](t *testing.T, sub *eventbus.Subscription[T], what string, cond func(eventbus.SubscriberMetrics) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond(sub.Metrics()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s: %+v", what, sub.Metrics())
+		}
+		runtime.Gosched()
+	}
+}
+
+// waitForHeld blocks until the delivery goroutine holds the only queued
+// message, so that the buffer is empty again.
+func waitForHeld[T any](t *testing.T, sub *eventbus.Subscription[T]) {
+	t.Helper()
+	waitFor(t, sub, "the first message to leave the queue", func(m eventbus.SubscriberMetrics) bool { return m.Queued == 0 })
+}
+
+func publish[T any](t *testing.T, topic eventbus.Topic[T], payload T) uint64 {
+	t.Helper()
+	id, err := topic.Publish(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestWildcardRouting(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+	euCreated := eventbus.NewTopic[string](bus, "orders.eu.created")
+	usPaid := eventbus.NewTopic[string](bus, "orders.us.paid")
+	count := eventbus.NewTopic[int](bus, "orders.eu.count")
+
+	created, _ := eventbus.Subscribe[string](bus, "orders.*.created")
+	all, _ := eventbus.Subscribe[any](bus, "orders.>")
+	strs, _ := eventbus.Subscribe[string](bus, "orders.>")
+
+	publish(t, euCreated, "a")
+	publish(t, usPaid, "b")
+	publish(t, count, 3)
+
+	if msg := receive(t, created); msg.Topic != "orders.eu.created" || msg.Payload != "a" {
+		t.Fatalf("orders.*.created got %+v", msg)
+	}
+	for _, want := range []any{"a", "b", 3} {
+		if msg := receive(t, all); msg.Payload != want {
+			t.Fatalf("orders.> as any got %v, want %v", msg.Payload, want)
+		}
+	}
+	// Payloads of another type are not delivered.
+	for _, want := range []string{"a", "b"} {
+		if msg := receive(t, strs); msg.Payload != want {
+			t.Fatalf("orders.> as string got %v, want %v", msg.Payload, want)
+		}
+	}
+	publish(t, euCreated, "c")
+	if msg := receive(t, strs); msg.Payload != "c" {
+		t.Fatalf("orders.> as string got %v after an int was published", msg.Payload)
+	}
+
+	if _, err := eventbus.Subscribe[string](bus, "orders.>.created"); !errors.Is(err, eventbus.ErrInvalidName) {
+		t.Fatalf("Subscribe with a misplaced > = %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewTopic with a wildcard did not panic")
+		}
+	}()
+	eventbus.NewTopic[string](bus, "orders.*")
+}
+
+func TestDropOldest(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+	topic := eventbus.NewTopic[int](bus, "n")
+	sub, _ := eventbus.Subscribe[int](bus, "n", eventbus.WithBuffer(2), eventbus.WithPolicy(eventbus.DropOldest))
+
+	publish(t, topic, 1)
+	waitForHeld(t, sub)
+	for n := 2; n <= 4; n++ {
+		publish(t, topic, n)
+	}
+	for _, want := range []int{1, 3, 4} {
+		if msg := receive(t, sub); msg.Payload != want {
+			t.Fatalf("got %d, want %d", msg.Payload, want)
+		}
+	}
+	// Delivered counts once the send has completed.
+	waitFor(t, sub, "the last delivery", func(m eventbus.SubscriberMetrics) bool { return m.Delivered == 3 })
+	if m := sub.Metrics(); m.Dropped != 1 {
+		t.Fatalf("Metrics = %+v", m)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+	topic := eventbus.NewTopic[int](bus, "n")
+	sub, _ := eventbus.Subscribe[int](bus, "n", eventbus.WithBuffer(1), eventbus.WithPolicy(eventbus.Block))
+
+	publish(t, topic, 1)
+	waitForHeld(t, sub)
+	publish(t, topic, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := topic.Publish(ctx, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Publish to a full subscriber = %v, want the deadline", err)
+	}
+
+	published := make(chan error, 1)
+	go func() {
+		_, err := topic.Publish(context.Background(), 4)
+		published <- err
+	}()
+	waitFor(t, sub, "the second blocked publish", func(m eventbus.SubscriberMetrics) bool { return m.Blocked == 2 })
+	for _, want := range []int{1, 2, 4} {
+		if msg := receive(t, sub); msg.Payload != want {
+			t.Fatalf("got %d, want %d", msg.Payload, want)
+		}
+	}
+	if err := <-published; err != nil {
+		t.Fatalf("blocked Publish = %v once there was room", err)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	bus := eventbus.New()
+	defer bus.Close()
+	topic := eventbus.NewTopic[int](bus, "n")
+	slow, _ := eventbus.Subscribe[int](bus, "n", eventbus.WithBuffer(1), eventbus.WithPolicy(eventbus.Disconnect))
+	other, _ := eventbus.Subscribe[int](bus, "n")
+
+	publish(t, topic, 1)
+	waitForHeld(t, slow)
+	publish(t, topic, 2)
+	publish(t, topic, 3)
+
+	<-slow.Done()
+	for range slow.C() {
+	}
+	if !errors.Is(slow.Err(), eventbus.ErrSlowConsumer) {
+		t.Fatalf("Err = %v, want ErrSlowConsumer", slow.Err())
+	}
+	if subs := bus.Subscribers(); len(subs) != 1 || subs[0].ID != other.Metrics().ID {
+		t.Fatalf("Subscribers = %+v, want only the other subscription", subs)
+	}
+	for _, want := range []int{1, 2, 3} {
+		if msg := receive(t, other); msg.Payload != want {
+			t.Fatalf("other subscriber got %d, want %d", msg.Payload, want)
+		}
+	}
+}
+
+func TestAckRedelivery(t *testing.T) {
+	fc := clock.NewFake(time.Unix(1000, 0))
+	bus := eventbus.New(eventbus.WithClock(fc))
+	defer bus.Close()
+	topic := eventbus.NewTopic[string](bus, "jobs")
+	sub, _ := eventbus.Subscribe[string](bus, "jobs", eventbus.WithAck(time.Second, 3))
+
+	id := publish(t, topic, "a")
+	publish(t, topic, "b")
+
+	// Nack redelivers at once.
+	msg := receive(t, sub)
+	if msg.ID != id || msg.Delivery != 1 {
+		t.Fatalf("first delivery %+v", msg)
+	}
+	msg.Nack()
+	if msg = receive(t, sub); msg.ID != id || msg.Delivery != 2 {
+		t.Fatalf("after Nack got %+v, want the same message again", msg)
+	}
+
+	// Not acknowledged in time: delivered once more, then dead-lettered.
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	if msg = receive(t, sub); msg.ID != id || msg.Delivery != 3 {
+		t.Fatalf("after the ack timeout got %+v", msg)
+	}
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+
+	// The next message waited for the first to be settled.
+	if msg = receive(t, sub); msg.Payload != "b" || msg.Delivery != 1 {
+		t.Fatalf("got %+v, want b", msg)
+	}
+	msg.Ack()
+	msg.Ack() // no-op
+	waitFor(t, sub, "the last delivery", func(m eventbus.SubscriberMetrics) bool { return m.Delivered == 4 })
+	m := sub.Metrics()
+	if m.InFlight != 0 || m.Redelivered != 2 || m.DeadLettered != 1 || m.Acked != 1 {
+		t.Fatalf("Metrics = %+v", m)
+	}
+}
+
+func TestAckInTime(t *testing.T) {
+	fc := clock.NewFake(time.Unix(1000, 0))
+	bus := eventbus.New(eventbus.WithClock(fc))
+	defer bus.Close()
+	topic := eventbus.NewTopic[string](bus, "jobs")
+	sub, _ := eventbus.Subscribe[string](bus, "jobs", eventbus.WithAck(time.Second, 0), eventbus.WithMaxInFlight(2))
+
+	publish(t, topic, "a")
+	publish(t, topic, "b")
+	a, b := receive(t, sub), receive(t, sub)
+	fc.BlockUntil(1)
+	fc.Advance(time.Second - 1)
+	a.Ack()
+	b.Ack()
+	fc.Advance(time.Hour)
+
+	publish(t, topic, "c")
+	if msg := receive(t, sub); msg.Payload != "c" {
+		t.Fatalf("got %+v, want no redelivery of acknowledged messages", msg)
+	}
+	if m := sub.Metrics(); m.Redelivered != 0 || m.Acked != 2 {
+		t.Fatalf("Metrics = %+v", m)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	bus := eventbus.New(eventbus.WithHistory(3))
+	defer bus.Close()
+	topic := eventbus.NewTopic[int](bus, "n")
+	other := eventbus.NewTopic[int](bus, "other")
+	for n := 1; n <= 5; n++ {
+		publish(t, topic, n)
+	}
+	publish(t, other, 6)
+
+	// The history holds the last three messages, 4, 5 and 6.
+	all, _ := eventbus.Subscribe[int](bus, "n", eventbus.WithReplay(0), eventbus.WithBuffer(1))
+	from, _ := eventbus.Subscribe[int](bus, "n", eventbus.WithReplay(5))
+	live, _ := eventbus.Subscribe[int](bus, "n")
+	publish(t, topic, 7)
+
+	for _, tt := range []struct {
+		sub  *eventbus.Subscription[int]
+		want []int
+	}{
+		{all, []int{4, 5, 7}},
+		{from, []int{5, 7}},
+		{live, []int{7}},
+	} {
+		for _, want := range tt.want {
+			if msg := receive(t, tt.sub); msg.Payload != want || msg.ID != uint64(want) {
+				t.Fatalf("%s got %+v, want %d", tt.sub.Metrics().Pattern, msg, want)
+			}
+		}
+	}
+	if m := all.Metrics(); m.Dropped != 0 {
+		t.Fatalf("replay counted against the buffer: %+v", m)
+	}
+}
+
+func TestSubscribers(t *testing.T) {
+	bus := eventbus.New()
+	a, _ := eventbus.Subscribe[int](bus, "a.>", eventbus.WithPolicy(eventbus.Block))
+	b, _ := eventbus.Subscribe[int](bus, "b")
+	c, _ := eventbus.Subscribe[int](bus, "c")
+	c.Unsubscribe()
+	if c.Err() != nil {
+		t.Fatalf("Err after Unsubscribe = %v", c.Err())
+	}
+
+	subs := bus.Subscribers()
+	if len(subs) != 2 || subs[0].ID != a.Metrics().ID || subs[1].ID != b.Metrics().ID || subs[0].ID >= subs[1].ID {
+		t.Fatalf("Subscribers = %+v, want a then b", subs)
+	}
+	if subs[0].Pattern != "a.>" || subs[0].Policy != eventbus.Block || subs[1].Policy != eventbus.DropOldest {
+		t.Fatalf("Subscribers = %+v", subs)
+	}
+
+	bus.Close()
+	<-a.Done()
+	if !errors.Is(a.Err(), eventbus.ErrClosed) {
+		t.Fatalf("Err after Close = %v", a.Err())
+	}
+	if _, err := eventbus.NewTopic[int](bus, "b").Publish(context.Background(), 1); !errors.Is(err, eventbus.ErrClosed) {
+		t.Fatalf("Publish after Close = %v", err)
+	}
+	if _, err := eventbus.Subscribe[int](bus, "b"); !errors.Is(err, eventbus.ErrClosed) {
+		t.Fatalf("Subscribe after Close = %v", err)
+	}
+}
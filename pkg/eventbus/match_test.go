@@ -0,0 +1,58 @@
+package eventbus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"orders.eu.created", "orders.eu.created", true},
+		{"orders.eu.created", "orders.us.created", false},
+		{"orders.eu", "orders.eu.created", false},
+		{"orders.eu.created", "orders.eu", false},
+		{"orders.*.created", "orders.eu.created", true},
+		{"orders.*.created", "orders.eu.paid", false},
+		{"orders.*", "orders.eu.created", false},
+		{"*", "orders", true},
+		{"*.*", "orders", false},
+		{"orders.>", "orders.eu", true},
+		{"orders.>", "orders.eu.created", true},
+		{"orders.>", "orders", false},
+		{">", "orders.eu.created", true},
+		{"*.eu.>", "orders.eu.created.v2", true},
+		{"*.eu.>", "orders.us.created", false},
+	} {
+		if got := match(strings.Split(tt.pattern, "."), tt.topic); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		wildcards bool
+		ok        bool
+	}{
+		{"orders.eu.created", false, true},
+		{"orders.*.created", true, true},
+		{"orders.>", true, true},
+		{"orders.*", false, false},
+		{"orders..created", true, false},
+		{".orders", true, false},
+		{"", true, false},
+		{"orders.>.created", true, false},
+		{"orders.eu*", true, false},
+		{"orders.>x", true, false},
+	} {
+		err := validate(tt.name, tt.wildcards)
+		if (err == nil) != tt.ok || (err != nil && !errors.Is(err, ErrInvalidName)) {
+			t.Errorf("validate(%q, %v) = %v, want ok %v", tt.name, tt.wildcards, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+// Policy decides what a full subscription buffer does with a new
+// message.
+type Policy int
+
+const (
+	// DropOldest discards the oldest queued message to make room.
+	DropOldest Policy = iota
+	// Block makes Publish wait for room or for its context to end.
+	Block
+	// Disconnect closes the subscription with ErrSlowConsumer.
+	Disconnect
+)
+
+func (p Policy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop-oldest"
+	case Block:
+		return "block"
+	case Disconnect:
+		return "disconnect"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+type subOptions struct {
+	buffer        int
+	policy        Policy
+	ackTimeout    time.Duration
+	maxInFlight   int
+	maxDeliveries int
+	replay        bool
+	replayFrom    uint64
+}
+
+// SubOption configures a Subscription.
+type SubOption func(*subOptions)
+
+// WithBuffer sets how many undelivered messages the subscription queues.
+// The default is 64.
+func WithBuffer(n int) SubOption {
+	return func(o *subOptions) {
+		o.buffer = n
+	}
+}
+
+// WithPolicy sets what happens when the buffer is full. The default is
+// DropOldest.
+func WithPolicy(p Policy) SubOption {
+	return func(o *subOptions) {
+		o.policy = p
+	}
+}
+
+// WithAck requires every message to be acknowledged within timeout.
+// Messages that are not, or that are rejected with Nack, are delivered
+// again, at most maxDeliveries times in total (zero means no limit).
+// At most WithMaxInFlight unacknowledged messages are outstanding.
+func WithAck(timeout time.Duration, maxDeliveries int) SubOption {
+	return func(o *subOptions) {
+		o.ackTimeout, o.maxDeliveries = timeout, maxDeliveries
+	}
+}
+
+// WithMaxInFlight caps unacknowledged deliveries under WithAck. The
+// default is 1, which delivers strictly in order.
+func WithMaxInFlight(n int) SubOption {
+	return func(o *subOptions) {
+		o.maxInFlight = n
+	}
+}
+
+// WithReplay delivers the bus's retained messages with IDs from fromID
+// onwards, zero meaning all of them, before any live message. Replayed
+// messages are queued even beyond the buffer size; the history bounds
+// them.
+func WithReplay(fromID uint64) SubOption {
+	return func(o *subOptions) {
+		o.replay, o.replayFrom = true, fromID
+	}
+}
+
+// Message is a delivered message.
+type Message[T any] struct {
+	ID       uint64
+	Topic    string
+	Payload  T
+	Time     time.Time // when it was published
+	Delivery int       // 1 for the first delivery, higher for redeliveries
+
+	sub *Subscription[T]
+}
+
+// Ack acknowledges the message. It does nothing for subscriptions
+// without WithAck.
+func (m Message[T]) Ack() {
+	if m.sub != nil {
+		m.sub.settle(m.ID, true)
+	}
+}
+
+// Nack asks for the message to be delivered again at once. It does
+// nothing for subscriptions without WithAck.
+func (m Message[T]) Nack() {
+	if m.sub != nil {
+		m.sub.settle(m.ID, false)
+	}
+}
+
+// SubscriberMetrics describes one subscription.
+type SubscriberMetrics struct {
+	ID           uint64
+	Pattern      string
+	Policy       Policy
+	Queued       int // waiting for delivery, redeliveries included
+	InFlight     int // delivered, not yet acknowledged
+	Delivered    int // deliveries, redeliveries included
+	Acked        int
+	Redelivered  int
+	Dropped      int // discarded by DropOldest
+	DeadLettered int // given up after maxDeliveries
+	Blocked      int // publishes that had to wait for room
+}
+
+// Subscription receives the messages of the topics matching its pattern.
+type Subscription[T any] struct {
+	bus     *Bus
+	id      uint64
+	pattern string
+	segs    []string
+	o       subOptions
+
+	out  chan Message[T]
+	wake chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	queue   containers.Deque[delivery] // redeliveries at the front
+	queued  int                        // live messages in queue, bounded by the buffer
+	pending map[uint64]*delivery
+	room    chan struct{} // closed when the buffer shrinks
+	err     error
+	closed  bool
+	stats   SubscriberMetrics
+}
+
+type delivery struct {
+	env      envelope
+	attempt  int       // deliveries so far
+	deadline time.Time // zero while being sent
+	replayed bool      // not counted against the buffer
+	retry    bool      // a redelivery, not counted against the buffer
+}
+
+// Subscribe subscribes to every topic on b matching pattern.
+func Subscribe[T any](b *Bus, pattern string, opts ...SubOption) (*Subscription[T], error) {
+	if err := validate(pattern, true); err != nil {
+		return nil, err
+	}
+	o := subOptions{buffer: 64, maxInFlight: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.buffer = max(o.buffer, 1)
+	o.maxInFlight = max(o.maxInFlight, 1)
+
+	s := &Subscription[T]{
+		bus:     b,
+		pattern: pattern,
+		segs:    strings.Split(pattern, "."),
+		o:       o,
+		out:     make(chan Message[T]),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		pending: make(map[uint64]*delivery),
+		room:    make(chan struct{}),
+	}
+	if err := b.register(s, o.replay, o.replayFrom); err != nil {
+		return nil, err
+	}
+	go s.run()
+	return s, nil
+}
+
+// C returns the delivery channel. It is closed when the subscription
+// ends; Err then tells why.
+func (s *Subscription[T]) C() <-chan Message[T] { return s.out }
+
+// Done is closed when the subscription ends.
+func (s *Subscription[T]) Done() <-chan struct{} { return s.done }
+
+// Err returns why the subscription ended: nil after Unsubscribe,
+// ErrSlowConsumer or ErrClosed.
+func (s *Subscription[T]) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Unsubscribe ends the subscription. Queued and unacknowledged messages
+// are discarded.
+func (s *Subscription[T]) Unsubscribe() {
+	s.shut(nil)
+}
+
+// Metrics returns the subscription's counters.
+func (s *Subscription[T]) Metrics() SubscriberMetrics {
+	return s.metrics()
+}
+
+func (s *Subscription[T]) metrics() SubscriberMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.stats
+	m.ID, m.Pattern, m.Policy = s.id, s.pattern, s.o.policy
+	m.Queued, m.InFlight = s.queue.Len(), len(s.pending)
+	return m
+}
+
+func (s *Subscription[T]) matches(topic string) bool {
+	return match(s.segs, topic)
+}
+
+func (s *Subscription[T]) accepts(env envelope) bool {
+	_, ok := env.payload.(T)
+	return ok
+}
+
+func (s *Subscription[T]) setID(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id = id
+}
+
+func (s *Subscription[T]) replay(env envelope) {
+	if !s.accepts(env) {
+		return
+	}
+	s.queue.PushBack(delivery{env: env, replayed: true})
+}
+
+// offer queues env according to the policy.
+func (s *Subscription[T]) offer(ctx context.Context, env envelope) error {
+	if !s.accepts(env) {
+		return nil
+	}
+	blocked := false
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return nil
+		}
+		if s.queued < s.o.buffer {
+			break
+		}
+		switch s.o.policy {
+		case DropOldest:
+			s.dropOldest()
+			s.mu.Unlock()
+			continue
+		case Disconnect:
+			s.mu.Unlock()
+			s.shut(ErrSlowConsumer)
+			return nil
+		}
+		if !blocked {
+			blocked = true
+			s.stats.Blocked++
+		}
+		room := s.room
+		s.mu.Unlock()
+		select {
+		case <-room:
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("eventbus: subscriber %d (%s) full: %w", s.id, s.pattern, ctx.Err())
+		}
+	}
+	s.queue.PushBack(delivery{env: env})
+	s.queued++
+	s.mu.Unlock()
+	s.signal()
+	return nil
+}
+
+// dropOldest removes the oldest live message. It must be called with
+// s.mu held and a full buffer.
+func (s *Subscription[T]) dropOldest() {
+	for i := range s.queue.Len() {
+		if d := s.queue.At(i); !d.replayed && !d.retry {
+			s.removeAt(i)
+			s.queued--
+			s.stats.Dropped++
+			return
+		}
+	}
+}
+
+// removeAt removes the i-th queued delivery, keeping the order of the
+// others.
+func (s *Subscription[T]) removeAt(i int) {
+	var head []delivery
+	for range i {
+		d, _ := s.queue.PopFront()
+		head = append(head, d)
+	}
+	s.queue.PopFront()
+	for j := len(head) - 1; j >= 0; j-- {
+		s.queue.PushFront(head[j])
+	}
+}
+
+func (s *Subscription[T]) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// shut ends the subscription once.
+func (s *Subscription[T]) shut(err error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.err = err
+	s.queue.Clear()
+	s.queued = 0
+	clear(s.pending)
+	id := s.id
+	s.mu.Unlock()
+	close(s.done)
+	if err != ErrClosed {
+		s.bus.unregister(id)
+	}
+}
+
+// settle acknowledges or rejects a pending delivery.
+func (s *Subscription[T]) settle(id uint64, ack bool) {
+	s.mu.Lock()
+	d, ok := s.pending[id]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.pending, id)
+	if ack {
+		s.stats.Acked++
+	} else {
+		s.requeue(d)
+	}
+	s.mu.Unlock()
+	s.signal()
+}
+
+// requeue puts d back for redelivery unless it has used up its
+// deliveries. It must be called with s.mu held.
+func (s *Subscription[T]) requeue(d *delivery) {
+	if s.o.maxDeliveries > 0 && d.attempt >= s.o.maxDeliveries {
+		s.stats.DeadLettered++
+		return
+	}
+	s.stats.Redelivered++
+	s.queue.PushFront(delivery{env: d.env, attempt: d.attempt, retry: true})
+}
+
+// run delivers queued messages to C.
+func (s *Subscription[T]) run() {
+	defer close(s.out)
+	clk := s.bus.clock
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		now := clk.Now()
+		wait := s.expire(now)
+		d, ok := s.next()
+		s.mu.Unlock()
+
+		if !ok {
+			var timer clock.Timer
+			var timeout <-chan time.Time
+			if wait > 0 {
+				timer = clk.NewTimer(wait)
+				timeout = timer.C()
+			}
+			select {
+			case <-s.wake:
+			case <-timeout:
+			case <-s.done:
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			continue
+		}
+
+		msg := Message[T]{
+			ID:       d.env.id,
+			Topic:    d.env.topic,
+			Payload:  d.env.payload.(T),
+			Time:     d.env.time,
+			Delivery: d.attempt,
+		}
+		if s.o.ackTimeout > 0 {
+			msg.sub = s
+		}
+		select {
+		case s.out <- msg:
+		case <-s.done:
+			return
+		}
+		s.mu.Lock()
+		s.stats.Delivered++
+		if p, ok := s.pending[d.env.id]; ok {
+			p.deadline = clk.Now().Add(s.o.ackTimeout)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// next pops the next delivery, registering it as pending under WithAck.
+// It must be called with s.mu held.
+func (s *Subscription[T]) next() (delivery, bool) {
+	if s.o.ackTimeout > 0 && len(s.pending) >= s.o.maxInFlight {
+		return delivery{}, false
+	}
+	d, ok := s.queue.PopFront()
+	if !ok {
+		return delivery{}, false
+	}
+	if !d.replayed && !d.retry {
+		s.queued--
+		close(s.room)
+		s.room = make(chan struct{})
+	}
+	d.attempt++
+	if s.o.ackTimeout > 0 {
+		p := d
+		s.pending[d.env.id] = &p
+	}
+	return d, true
+}
+
+// expire requeues pending deliveries whose deadline has passed and
+// returns how long until the next one does, or zero if none is pending.
+// It must be called with s.mu held.
+func (s *Subscription[T]) expire(now time.Time) time.Duration {
+	var wait time.Duration
+	for id, d := range s.pending {
+		if d.deadline.IsZero() {
+			continue
+		}
+		if left := d.deadline.Sub(now); left > 0 {
+			if wait == 0 || left < wait {
+				wait = left
+			}
+			continue
+		}
+		delete(s.pending, id)
+		s.requeue(d)
+	}
+	return wait
+}
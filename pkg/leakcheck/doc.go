@@ -0,0 +1,27 @@
+// Package leakcheck fails tests that leave goroutines running, enforcing
+// the "know how every goroutine ends" rule from the goroutines and race
+// conditions chapters.
+//
+// Check a single test by snapshotting goroutines when the defer statement
+// runs (deferred arguments are evaluated immediately) and comparing at the
+// end:
+//
+//	func TestPipeline(t *testing.T) {
+//	    defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+//	    ...
+//	}
+//
+// Without IgnoreCurrent, Verify compares against the goroutines that
+// existed when the test binary started, which suits sequential tests.
+// Check a whole package instead from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//	    leakcheck.VerifyTestMain(m)
+//	}
+//
+// Goroutines are read from runtime.Stack. Leftovers are rechecked with
+// backoff for a grace period, because goroutines that are already
+// finishing need a moment to exit. Goroutines owned by the runtime and
+// the testing package are always ignored, and leaks are reported with
+// their full stacks.
+package leakcheck
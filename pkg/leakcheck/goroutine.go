@@ -0,0 +1,127 @@
+package leakcheck
+
+import (
+	"bufio"
+	"bytes"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Goroutine is one goroutine parsed from a runtime.Stack dump.
+type Goroutine struct {
+	ID    int
+	State string // e.g. "chan receive", without the wait duration
+	// Functions lists the stack's frames from innermost outwards.
+	Functions []string
+	CreatedBy string // function that started the goroutine, if known
+	Stack     string // the goroutine's full dump
+}
+
+// Top returns the innermost function.
+func (g Goroutine) Top() string {
+	if len(g.Functions) == 0 {
+		return ""
+	}
+	return g.Functions[0]
+}
+
+// Has reports whether fn appears anywhere in the stack, including as the
+// creator.
+func (g Goroutine) Has(fn string) bool {
+	return g.CreatedBy == fn || slices.Contains(g.Functions, fn)
+}
+
+func (g Goroutine) String() string {
+	return g.Stack
+}
+
+// All returns every goroutine except the caller's.
+func All() []Goroutine {
+	all := parse(dump())
+	self := currentID()
+	kept := all[:0]
+	for _, g := range all {
+		if g.ID != self {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
+
+// dump returns runtime.Stack for all goroutines, growing the buffer until
+// the dump fits.
+func dump() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func currentID() int {
+	var buf [64]byte
+	g := parse(buf[:runtime.Stack(buf[:], false)])
+	if len(g) == 0 {
+		return 0
+	}
+	return g[0].ID
+}
+
+// parse splits a dump into goroutines. Each starts with a header such as
+// "goroutine 7 [chan receive, 2 minutes]:" followed by pairs of function
+// and file lines, and the dumps are separated by blank lines.
+func parse(data []byte) []Goroutine {
+	var out []Goroutine
+	for _, block := range bytes.Split(data, []byte("\n\n")) {
+		if g, ok := parseOne(string(block)); ok {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func parseOne(block string) (Goroutine, bool) {
+	s := bufio.NewScanner(strings.NewReader(block))
+	if !s.Scan() {
+		return Goroutine{}, false
+	}
+	header, ok := strings.CutPrefix(s.Text(), "goroutine ")
+	if !ok {
+		return Goroutine{}, false
+	}
+	id, rest, ok := strings.Cut(header, " [")
+	n, err := strconv.Atoi(id)
+	if !ok || err != nil {
+		return Goroutine{}, false
+	}
+	state, _, _ := strings.Cut(strings.TrimSuffix(rest, "]:"), ",")
+
+	g := Goroutine{ID: n, State: state, Stack: strings.TrimSpace(block)}
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || line[0] == '\t' {
+			continue
+		}
+		if by, ok := strings.CutPrefix(line, "created by "); ok {
+			by, _, _ = strings.Cut(by, " in goroutine ")
+			g.CreatedBy = by
+			continue
+		}
+		g.Functions = append(g.Functions, funcName(line))
+	}
+	return g, true
+}
+
+// funcName strips the argument list from a frame line such as
+// "testing.(*T).Run(0xc000102340, {0x5a1b2c, 0x4}, 0x5b3c40)".
+func funcName(line string) string {
+	if i := strings.LastIndexByte(line, '('); i > 0 && strings.HasSuffix(line, ")") {
+		return line[:i]
+	}
+	return line
+}
@@ -0,0 +1,175 @@
+package leakcheck
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TB is the subset of testing.TB used by Verify.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// M is the subset of *testing.M used by VerifyTestMain.
+type M interface {
+	Run() int
+}
+
+// baseline holds the goroutines alive when the package was initialised,
+// which in a test binary is before any test runs.
+var baseline = ids(All())
+
+// ignoredFunctions are runtime and testing goroutines that outlive tests
+// legitimately.
+var ignoredFunctions = []string{
+	"testing.RunTests",
+	"testing.(*T).Run",
+	"testing.(*T).Parallel",
+	"testing.(*M).Run",
+	"testing.runFuzzing",
+	"testing.runFuzzTests",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+	"runtime.ReadTrace",
+	"runtime/trace.Start.func1",
+}
+
+type options struct {
+	grace   time.Duration
+	ignore  map[int]bool
+	anyFunc []string
+	topFunc []string
+}
+
+// Option configures Verify, VerifyTestMain and Find.
+type Option func(*options)
+
+// IgnoreCurrent ignores every goroutine running now. As a deferred
+// argument it is evaluated when the defer statement runs, which makes it a
+// snapshot of the goroutines that existed before the test body.
+func IgnoreCurrent() Option {
+	current := ids(All())
+	return func(o *options) {
+		for id := range current {
+			o.ignore[id] = true
+		}
+	}
+}
+
+// IgnoreFunction ignores goroutines with fn anywhere in their stack. fn
+// is a fully qualified name such as "net/http.(*persistConn).readLoop".
+func IgnoreFunction(fn string) Option {
+	return func(o *options) {
+		o.anyFunc = append(o.anyFunc, fn)
+	}
+}
+
+// IgnoreTopFunction ignores goroutines whose innermost frame is fn.
+func IgnoreTopFunction(fn string) Option {
+	return func(o *options) {
+		o.topFunc = append(o.topFunc, fn)
+	}
+}
+
+// WithGrace sets how long leftovers are given to exit. The default is
+// one second.
+func WithGrace(d time.Duration) Option {
+	return func(o *options) {
+		o.grace = d
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{grace: time.Second, ignore: make(map[int]bool)}
+	for id := range baseline {
+		o.ignore[id] = true
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o *options) ignored(g Goroutine) bool {
+	if o.ignore[g.ID] || slices.Contains(o.topFunc, g.Top()) {
+		return true
+	}
+	for _, fn := range ignoredFunctions {
+		if g.Has(fn) {
+			return true
+		}
+	}
+	for _, fn := range o.anyFunc {
+		if g.Has(fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the goroutines still running after the grace period that
+// are not ignored. It returns nil as soon as there are none.
+func Find(opts ...Option) []Goroutine {
+	o := buildOptions(opts)
+	deadline := time.Now().Add(o.grace)
+	delay := time.Microsecond
+	for {
+		var leaked []Goroutine
+		for _, g := range All() {
+			if !o.ignored(g) {
+				leaked = append(leaked, g)
+			}
+		}
+		if len(leaked) == 0 || !time.Now().Before(deadline) {
+			return leaked
+		}
+		time.Sleep(min(delay, time.Until(deadline)))
+		delay = min(2*delay, 100*time.Millisecond)
+	}
+}
+
+// Verify reports a test error listing the stacks of leaked goroutines.
+func Verify(t TB, opts ...Option) {
+	t.Helper()
+	if leaked := Find(opts...); len(leaked) > 0 {
+		t.Errorf("%s", Report(leaked))
+	}
+}
+
+// VerifyTestMain runs the tests and, if they pass, checks for goroutines
+// left over by the package as a whole. It exits the process with the
+// tests' status, or 1 if goroutines leaked.
+func VerifyTestMain(m M, opts ...Option) {
+	code := m.Run()
+	if code == 0 {
+		if leaked := Find(opts...); len(leaked) > 0 {
+			fmt.Fprintln(os.Stderr, Report(leaked))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// Report formats leaked goroutines with their stacks.
+func Report(leaked []Goroutine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "leakcheck: %d goroutine(s) leaked:", len(leaked))
+	for _, g := range leaked {
+		b.WriteString("\n\n")
+		b.WriteString(g.Stack)
+	}
+	return b.String()
+}
+
+func ids(gs []Goroutine) map[int]bool {
+	m := make(map[int]bool, len(gs))
+	for _, g := range gs {
+		m[g.ID] = true
+	}
+	return m
+}
@@ -0,0 +1,135 @@
+package leakcheck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	VerifyTestMain(m)
+}
+
+const sampleDump = `goroutine 1 [chan receive, 2 minutes]:
+testing.(*T).Run(0xc000102340, {0x5a1b2c, 0x4}, 0x5b3c40)
+	/usr/local/go/src/testing/testing.go:1751 +0x3ab
+main.main()
+	_testmain.go:45 +0x1c5
+
+goroutine 18 [select]:
+example.com/app.(*worker).loop(0xc0000a2000)
+	/src/app/worker.go:40 +0x85
+example.com/app.run.func1()
+	/src/app/run.go:12 +0x25
+created by example.com/app.run in goroutine 1
+	/src/app/run.go:11 +0x6a
+
+garbage that is not a goroutine
+`
+
+func TestParse(t *testing.T) {
+	gs := parse([]byte(sampleDump))
+	if len(gs) != 2 {
+		t.Fatalf("parsed %d goroutines, want 2", len(gs))
+	}
+	g := gs[0]
+	if g.ID != 1 || g.State != "chan receive" || g.Top() != "testing.(*T).Run" || g.CreatedBy != "" {
+		t.Errorf("goroutine 1 = %+v", g)
+	}
+	g = gs[1]
+	if g.ID != 18 || g.State != "select" || g.CreatedBy != "example.com/app.run" {
+		t.Errorf("goroutine 18 = %+v", g)
+	}
+	if want := []string{"example.com/app.(*worker).loop", "example.com/app.run.func1"}; fmt.Sprint(g.Functions) != fmt.Sprint(want) {
+		t.Errorf("Functions = %q, want %q", g.Functions, want)
+	}
+	if !g.Has("example.com/app.run") || g.Has("main.main") {
+		t.Error("Has does not match the stack and creator")
+	}
+	if !strings.HasPrefix(g.Stack, "goroutine 18 [select]:") || strings.HasSuffix(g.Stack, "\n") {
+		t.Errorf("Stack = %q", g.Stack)
+	}
+}
+
+func TestAllExcludesCaller(t *testing.T) {
+	self := currentID()
+	for _, g := range All() {
+		if g.ID == self {
+			t.Fatalf("All includes the calling goroutine %d", self)
+		}
+	}
+}
+
+// blockForever parks until stop is closed; tests look for it by name.
+func blockForever(stop chan struct{}) {
+	<-stop
+}
+
+const blocker = "github.com/thanhnamdk2710/go-handbook/pkg/leakcheck.blockForever"
+
+func TestFindReportsLeak(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go blockForever(stop)
+
+	leaked := Find(WithGrace(20 * time.Millisecond))
+	if len(leaked) != 1 || leaked[0].Top() != blocker || leaked[0].State != "chan receive" {
+		t.Fatalf("Find = %v, want the blocked goroutine", leaked)
+	}
+	if r := Report(leaked); !strings.Contains(r, "1 goroutine(s) leaked") || !strings.Contains(r, blocker) {
+		t.Errorf("Report = %s", r)
+	}
+
+	var rec recorder
+	Verify(&rec, WithGrace(20*time.Millisecond))
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], blocker) {
+		t.Errorf("Verify reported %q", rec.errors)
+	}
+}
+
+func TestFindWaitsForExitingGoroutines(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(done)
+	}()
+	if leaked := Find(WithGrace(time.Second)); len(leaked) != 0 {
+		t.Fatalf("Find = %v, want goroutine to be given time to exit", leaked)
+	}
+	<-done
+}
+
+func TestIgnoreOptions(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go blockForever(stop)
+
+	grace := WithGrace(10 * time.Millisecond)
+	if leaked := Find(grace, IgnoreCurrent()); len(leaked) != 0 {
+		t.Errorf("IgnoreCurrent: Find = %v", leaked)
+	}
+	if leaked := Find(grace, IgnoreTopFunction(blocker)); len(leaked) != 0 {
+		t.Errorf("IgnoreTopFunction: Find = %v", leaked)
+	}
+	if leaked := Find(grace, IgnoreFunction(blocker)); len(leaked) != 0 {
+		t.Errorf("IgnoreFunction: Find = %v", leaked)
+	}
+
+	// A snapshot taken before the goroutine started does not cover it.
+	before := IgnoreCurrent()
+	go blockForever(stop)
+	if leaked := Find(grace, before); len(leaked) != 1 {
+		t.Errorf("Find after snapshot = %v, want the new goroutine", leaked)
+	}
+}
+
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,15 @@
+// Package notify sends email notifications through interchangeable
+// transports, building on the EmailSender test double from the
+// interfaces chapter.
+//
+// Transports:
+//
+//   - SMTPSender delivers over SMTP using net/smtp
+//   - SpoolSender writes .eml files to a directory for later pickup
+//   - Recorder keeps messages in memory for assertions in tests
+//
+// Retry wraps any EmailSender with exponential backoff, and Template
+// renders subjects and bodies with text/template and html/template.
+// The smtptest subpackage runs an in-process SMTP server so SMTPSender
+// can be exercised without outside services.
+package notify
@@ -0,0 +1,220 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EmailSender delivers messages.
+type EmailSender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Message is an email with a plain text body, an HTML body, or both.
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
+
+// Errors returned by Message.Validate.
+var (
+	ErrNoRecipients  = errors.New("notify: message has no recipients")
+	ErrNoSender      = errors.New("notify: message has no From address")
+	ErrInvalidHeader = errors.New("notify: invalid header")
+)
+
+// reservedHeaders are written by Bytes from the message's fields and its
+// MIME structure; Headers may not override them.
+var reservedHeaders = map[string]bool{
+	"From":                      true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Subject":                   true,
+	"Date":                      true,
+	"Message-Id":                true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+}
+
+// Recipients returns every envelope recipient, including Bcc.
+func (m *Message) Recipients() []string {
+	all := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	all = append(all, m.To...)
+	all = append(all, m.Cc...)
+	return append(all, m.Bcc...)
+}
+
+// Validate checks that the addresses parse, that there is someone to
+// deliver to, and that Headers neither spans lines nor sets a header
+// that Bytes writes itself.
+func (m *Message) Validate() error {
+	if m.From == "" {
+		return ErrNoSender
+	}
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		return fmt.Errorf("notify: invalid From %q: %w", m.From, err)
+	}
+
+	recipients := m.Recipients()
+	if len(recipients) == 0 {
+		return ErrNoRecipients
+	}
+	for _, addr := range recipients {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("notify: invalid recipient %q: %w", addr, err)
+		}
+	}
+	for k, v := range m.Headers {
+		if err := validHeader(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validHeader(name, value string) error {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool { return r <= ' ' || r > '~' || r == ':' }) {
+		return fmt.Errorf("%w name %q", ErrInvalidHeader, name)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%w %s: value contains a line break", ErrInvalidHeader, name)
+	}
+	if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+		return fmt.Errorf("%w %s: set by the message itself", ErrInvalidHeader, name)
+	}
+	return nil
+}
+
+// Bytes encodes the message in RFC 5322 format. Bcc recipients are left
+// out of the headers. When both bodies are set the message is
+// multipart/alternative.
+func (m *Message) Bytes() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	h := textproto.MIMEHeader{}
+	h.Set("From", m.From)
+	h.Set("To", strings.Join(m.To, ", "))
+	if len(m.Cc) > 0 {
+		h.Set("Cc", strings.Join(m.Cc, ", "))
+	}
+	h.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	h.Set("Date", time.Now().Format(time.RFC1123Z))
+	h.Set("Message-ID", messageID(m.From))
+	h.Set("MIME-Version", "1.0")
+	for k, v := range m.Headers {
+		h.Set(k, v)
+	}
+
+	switch {
+	case m.Text != "" && m.HTML != "":
+		mw := multipart.NewWriter(&buf)
+		h.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+		writeHeader(&buf, h)
+		if err := writePart(mw, "text/plain", m.Text); err != nil {
+			return nil, err
+		}
+		if err := writePart(mw, "text/html", m.HTML); err != nil {
+			return nil, err
+		}
+		if err := mw.Close(); err != nil {
+			return nil, err
+		}
+	case m.HTML != "":
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&buf, h)
+		if err := writeQuoted(&buf, m.HTML); err != nil {
+			return nil, err
+		}
+	default:
+		h.Set("Content-Type", "text/plain; charset=utf-8")
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&buf, h)
+		if err := writeQuoted(&buf, m.Text); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, h textproto.MIMEHeader) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+func writePart(mw *multipart.Writer, contentType, body string) error {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", contentType+"; charset=utf-8")
+	h.Set("Content-Transfer-Encoding", "quoted-printable")
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func writeQuoted(buf *bytes.Buffer, body string) error {
+	qw := quotedprintable.NewWriter(buf)
+	if _, err := qw.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(addr.Address, "@"); ok {
+			domain = d
+		}
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// envelopeAddress strips a display name so the address can be used in
+// SMTP MAIL and RCPT commands.
+func envelopeAddress(addr string) (string, error) {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return "", err
+	}
+	return a.Address, nil
+}
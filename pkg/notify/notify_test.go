@@ -0,0 +1,266 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/notify"
+	"github.com/thanhnamdk2710/go-handbook/pkg/notify/smtptest"
+	"github.com/thanhnamdk2710/go-handbook/pkg/retry"
+)
+
+func welcome() *notify.Message {
+	return &notify.Message{
+		From:    "Shop <shop@example.com>",
+		To:      []string{"alice@example.com"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Welcome",
+		Text:    "Hello Alice",
+	}
+}
+
+func TestSMTPSenderDelivers(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+
+	if err := notify.NewSMTPSender(srv.Addr, nil).Send(context.Background(), welcome()); err != nil {
+		t.Fatal(err)
+	}
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("server got %d messages, want 1", len(msgs))
+	}
+	got := msgs[0]
+	if got.From != "shop@example.com" || strings.Join(got.To, ",") != "alice@example.com,audit@example.com" {
+		t.Fatalf("envelope = %s → %v", got.From, got.To)
+	}
+	parsed, err := got.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header.Get("Subject") != "Welcome" || parsed.Header.Get("Bcc") != "" {
+		t.Fatalf("headers = %v", parsed.Header)
+	}
+	body, _ := io.ReadAll(parsed.Body)
+	if !strings.Contains(string(body), "Hello Alice") {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestSMTPSenderAuth(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+	srv.Credentials = map[string]string{"shop": "s3cret"}
+	host := strings.Split(srv.Addr, ":")[0]
+
+	tests := []struct {
+		name string
+		auth smtp.Auth
+		code int // 0 for success
+	}{
+		{"valid", smtp.PlainAuth("", "shop", "s3cret", host), 0},
+		{"wrong password", smtp.PlainAuth("", "shop", "guess", host), 535},
+		{"missing", nil, 530},
+	}
+	for _, tt := range tests {
+		err := notify.NewSMTPSender(srv.Addr, tt.auth).Send(context.Background(), welcome())
+		var proto *textproto.Error
+		switch {
+		case tt.code == 0 && err != nil:
+			t.Errorf("%s: Send = %v", tt.name, err)
+		case tt.code != 0 && (!errors.As(err, &proto) || proto.Code != tt.code):
+			t.Errorf("%s: Send = %v, want SMTP %d", tt.name, err, tt.code)
+		}
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Fatalf("server accepted %d messages, want 1", n)
+	}
+}
+
+func TestRetryTemporaryAndPermanentRejects(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+
+	var attempts atomic.Int32
+	srv.Reject = func(rcpt string) (int, string) {
+		n := attempts.Add(1)
+		switch {
+		case rcpt == "gone@example.com":
+			return 550, "no such user"
+		case n < 3:
+			return 451, "try again later"
+		}
+		return 0, ""
+	}
+	sender := notify.NewRetry(notify.NewSMTPSender(srv.Addr, nil), 5, time.Millisecond)
+
+	msg := welcome()
+	msg.Bcc = nil
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send after 4xx rejects = %v", err)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Fatalf("RCPT attempts = %d, want 3", n)
+	}
+
+	attempts.Store(10)
+	msg.To = []string{"gone@example.com"}
+	err := sender.Send(context.Background(), msg)
+	if err == nil || notify.IsTemporary(err) {
+		t.Fatalf("Send to rejected mailbox = %v, want permanent error", err)
+	}
+	if n := attempts.Load(); n != 11 {
+		t.Fatalf("5xx reject was retried: %d RCPT attempts", n-10)
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Fatalf("server accepted %d messages, want 1", n)
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	failing := &notify.Recorder{Err: &textproto.Error{Code: 421, Msg: "busy"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := notify.NewRetry(failing, 100, 5*time.Millisecond).Send(ctx, welcome())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send = %v, want deadline exceeded", err)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	failing := &notify.Recorder{Err: &textproto.Error{Code: 421, Msg: "busy"}}
+	err := notify.NewRetry(failing, 2, time.Millisecond).Send(context.Background(), welcome())
+	var re *retry.Error
+	var proto *textproto.Error
+	if !errors.As(err, &re) || re.Attempts != 2 || !errors.Is(err, retry.ErrMaxAttempts) || !errors.As(err, &proto) {
+		t.Fatalf("Send = %v, want *retry.Error wrapping the 421 after 2 attempts", err)
+	}
+}
+
+func TestSpoolSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	spool, err := notify.NewSpoolSender(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := spool.Send(context.Background(), welcome()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := spool.Send(context.Background(), &notify.Message{To: []string{"a@example.com"}}); !errors.Is(err, notify.ErrNoSender) {
+		t.Fatalf("Send without From = %v, want ErrNoSender", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("spool holds %d files, want 3", len(entries))
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".eml") {
+			t.Errorf("unexpected spool file %s", e.Name())
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, e.Name()))
+		if !strings.Contains(string(data), "Subject: Welcome") {
+			t.Errorf("%s lacks the subject:\n%s", e.Name(), data)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := notify.NewTemplate("welcome",
+		"Welcome, {{.Name}}",
+		"Hi {{.Name}}, your code is {{.Code}}.",
+		"<p>Hi {{.Name}}</p>",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := tmpl.Render("shop@example.com", []string{"bob@example.com"}, map[string]string{
+		"Name": "<Bob>",
+		"Code": "42",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "Welcome, <Bob>" || msg.Text != "Hi <Bob>, your code is 42." {
+		t.Fatalf("subject, text = %q, %q", msg.Subject, msg.Text)
+	}
+	if msg.HTML != "<p>Hi &lt;Bob&gt;</p>" {
+		t.Fatalf("html = %q, want escaped name", msg.HTML)
+	}
+
+	if _, err := notify.NewTemplate("bad", "{{.Name", "", ""); err == nil {
+		t.Fatal("NewTemplate accepted a malformed subject")
+	}
+}
+
+func TestRecorderCopiesMessages(t *testing.T) {
+	var rec notify.Recorder
+	msg := welcome()
+	msg.Headers = map[string]string{"X-Campaign": "spring"}
+	if err := rec.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	msg.To[0] = "mallory@example.com"
+	msg.Headers["X-Campaign"] = "changed"
+
+	got := rec.Last()
+	if got.To[0] != "alice@example.com" || got.Headers["X-Campaign"] != "spring" {
+		t.Fatalf("recorded message changed with the original: %+v", got)
+	}
+	got.To[0] = "mallory@example.com"
+	got.Headers["X-Campaign"] = "changed"
+	for _, m := range append(rec.Messages(), rec.Last()) {
+		if m.To[0] != "alice@example.com" || m.Headers["X-Campaign"] != "spring" {
+			t.Fatalf("changing a returned message changed the record: %+v", m)
+		}
+	}
+	if len(rec.SentTo("audit@example.com")) != 1 {
+		t.Fatal("SentTo does not match Bcc recipients")
+	}
+}
+
+func TestMessageHeaders(t *testing.T) {
+	msg := welcome()
+	msg.Headers = map[string]string{"X-Campaign": "spring", "List-Unsubscribe": "<mailto:stop@example.com>"}
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "\r\nX-Campaign: spring\r\n") {
+		t.Fatalf("custom header missing:\n%s", raw)
+	}
+
+	for _, h := range []map[string]string{
+		{"X-Campaign": "spring\r\nBcc: everyone@example.com"},
+		{"X-Campaign": "line\nbreak"},
+		{"X-Bad\r\nBcc": "everyone@example.com"},
+		{"X Campaign": "spaced"},
+		{"": "empty"},
+		{"from": "mallory@example.com"},
+		{"Content-Type": "text/html"},
+		{"MIME-Version": "2.0"},
+	} {
+		msg.Headers = h
+		if _, err := msg.Bytes(); !errors.Is(err, notify.ErrInvalidHeader) {
+			t.Errorf("Bytes with headers %q = %v, want ErrInvalidHeader", h, err)
+		}
+		if err := new(notify.Recorder).Send(context.Background(), msg); !errors.Is(err, notify.ErrInvalidHeader) {
+			t.Errorf("Recorder.Send with headers %q = %v, want ErrInvalidHeader", h, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Recorder is an EmailSender that keeps messages in memory. It replaces
+// TestEmailSender from the interfaces chapter and is safe for concurrent
+// use.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []*Message
+	// Err, when set, is returned from Send instead of recording.
+	Err error
+}
+
+// Send records a copy of msg.
+func (r *Recorder) Send(ctx context.Context, msg *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Err != nil {
+		return r.Err
+	}
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+	r.messages = append(r.messages, clone(msg))
+	return nil
+}
+
+// Messages returns copies of every recorded message in send order.
+func (r *Recorder) Messages() []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*Message, len(r.messages))
+	for i, m := range r.messages {
+		out[i] = clone(m)
+	}
+	return out
+}
+
+// Len returns the number of recorded messages.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.messages)
+}
+
+// Last returns a copy of the most recent message, or nil.
+func (r *Recorder) Last() *Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.messages) == 0 {
+		return nil
+	}
+	return clone(r.messages[len(r.messages)-1])
+}
+
+// SentTo returns copies of the messages with addr among their
+// recipients.
+func (r *Recorder) SentTo(addr string) []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []*Message
+	for _, m := range r.messages {
+		if slices.Contains(m.Recipients(), addr) {
+			out = append(out, clone(m))
+		}
+	}
+	return out
+}
+
+// Reset forgets all recorded messages.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = nil
+}
+
+// clone copies msg deeply enough that neither copy sees changes to the
+// other.
+func clone(msg *Message) *Message {
+	c := *msg
+	c.To = slices.Clone(msg.To)
+	c.Cc = slices.Clone(msg.Cc)
+	c.Bcc = slices.Clone(msg.Bcc)
+	c.Headers = maps.Clone(msg.Headers)
+	return &c
+}
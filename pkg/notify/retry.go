@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/textproto"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/retry"
+)
+
+// Retry wraps an EmailSender and retries transient failures with
+// exponential backoff.
+type Retry struct {
+	Sender   EmailSender
+	Attempts int
+	// Backoff is the delay after the first failure; it doubles after
+	// each further failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable classifies errors. It defaults to IsTemporary.
+	Retryable func(error) bool
+}
+
+// NewRetry retries sender up to attempts times starting at backoff.
+func NewRetry(sender EmailSender, attempts int, backoff time.Duration) *Retry {
+	return &Retry{Sender: sender, Attempts: attempts, Backoff: backoff, MaxBackoff: time.Minute}
+}
+
+// Send delivers msg with retry.Do, retrying while errors are retryable
+// and the context is live. Once the attempts run out the last error is
+// returned inside a *retry.Error.
+func (r *Retry) Send(ctx context.Context, msg *Message) error {
+	retryable := r.Retryable
+	if retryable == nil {
+		retryable = IsTemporary
+	}
+	maxBackoff := r.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = math.MaxInt64
+	}
+
+	return retry.Do(ctx, func(ctx context.Context) error {
+		return r.Sender.Send(ctx, msg)
+	},
+		retry.WithMaxAttempts(max(r.Attempts, 1)),
+		retry.WithBackoff(retry.Exponential(r.Backoff, maxBackoff)),
+		retry.WithClassifier(func(err error) retry.Class {
+			if retryable(err) {
+				return retry.Retryable
+			}
+			return retry.Permanent
+		}),
+	)
+}
+
+// IsTemporary reports whether err is worth retrying: SMTP 4xx replies
+// and network errors are; SMTP 5xx replies, invalid messages and context
+// errors are not.
+func IsTemporary(err error) bool {
+	var proto *textproto.Error
+	if errors.As(err, &proto) {
+		return proto.Code >= 400 && proto.Code < 500
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
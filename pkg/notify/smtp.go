@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPSender delivers messages to an SMTP server.
+type SMTPSender struct {
+	// Addr is the server's host:port.
+	Addr string
+	// Auth authenticates the session when non-nil.
+	Auth smtp.Auth
+	// TLSConfig enables STARTTLS when the server offers it.
+	TLSConfig *tls.Config
+	// LocalName is sent in EHLO; it defaults to "localhost".
+	LocalName string
+	// Timeout bounds a whole delivery when the context has no deadline.
+	Timeout time.Duration
+}
+
+// NewSMTPSender creates a sender for the server at addr.
+func NewSMTPSender(addr string, auth smtp.Auth) *SMTPSender {
+	return &SMTPSender{Addr: addr, Auth: auth, Timeout: 30 * time.Second}
+}
+
+// Send delivers msg in a single SMTP session.
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok && s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("notify: dial %s: %w", s.Addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Unblock the session if the context is cancelled mid-conversation.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("notify: %w", err)
+	}
+	defer c.Close()
+
+	if err := s.deliver(c, msg, data); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("notify: %w", ctx.Err())
+		}
+		return fmt.Errorf("notify: %w", err)
+	}
+	return nil
+}
+
+func (s *SMTPSender) deliver(c *smtp.Client, msg *Message, data []byte) error {
+	localName := s.LocalName
+	if localName == "" {
+		localName = "localhost"
+	}
+	if err := c.Hello(localName); err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok && s.TLSConfig != nil {
+		if err := c.StartTLS(s.TLSConfig); err != nil {
+			return err
+		}
+	}
+	if s.Auth != nil {
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+
+	from, err := envelopeAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range msg.Recipients() {
+		to, err := envelopeAddress(rcpt)
+		if err != nil {
+			return err
+		}
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,239 @@
+// Package smtptest provides an in-process SMTP server for tests, in the
+// spirit of net/http/httptest.
+//
+//	srv := smtptest.NewServer()
+//	defer srv.Close()
+//
+//	sender := notify.NewSMTPSender(srv.Addr, nil)
+//	sender.Send(ctx, msg)
+//
+//	got := srv.Messages()
+//
+// The server implements enough of RFC 5321 for net/smtp: EHLO/HELO,
+// AUTH PLAIN, MAIL, RCPT, DATA, RSET, NOOP and QUIT. It does not
+// support STARTTLS.
+package smtptest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message is one message accepted by the server.
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Parse parses the raw message data.
+func (m Message) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(strings.NewReader(string(m.Data)))
+}
+
+// Server is a local SMTP server that stores accepted messages.
+type Server struct {
+	// Addr is the host:port the server listens on.
+	Addr string
+
+	// Reject, when set, is consulted for each RCPT address; a non-zero
+	// code is returned to the client instead of accepting it, which lets
+	// tests simulate 4xx and 5xx failures.
+	Reject func(rcpt string) (code int, msg string)
+
+	// Credentials, when non-empty, makes AUTH PLAIN required and checks
+	// the username and password against it.
+	Credentials map[string]string
+
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server on a random loopback port.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: failed to listen: %v", err))
+	}
+	s := &Server{
+		Addr:     l.Addr().String(),
+		listener: l,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Messages returns the accepted messages in arrival order.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Reset forgets accepted messages.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+// Close stops the server and waits for open sessions to end.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.session(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+type session struct {
+	from   string
+	to     []string
+	authed bool
+}
+
+func (s *Server) session(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) bool {
+		return tp.PrintfLine("%d %s", code, msg) == nil
+	}
+
+	reply(220, "smtptest ready")
+	var st session
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			tp.PrintfLine("250-smtptest greets %s", arg)
+			tp.PrintfLine("250-8BITMIME")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "HELO":
+			reply(250, "smtptest")
+		case "AUTH":
+			s.auth(tp, arg, &st)
+		case "MAIL":
+			if len(s.Credentials) > 0 && !st.authed {
+				reply(530, "authentication required")
+				continue
+			}
+			st = session{authed: st.authed, from: address(arg, "FROM:")}
+			reply(250, "OK")
+		case "RCPT":
+			if st.from == "" {
+				reply(503, "need MAIL first")
+				continue
+			}
+			rcpt := address(arg, "TO:")
+			if s.Reject != nil {
+				if code, msg := s.Reject(rcpt); code != 0 {
+					reply(code, msg)
+					continue
+				}
+			}
+			st.to = append(st.to, rcpt)
+			reply(250, "OK")
+		case "DATA":
+			if len(st.to) == 0 {
+				reply(503, "need RCPT first")
+				continue
+			}
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, Message{From: st.from, To: st.to, Data: data})
+			s.mu.Unlock()
+			st = session{authed: st.authed}
+			reply(250, "OK: queued")
+		case "RSET":
+			st = session{authed: st.authed}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+func (s *Server) auth(tp *textproto.Conn, arg string, st *session) {
+	mech, initial, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mech, "PLAIN") {
+		tp.PrintfLine("504 unrecognized authentication type")
+		return
+	}
+	if initial == "" {
+		tp.PrintfLine("334 ")
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		initial = line
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(initial)
+	parts := strings.Split(string(raw), "\x00")
+	if err != nil || len(parts) != 3 {
+		tp.PrintfLine("501 malformed credentials")
+		return
+	}
+	if want, ok := s.Credentials[parts[1]]; len(s.Credentials) > 0 && (!ok || want != parts[2]) {
+		tp.PrintfLine("535 authentication failed")
+		return
+	}
+	st.authed = true
+	tp.PrintfLine("235 authentication succeeded")
+}
+
+// address extracts the mailbox from "FROM:<a@b> SIZE=..." style arguments.
+func address(arg, prefix string) string {
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	arg, _, _ = strings.Cut(strings.TrimSpace(arg), " ")
+	return strings.Trim(arg, "<>")
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// SpoolSender writes each message to its own .eml file in Dir. Files
+// are written to a temporary name and renamed, so a process picking up
+// the spool never sees a partial message.
+type SpoolSender struct {
+	Dir string
+	seq atomic.Uint64
+}
+
+// NewSpoolSender creates the spool directory if needed.
+func NewSpoolSender(dir string) (*SpoolSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &SpoolSender{Dir: dir}, nil
+}
+
+// Send writes msg to the spool.
+func (s *SpoolSender) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%d-%06d.eml", time.Now().UnixNano(), os.Getpid(), s.seq.Add(1))
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("notify: spool: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("notify: spool: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("notify: spool: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Dir, name)); err != nil {
+		return fmt.Errorf("notify: spool: %w", err)
+	}
+	return nil
+}
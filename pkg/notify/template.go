@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"text/template"
+)
+
+// Template renders the subject and bodies of a message. The HTML body
+// is escaped by html/template; the subject and text body use
+// text/template.
+type Template struct {
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+// NewTemplate parses the given sources. An empty text or html source
+// leaves that body out of rendered messages.
+func NewTemplate(name, subject, text, html string) (*Template, error) {
+	t := &Template{}
+	var err error
+	if t.subject, err = template.New(name + ".subject").Parse(subject); err != nil {
+		return nil, err
+	}
+	if text != "" {
+		if t.text, err = template.New(name + ".text").Parse(text); err != nil {
+			return nil, err
+		}
+	}
+	if html != "" {
+		if t.html, err = htmltemplate.New(name + ".html").Parse(html); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Render executes the templates with data and returns a message
+// addressed from and to the given addresses.
+func (t *Template) Render(from string, to []string, data any) (*Message, error) {
+	msg := &Message{From: from, To: to}
+	var buf bytes.Buffer
+
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	msg.Subject = buf.String()
+
+	if t.text != nil {
+		buf.Reset()
+		if err := t.text.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		msg.Text = buf.String()
+	}
+	if t.html != nil {
+		buf.Reset()
+		if err := t.html.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		msg.HTML = buf.String()
+	}
+	return msg, nil
+}
@@ -0,0 +1,7 @@
+// Package orderedmap provides maps with a predictable iteration order.
+//
+// OrderedMap remembers insertion order with O(1) Set, Get and Delete.
+// SortedMap keeps keys ordered by a comparator using a skip list and
+// supports range queries and floor/ceiling lookups. Both encode to JSON
+// objects whose keys appear in iteration order.
+package orderedmap
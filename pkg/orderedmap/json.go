@@ -0,0 +1,112 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strconv"
+)
+
+// marshalObject writes entries as a JSON object in iteration order.
+func marshalObject[K, V any](entries iter.Seq2[K, V]) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for k, v := range entries {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		key, err := marshalKey(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalKey encodes a key as a JSON string. encoding.TextMarshaler keys
+// use their text, strings encode as themselves and numbers and bools are
+// quoted, close to the rules encoding/json applies to map keys.
+func marshalKey[K any](k K) ([]byte, error) {
+	if tm, ok := any(k).(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(string(text))
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(data) > 0 && data[0] == '"':
+		return data, nil
+	case len(data) > 0 && (data[0] == '-' || data[0] == 't' || data[0] == 'f' || (data[0] >= '0' && data[0] <= '9')):
+		return []byte(strconv.Quote(string(data))), nil
+	default:
+		return nil, fmt.Errorf("orderedmap: unsupported key type %T", k)
+	}
+}
+
+// unmarshalObject decodes a JSON object and calls set for each member in
+// document order.
+func unmarshalObject[K, V any](data []byte, set func(K, V)) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("orderedmap: expected JSON object, got %v", tok)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var key K
+		if err := unmarshalKey(tok.(string), &key); err != nil {
+			return err
+		}
+
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		set(key, value)
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// unmarshalKey decodes an object key with encoding.TextUnmarshaler when
+// K implements it, and otherwise first as a JSON string and then as a
+// bare literal so numeric and bool keys round-trip.
+func unmarshalKey[K any](s string, key *K) error {
+	if tu, ok := any(key).(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(s))
+	}
+	if err := json.Unmarshal([]byte(strconv.Quote(s)), key); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s), key); err != nil {
+		return fmt.Errorf("orderedmap: cannot decode key %q into %T: %w", s, *key, err)
+	}
+	return nil
+}
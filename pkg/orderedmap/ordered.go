@@ -0,0 +1,215 @@
+package orderedmap
+
+import (
+	"encoding/json"
+	"iter"
+)
+
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *entry[K, V]
+	// deleted marks an entry removed by Delete. Its links are kept so an
+	// iterator standing on it can still find the following live entry.
+	deleted bool
+}
+
+// OrderedMap is a map that iterates in insertion order.
+// The zero value is ready to use.
+type OrderedMap[K comparable, V any] struct {
+	index map[K]*entry[K, V]
+	// root is a sentinel: root.next is the oldest entry, root.prev the newest.
+	root entry[K, V]
+}
+
+// New creates an empty ordered map.
+func New[K comparable, V any]() *OrderedMap[K, V] {
+	m := &OrderedMap[K, V]{}
+	m.lazyInit()
+	return m
+}
+
+func (m *OrderedMap[K, V]) lazyInit() {
+	if m.index == nil {
+		m.index = make(map[K]*entry[K, V])
+		m.root.next = &m.root
+		m.root.prev = &m.root
+	}
+}
+
+// Set stores value under key. Updating an existing key keeps its position.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	m.lazyInit()
+	if e, ok := m.index[key]; ok {
+		e.value = value
+		return
+	}
+
+	e := &entry[K, V]{key: key, value: value}
+	m.insertBefore(e, &m.root)
+	m.index[key] = e
+}
+
+// Get returns the value stored under key.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	if e, ok := m.index[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Has reports whether key is present.
+func (m *OrderedMap[K, V]) Has(key K) bool {
+	_, ok := m.index[key]
+	return ok
+}
+
+// Delete removes key and reports whether it was present.
+func (m *OrderedMap[K, V]) Delete(key K) bool {
+	e, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	m.unlink(e)
+	e.deleted = true
+	delete(m.index, key)
+	return true
+}
+
+// MoveToBack moves key to the newest position.
+func (m *OrderedMap[K, V]) MoveToBack(key K) bool {
+	e, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	m.unlink(e)
+	m.insertBefore(e, &m.root)
+	return true
+}
+
+// MoveToFront moves key to the oldest position.
+func (m *OrderedMap[K, V]) MoveToFront(key K) bool {
+	e, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	m.unlink(e)
+	m.insertBefore(e, m.root.next)
+	return true
+}
+
+// Oldest returns the first inserted entry.
+func (m *OrderedMap[K, V]) Oldest() (K, V, bool) {
+	return m.edge(m.root.next)
+}
+
+// Newest returns the last inserted entry.
+func (m *OrderedMap[K, V]) Newest() (K, V, bool) {
+	return m.edge(m.root.prev)
+}
+
+// Len returns the number of entries.
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.index)
+}
+
+// All iterates over entries in insertion order. Any entry may be deleted
+// during iteration; deleted entries that have not been reached yet are
+// skipped.
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.index == nil {
+			return
+		}
+		for e := m.root.next; e != &m.root; {
+			if !yield(e.key, e.value) {
+				return
+			}
+			for e = e.next; e.deleted; e = e.next {
+			}
+		}
+	}
+}
+
+// Backward iterates over entries from newest to oldest. Entries may be
+// deleted during iteration, as with All.
+func (m *OrderedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.index == nil {
+			return
+		}
+		for e := m.root.prev; e != &m.root; {
+			if !yield(e.key, e.value) {
+				return
+			}
+			for e = e.prev; e.deleted; e = e.prev {
+			}
+		}
+	}
+}
+
+// Keys iterates over keys in insertion order.
+func (m *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values iterates over values in insertion order.
+func (m *OrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range m.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON encodes the map as a JSON object in insertion order.
+func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	return marshalObject(m.All())
+}
+
+// UnmarshalJSON decodes a JSON object, inserting keys in document order.
+func (m *OrderedMap[K, V]) UnmarshalJSON(data []byte) error {
+	*m = OrderedMap[K, V]{}
+	m.lazyInit()
+	return unmarshalObject(data, m.Set)
+}
+
+func (m *OrderedMap[K, V]) edge(e *entry[K, V]) (K, V, bool) {
+	if m.index == nil || e == &m.root {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	return e.key, e.value, true
+}
+
+func (m *OrderedMap[K, V]) insertBefore(e, at *entry[K, V]) {
+	e.prev = at.prev
+	e.next = at
+	at.prev.next = e
+	at.prev = e
+}
+
+// unlink removes e from the list but leaves its own links in place for
+// iterators; insertBefore overwrites them when e is moved.
+func (m *OrderedMap[K, V]) unlink(e *entry[K, V]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+}
+
+// compile-time checks
+var (
+	_ json.Marshaler   = (*OrderedMap[string, int])(nil)
+	_ json.Unmarshaler = (*OrderedMap[string, int])(nil)
+)
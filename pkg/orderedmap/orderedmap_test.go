@@ -0,0 +1,195 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/orderedmap"
+)
+
+func TestOrderedMapDeleteDuringIteration(t *testing.T) {
+	m := orderedmap.New[int, string]()
+	for i := range 6 {
+		m.Set(i, fmt.Sprint(i))
+	}
+	var seen []int
+	for k := range m.All() {
+		seen = append(seen, k)
+		switch k {
+		case 0:
+			m.Delete(1) // the next entry
+			m.Delete(2) // and the one after it
+		case 3:
+			m.Delete(3) // the current entry
+			m.Delete(5) // the last entry
+		}
+	}
+	if want := []int{0, 3, 4}; !slices.Equal(seen, want) {
+		t.Fatalf("All visited %v, want %v", seen, want)
+	}
+	if got := slices.Collect(m.Keys()); !slices.Equal(got, []int{0, 4}) {
+		t.Fatalf("Keys after deletes = %v", got)
+	}
+
+	seen = seen[:0]
+	for k := range m.Backward() {
+		seen = append(seen, k)
+		m.Delete(0)
+	}
+	if want := []int{4}; !slices.Equal(seen, want) {
+		t.Fatalf("Backward visited %v, want %v", seen, want)
+	}
+}
+
+func TestOrderedMapJSONOrder(t *testing.T) {
+	m := orderedmap.New[string, int]()
+	m.Set("b", 1)
+	m.Set("a", 2)
+	data, err := json.Marshal(m)
+	if err != nil || string(data) != `{"b":1,"a":2}` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+
+	m.Set("stale", 0)
+	if err := json.Unmarshal([]byte(`{"z":1,"y":2}`), m); err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(m.Keys()); !slices.Equal(got, []string{"z", "y"}) {
+		t.Fatalf("Keys after Unmarshal = %v", got)
+	}
+}
+
+func TestSortedMapUnmarshalJSON(t *testing.T) {
+	m := orderedmap.NewSorted[int, string]()
+	m.Set(99, "stale")
+	if err := json.Unmarshal([]byte(`{"3":"c","1":"a","2":"b"}`), m); err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(m.Keys()); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("Keys = %v, want [1 2 3]", got)
+	}
+
+	var zero orderedmap.SortedMap[int, string]
+	if err := json.Unmarshal([]byte(`{"1":"a"}`), &zero); !errors.Is(err, orderedmap.ErrNoComparator) {
+		t.Fatalf("Unmarshal into zero SortedMap = %v, want ErrNoComparator", err)
+	}
+}
+
+func TestTextMarshalerKeys(t *testing.T) {
+	m := orderedmap.NewSortedFunc[netip.Addr, int](func(a, b netip.Addr) int { return a.Compare(b) })
+	m.Set(netip.MustParseAddr("10.0.0.2"), 2)
+	m.Set(netip.MustParseAddr("10.0.0.1"), 1)
+	data, err := json.Marshal(m)
+	if err != nil || string(data) != `{"10.0.0.1":1,"10.0.0.2":2}` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+
+	back := orderedmap.New[netip.Addr, int]()
+	if err := json.Unmarshal(data, back); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := back.Get(netip.MustParseAddr("10.0.0.2")); !ok || v != 2 {
+		t.Fatalf("Get after round trip = %d, %v", v, ok)
+	}
+}
+
+func sortedOf(keys ...int) *orderedmap.SortedMap[int, string] {
+	m := orderedmap.NewSorted[int, string]()
+	for _, k := range keys {
+		m.Set(k, fmt.Sprint(k))
+	}
+	return m
+}
+
+func TestSortedMapFloorCeiling(t *testing.T) {
+	m := sortedOf(50, 10, 30, 20, 40)
+	tests := []struct {
+		key            int
+		floor, ceiling int
+		hasF, hasC     bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{25, 20, 30, true, true},
+		{50, 50, 50, true, true},
+		{55, 50, 0, true, false},
+	}
+	for _, tt := range tests {
+		if k, _, ok := m.Floor(tt.key); ok != tt.hasF || (ok && k != tt.floor) {
+			t.Errorf("Floor(%d) = %d, %v; want %d, %v", tt.key, k, ok, tt.floor, tt.hasF)
+		}
+		if k, _, ok := m.Ceiling(tt.key); ok != tt.hasC || (ok && k != tt.ceiling) {
+			t.Errorf("Ceiling(%d) = %d, %v; want %d, %v", tt.key, k, ok, tt.ceiling, tt.hasC)
+		}
+	}
+	if k, _, _ := m.Min(); k != 10 {
+		t.Errorf("Min = %d", k)
+	}
+	if k, _, _ := m.Max(); k != 50 {
+		t.Errorf("Max = %d", k)
+	}
+}
+
+func TestSortedMapRange(t *testing.T) {
+	m := sortedOf(50, 10, 30, 20, 40)
+	tests := []struct {
+		from, to int
+		want     []int
+	}{
+		{20, 40, []int{20, 30}},
+		{15, 45, []int{20, 30, 40}},
+		{0, 100, []int{10, 20, 30, 40, 50}},
+		{30, 30, nil},
+		{60, 70, nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for k := range m.Range(tt.from, tt.to) {
+			got = append(got, k)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Range(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+	if got := slices.Collect(m.Keys()); !slices.Equal(got, []int{10, 20, 30, 40, 50}) {
+		t.Errorf("Keys = %v", got)
+	}
+	var back []int
+	for k := range m.Backward() {
+		back = append(back, k)
+	}
+	if !slices.Equal(back, []int{50, 40, 30, 20, 10}) {
+		t.Errorf("Backward = %v", back)
+	}
+}
+
+func TestSortedMapIteratorReuse(t *testing.T) {
+	m := sortedOf(20, 30)
+	all, from, rng := m.All(), m.From(15), m.Range(15, 35)
+
+	// Mutate after the sequences are built but before they are ranged.
+	m.Delete(20)
+	m.Set(10, "10")
+	m.Set(25, "25")
+
+	collect := func(seq func(func(int, string) bool)) []int {
+		var keys []int
+		for k := range seq {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+	if got := collect(all); !slices.Equal(got, []int{10, 25, 30}) {
+		t.Errorf("All = %v, want [10 25 30]", got)
+	}
+	if got := collect(from); !slices.Equal(got, []int{25, 30}) {
+		t.Errorf("From = %v, want [25 30]", got)
+	}
+	if got := collect(rng); !slices.Equal(got, []int{25, 30}) {
+		t.Errorf("Range = %v, want [25 30]", got)
+	}
+}
@@ -0,0 +1,265 @@
+package orderedmap
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"math/rand/v2"
+)
+
+// ErrNoComparator is returned when decoding into a SortedMap that was not
+// created with NewSorted or NewSortedFunc.
+var ErrNoComparator = errors.New("orderedmap: SortedMap has no comparator")
+
+const (
+	maxLevel    = 32
+	probability = 0.25
+)
+
+type node[K, V any] struct {
+	key   K
+	value V
+	prev  *node[K, V]
+	next  []*node[K, V]
+}
+
+// SortedMap is a map that iterates in key order. It is implemented as a
+// skip list, so lookups, inserts and deletes take O(log n) on average.
+// The zero value is not usable; create maps with NewSorted or
+// NewSortedFunc, which supply the ordering.
+type SortedMap[K, V any] struct {
+	compare func(a, b K) int
+	head    node[K, V]
+	tail    *node[K, V]
+	level   int
+	length  int
+}
+
+// NewSorted creates a sorted map ordered by the natural order of K.
+func NewSorted[K cmp.Ordered, V any]() *SortedMap[K, V] {
+	return NewSortedFunc[K, V](cmp.Compare[K])
+}
+
+// NewSortedFunc creates a sorted map ordered by compare, which returns a
+// negative number when a < b, zero when a == b and a positive number
+// when a > b.
+func NewSortedFunc[K, V any](compare func(a, b K) int) *SortedMap[K, V] {
+	return &SortedMap[K, V]{
+		compare: compare,
+		head:    node[K, V]{next: make([]*node[K, V], maxLevel)},
+		level:   1,
+	}
+}
+
+// Set stores value under key.
+func (m *SortedMap[K, V]) Set(key K, value V) {
+	var update [maxLevel]*node[K, V]
+	x := m.search(key, &update)
+	if x != nil && m.compare(x.key, key) == 0 {
+		x.value = value
+		return
+	}
+
+	level := randomLevel()
+	if level > m.level {
+		for i := m.level; i < level; i++ {
+			update[i] = &m.head
+		}
+		m.level = level
+	}
+
+	n := &node[K, V]{key: key, value: value, next: make([]*node[K, V], level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+
+	if update[0] != &m.head {
+		n.prev = update[0]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		m.tail = n
+	}
+	m.length++
+}
+
+// Get returns the value stored under key.
+func (m *SortedMap[K, V]) Get(key K) (V, bool) {
+	x := m.search(key, nil)
+	if x != nil && m.compare(x.key, key) == 0 {
+		return x.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Has reports whether key is present.
+func (m *SortedMap[K, V]) Has(key K) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Delete removes key and reports whether it was present.
+func (m *SortedMap[K, V]) Delete(key K) bool {
+	var update [maxLevel]*node[K, V]
+	x := m.search(key, &update)
+	if x == nil || m.compare(x.key, key) != 0 {
+		return false
+	}
+
+	for i := 0; i < m.level; i++ {
+		if update[i].next[i] != x {
+			break
+		}
+		update[i].next[i] = x.next[i]
+	}
+	if x.next[0] != nil {
+		x.next[0].prev = x.prev
+	} else {
+		m.tail = x.prev
+	}
+	for m.level > 1 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+	m.length--
+	return true
+}
+
+// Len returns the number of entries.
+func (m *SortedMap[K, V]) Len() int {
+	return m.length
+}
+
+// Min returns the entry with the smallest key.
+func (m *SortedMap[K, V]) Min() (K, V, bool) {
+	return result(m.head.next[0])
+}
+
+// Max returns the entry with the largest key.
+func (m *SortedMap[K, V]) Max() (K, V, bool) {
+	return result(m.tail)
+}
+
+// Floor returns the entry with the largest key less than or equal to key.
+func (m *SortedMap[K, V]) Floor(key K) (K, V, bool) {
+	x := m.search(key, nil)
+	if x != nil && m.compare(x.key, key) == 0 {
+		return result(x)
+	}
+	if x == nil {
+		return result(m.tail)
+	}
+	return result(x.prev)
+}
+
+// Ceiling returns the entry with the smallest key greater than or equal
+// to key.
+func (m *SortedMap[K, V]) Ceiling(key K) (K, V, bool) {
+	return result(m.search(key, nil))
+}
+
+// All iterates over entries in ascending key order.
+func (m *SortedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.ascend(func() *node[K, V] { return m.head.next[0] }, nil)
+}
+
+// Backward iterates over entries in descending key order.
+func (m *SortedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for x := m.tail; x != nil; x = x.prev {
+			if !yield(x.key, x.value) {
+				return
+			}
+		}
+	}
+}
+
+// Range iterates over entries with from <= key < to in ascending order.
+func (m *SortedMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return m.ascend(func() *node[K, V] { return m.search(from, nil) }, func(k K) bool {
+		return m.compare(k, to) < 0
+	})
+}
+
+// From iterates over entries with key >= from in ascending order.
+func (m *SortedMap[K, V]) From(from K) iter.Seq2[K, V] {
+	return m.ascend(func() *node[K, V] { return m.search(from, nil) }, nil)
+}
+
+// Keys iterates over keys in ascending order.
+func (m *SortedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON encodes the map as a JSON object in key order.
+func (m *SortedMap[K, V]) MarshalJSON() ([]byte, error) {
+	return marshalObject(m.All())
+}
+
+// UnmarshalJSON replaces the map's entries with a decoded JSON object.
+// The map must have been created with NewSorted or NewSortedFunc so it
+// has a comparator; otherwise ErrNoComparator is returned.
+func (m *SortedMap[K, V]) UnmarshalJSON(data []byte) error {
+	if m.compare == nil {
+		return ErrNoComparator
+	}
+	*m = *NewSortedFunc[K, V](m.compare)
+	return unmarshalObject(data, m.Set)
+}
+
+// search returns the first node with key >= key, recording the rightmost
+// node visited on each level in update when it is non-nil.
+func (m *SortedMap[K, V]) search(key K, update *[maxLevel]*node[K, V]) *node[K, V] {
+	x := &m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+// ascend iterates from the node start returns, looked up each time the
+// sequence is ranged over so a sequence stays valid after mutations.
+func (m *SortedMap[K, V]) ascend(start func() *node[K, V], inRange func(K) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for x := start(); x != nil; x = x.next[0] {
+			if inRange != nil && !inRange(x.key) {
+				return
+			}
+			if !yield(x.key, x.value) {
+				return
+			}
+		}
+	}
+}
+
+func result[K, V any](x *node[K, V]) (K, V, bool) {
+	if x == nil {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	return x.key, x.value, true
+}
+
+func randomLevel() int {
+	level := 1
+	for level < maxLevel && rand.Float64() < probability {
+		level++
+	}
+	return level
+}
@@ -0,0 +1,108 @@
+package payment
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Card validation errors.
+var (
+	ErrInvalidNumber = errors.New("payment: invalid card number")
+	ErrInvalidCVV    = errors.New("payment: invalid CVV")
+	ErrCardExpired   = errors.New("payment: card expired")
+)
+
+// CreditCard extends the CreditCard struct from the structs chapter with
+// an expiry date.
+type CreditCard struct {
+	Number   string
+	CVV      string
+	ExpMonth int
+	ExpYear  int
+	Holder   string
+}
+
+// Validate checks the number with the Luhn algorithm, the CVV length
+// and that the card has not expired at now. Cards expire at the end of
+// their expiry month.
+func (c CreditCard) Validate(now time.Time) error {
+	number := c.digits()
+	if len(number) < 12 || len(number) > 19 || !Luhn(number) {
+		return ErrInvalidNumber
+	}
+	if n := len(c.CVV); n < 3 || n > 4 || strings.Trim(c.CVV, "0123456789") != "" {
+		return ErrInvalidCVV
+	}
+	if c.ExpMonth < 1 || c.ExpMonth > 12 {
+		return ErrCardExpired
+	}
+	expires := time.Date(c.ExpYear, time.Month(c.ExpMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+	if !now.Before(expires) {
+		return ErrCardExpired
+	}
+	return nil
+}
+
+// Last4 returns the last four digits of the number.
+func (c CreditCard) Last4() string {
+	number := c.digits()
+	if len(number) < 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
+
+// Brand guesses the card network from the number prefix.
+func (c CreditCard) Brand() string {
+	number := c.digits()
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "visa"
+	case len(number) >= 2 && number[:2] >= "51" && number[:2] <= "55":
+		return "mastercard"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "amex"
+	case strings.HasPrefix(number, "6011"), strings.HasPrefix(number, "65"):
+		return "discover"
+	default:
+		return "unknown"
+	}
+}
+
+// String masks the number so cards can be logged safely.
+func (c CreditCard) String() string {
+	return c.Brand() + " ****" + c.Last4()
+}
+
+// digits returns the card number without spaces or dashes.
+func (c CreditCard) digits() string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, c.Number)
+}
+
+// Luhn reports whether number, a string of digits, passes the Luhn
+// checksum.
+func Luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return len(number) > 0 && sum%10 == 0
+}
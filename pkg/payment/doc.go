@@ -0,0 +1,17 @@
+// Package payment turns the CreditCardProcessor example from the
+// interfaces chapter into a small payments layer.
+//
+// Processors are registered by name, like database/sql drivers, and
+// opened with a configuration map:
+//
+//	import _ "github.com/thanhnamdk2710/go-handbook/pkg/payment/simulator"
+//
+//	proc, err := payment.Open("simulator", payment.Config{"timeout": "50ms"})
+//	gw := payment.NewGateway(proc)
+//
+//	p, err := gw.Authorize(ctx, "order-42-auth", payment.AuthorizeRequest{...})
+//	p, err = gw.Capture(ctx, "order-42-capture", p.ID, 500)
+//
+// A Gateway validates cards, enforces the authorize, capture, refund and
+// void state machine and de-duplicates requests by idempotency key.
+package payment
@@ -0,0 +1,318 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for unknown payment IDs.
+	ErrNotFound = errors.New("payment: not found")
+	// ErrIdempotencyConflict is returned when an idempotency key is
+	// reused for a different request.
+	ErrIdempotencyConflict = errors.New("payment: idempotency key reused with different parameters")
+)
+
+// Gateway manages payments on top of a Processor.
+type Gateway struct {
+	processor Processor
+	now       func() time.Time
+	// secret keys the request fingerprints so stored idempotency records
+	// never hold card numbers.
+	secret []byte
+
+	mu       sync.Mutex
+	seq      int
+	payments map[string]*Payment
+	locks    map[string]*sync.Mutex
+	requests map[string]*request
+	// order lists requests oldest first so expired ones can be dropped
+	// from the front.
+	order          []keyedRequest
+	idempotencyTTL time.Duration
+}
+
+// DefaultIdempotencyTTL is how long a Gateway remembers idempotency keys
+// unless SetIdempotencyTTL changes it.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// request is the stored outcome of an idempotent call. Concurrent
+// duplicates wait on done and then share the outcome.
+type request struct {
+	fingerprint string // keyed hash of the request parameters
+	at          time.Time
+	done        chan struct{}
+	payment     *Payment
+	err         error
+}
+
+type keyedRequest struct {
+	key string
+	r   *request
+}
+
+// NewGateway creates a gateway that uses processor.
+func NewGateway(processor Processor) *Gateway {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return &Gateway{
+		processor: processor,
+		now:       time.Now,
+		secret:    secret,
+		payments:  make(map[string]*Payment),
+		locks:     make(map[string]*sync.Mutex),
+		requests:  make(map[string]*request),
+
+		idempotencyTTL: DefaultIdempotencyTTL,
+	}
+}
+
+// SetClock replaces time.Now for card expiry checks, timestamps and
+// idempotency key expiry.
+func (g *Gateway) SetClock(now func() time.Time) {
+	g.now = now
+}
+
+// SetIdempotencyTTL sets how long idempotency keys are remembered. A key
+// reused after it has expired starts a new request.
+func (g *Gateway) SetIdempotencyTTL(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.idempotencyTTL = d
+}
+
+// Get returns a copy of the payment with the given ID.
+func (g *Gateway) Get(id string) (*Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return p.clone(), nil
+}
+
+// Authorize validates the card and asks the processor for a hold.
+// Declines are recorded as payments with StatusDeclined and returned
+// together with a *DeclineError.
+func (g *Gateway) Authorize(ctx context.Context, key string, req AuthorizeRequest) (*Payment, error) {
+	fp := fmt.Sprintf("authorize|%s|%d|%s", req.Card.digits(), req.Amount, req.Currency)
+	return g.idempotent(key, fp, func() (*Payment, error) {
+		if req.Amount <= 0 {
+			return nil, ErrInvalidAmount
+		}
+		if err := req.Card.Validate(g.now()); err != nil {
+			return nil, err
+		}
+
+		auth, err := g.processor.Authorize(ctx, req)
+		var decline *DeclineError
+		if err != nil && !errors.As(err, &decline) {
+			return nil, err
+		}
+
+		now := g.now()
+		p := &Payment{
+			Currency:  req.Currency,
+			Card:      req.Card.String(),
+			CreatedAt: now,
+		}
+		if decline != nil {
+			p.Decline = decline
+			p.transition(StatusDeclined, req.Amount, now)
+		} else {
+			p.Amount = auth.Amount
+			p.AuthorizationID = auth.ID
+			p.transition(StatusAuthorized, auth.Amount, now)
+		}
+
+		g.mu.Lock()
+		g.seq++
+		p.ID = fmt.Sprintf("pay_%06d", g.seq)
+		g.payments[p.ID] = p
+		g.locks[p.ID] = &sync.Mutex{}
+		g.mu.Unlock()
+
+		if decline != nil {
+			return p.clone(), err
+		}
+		return p.clone(), nil
+	})
+}
+
+// Capture collects amount from an authorized payment. Several partial
+// captures are allowed up to the authorized amount.
+func (g *Gateway) Capture(ctx context.Context, key, id string, amount int64) (*Payment, error) {
+	fp := fmt.Sprintf("capture|%s|%d", id, amount)
+	return g.idempotent(key, fp, func() (*Payment, error) {
+		return g.update(id, func(p *Payment) error {
+			if err := p.checkCapture(amount); err != nil {
+				return err
+			}
+			captureID, err := g.processor.Capture(ctx, p.AuthorizationID, amount)
+			if err != nil {
+				return err
+			}
+			p.applyCapture(captureID, amount, g.now())
+			return nil
+		})
+	})
+}
+
+// Refund returns amount from the payment's captures, most recent first,
+// issuing one processor refund per capture it draws on. If a later
+// refund fails, the ones already issued are kept and the payment is
+// returned together with the error.
+func (g *Gateway) Refund(ctx context.Context, key, id string, amount int64) (*Payment, error) {
+	fp := fmt.Sprintf("refund|%s|%d", id, amount)
+	return g.idempotent(key, fp, func() (*Payment, error) {
+		return g.update(id, func(p *Payment) error {
+			if err := p.checkRefund(amount); err != nil {
+				return err
+			}
+			for i := len(p.Captures) - 1; i >= 0 && amount > 0; i-- {
+				part := min(amount, p.Captures[i].Amount-p.Captures[i].Refunded)
+				if part == 0 {
+					continue
+				}
+				refundID, err := g.processor.Refund(ctx, p.Captures[i].ID, part)
+				if err != nil {
+					return err
+				}
+				p.applyRefund(i, refundID, part, g.now())
+				amount -= part
+			}
+			return nil
+		})
+	})
+}
+
+// Void releases an authorization that has not been captured.
+func (g *Gateway) Void(ctx context.Context, key, id string) (*Payment, error) {
+	fp := "void|" + id
+	return g.idempotent(key, fp, func() (*Payment, error) {
+		return g.update(id, func(p *Payment) error {
+			if err := p.checkVoid(); err != nil {
+				return err
+			}
+			if err := g.processor.Void(ctx, p.AuthorizationID); err != nil {
+				return err
+			}
+			p.transition(StatusVoided, p.Amount, g.now())
+			return nil
+		})
+	})
+}
+
+// update runs fn on a working copy of the payment while holding the
+// payment's lock. The copy is committed if fn succeeds or if it recorded
+// a status change before failing, since the processor has then already
+// acted on part of the request.
+func (g *Gateway) update(id string, fn func(p *Payment) error) (*Payment, error) {
+	g.mu.Lock()
+	p, ok := g.payments[id]
+	lock := g.locks[id]
+	g.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	g.mu.Lock()
+	working := p.clone()
+	g.mu.Unlock()
+
+	err := fn(working)
+	if err != nil && len(working.History) == len(p.History) {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	g.payments[id] = working
+	g.mu.Unlock()
+	return working.clone(), err
+}
+
+// idempotent runs fn once per key. Replays with the same parameters get
+// the stored outcome; replays with different parameters are rejected.
+// Outcomes that leave the result unknown, such as timeouts and context
+// errors, are not stored so the caller can retry with the same key.
+// Stored outcomes are forgotten after the idempotency TTL.
+func (g *Gateway) idempotent(key, fingerprint string, fn func() (*Payment, error)) (*Payment, error) {
+	if key == "" {
+		return fn()
+	}
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(fingerprint))
+	fingerprint = hex.EncodeToString(mac.Sum(nil))
+
+	g.mu.Lock()
+	now := g.now()
+	g.expireRequests(now)
+	if r, ok := g.requests[key]; ok {
+		g.mu.Unlock()
+		if r.fingerprint != fingerprint {
+			return nil, ErrIdempotencyConflict
+		}
+		<-r.done
+		if r.payment == nil {
+			return nil, r.err
+		}
+		return r.payment.clone(), r.err
+	}
+	r := &request{fingerprint: fingerprint, at: now, done: make(chan struct{})}
+	g.requests[key] = r
+	g.order = append(g.order, keyedRequest{key, r})
+	g.mu.Unlock()
+
+	r.payment, r.err = fn()
+	if isUnknownOutcome(r.err) {
+		g.mu.Lock()
+		delete(g.requests, key)
+		g.mu.Unlock()
+	}
+	close(r.done)
+
+	if r.payment == nil {
+		return nil, r.err
+	}
+	return r.payment.clone(), r.err
+}
+
+// expireRequests forgets finished requests older than the TTL. A request
+// still in flight stops the sweep; it and those behind it are looked at
+// again on a later call. The caller holds g.mu.
+func (g *Gateway) expireRequests(now time.Time) {
+	for len(g.order) > 0 {
+		head := g.order[0]
+		if g.requests[head.key] == head.r {
+			if now.Sub(head.r.at) < g.idempotencyTTL {
+				return
+			}
+			select {
+			case <-head.r.done:
+			default:
+				return
+			}
+			delete(g.requests, head.key)
+		}
+		g.order[0] = keyedRequest{}
+		g.order = g.order[1:]
+	}
+}
+
+func isUnknownOutcome(err error) bool {
+	return errors.Is(err, ErrTimeout) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,203 @@
+package payment_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/payment"
+	"github.com/thanhnamdk2710/go-handbook/pkg/payment/simulator"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newGateway(t *testing.T) *payment.Gateway {
+	t.Helper()
+	proc, err := payment.Open("simulator", payment.Config{"timeout": "5ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := payment.NewGateway(proc)
+	gw.SetClock(func() time.Time { return now })
+	return gw
+}
+
+func card(number string) payment.CreditCard {
+	return payment.CreditCard{Number: number, CVV: "123", ExpMonth: 12, ExpYear: 2030}
+}
+
+func authorize(t *testing.T, gw *payment.Gateway, number string, amount int64) *payment.Payment {
+	t.Helper()
+	p, err := gw.Authorize(context.Background(), "", payment.AuthorizeRequest{Card: card(number), Amount: amount, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRefundSplitsAcrossCapturesNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	gw := newGateway(t)
+	p := authorize(t, gw, simulator.CardApproved, 1000)
+	for _, amount := range []int64{800, 200} {
+		if _, err := gw.Capture(ctx, "", p.ID, amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := gw.Refund(ctx, "", p.ID, 700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refunded []int64
+	for _, c := range p.Captures {
+		refunded = append(refunded, c.Refunded)
+	}
+	if !slices.Equal(refunded, []int64{500, 200}) || len(p.RefundIDs) != 2 {
+		t.Fatalf("per-capture refunds = %v with %d refund IDs, want [500 200] with 2", refunded, len(p.RefundIDs))
+	}
+	if p.Status != payment.StatusPartiallyRefunded || p.Refunded != 700 {
+		t.Fatalf("status, refunded = %s, %d", p.Status, p.Refunded)
+	}
+
+	p, err = gw.Refund(ctx, "", p.ID, 300)
+	if err != nil || p.Status != payment.StatusRefunded || p.Captures[0].Refunded != 800 {
+		t.Fatalf("final refund = %+v, %v", p, err)
+	}
+	if _, err := gw.Refund(ctx, "", p.ID, 1); !errors.Is(err, payment.ErrInvalidTransition) {
+		t.Fatalf("refund of a refunded payment = %v", err)
+	}
+}
+
+func TestRefundOfPartialCapture(t *testing.T) {
+	ctx := context.Background()
+	gw := newGateway(t)
+	p := authorize(t, gw, simulator.CardApproved, 1000)
+	if _, err := gw.Capture(ctx, "", p.ID, 600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := gw.Refund(ctx, "", p.ID, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != payment.StatusPartiallyRefunded || p.Refunded != p.Captured {
+		t.Fatalf("refunding a partial capture in full = %s with %d of %d refunded, want partially refunded",
+			p.Status, p.Refunded, p.Captured)
+	}
+	if _, err := gw.Refund(ctx, "", p.ID, 1); !errors.Is(err, payment.ErrInvalidAmount) {
+		t.Fatalf("refund beyond the capture = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestSimulatorRejectsRefundAboveCapture(t *testing.T) {
+	ctx := context.Background()
+	proc, err := simulator.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := proc.Authorize(ctx, payment.AuthorizeRequest{Card: card(simulator.CardApproved), Amount: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	captureID, err := proc.Capture(ctx, auth.ID, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := proc.Refund(ctx, captureID, 200); err != nil {
+		t.Fatal(err)
+	}
+	var decline *payment.DeclineError
+	if _, err := proc.Refund(ctx, captureID, 101); !errors.As(err, &decline) || decline.Code != "refund_exceeds_capture" {
+		t.Fatalf("over-refund = %v, want refund_exceeds_capture", err)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	ctx := context.Background()
+	gw := newGateway(t)
+	req := payment.AuthorizeRequest{Card: card(simulator.CardApproved), Amount: 500, Currency: "USD"}
+
+	first, err := gw.Authorize(ctx, "order-1", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := gw.Authorize(ctx, "order-1", req)
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("replay = %v, %v; want payment %s", again, err, first.ID)
+	}
+
+	req.Card = card(simulator.CardApprovedMC)
+	if _, err := gw.Authorize(ctx, "order-1", req); !errors.Is(err, payment.ErrIdempotencyConflict) {
+		t.Fatalf("different card under the same key = %v, want conflict", err)
+	}
+
+	// A timeout leaves the outcome unknown, so the key can be retried.
+	req.Card = card(simulator.CardTimeout)
+	for range 2 {
+		if _, err := gw.Authorize(ctx, "order-2", req); !errors.Is(err, payment.ErrTimeout) {
+			t.Fatalf("Authorize = %v, want ErrTimeout", err)
+		}
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	ctx := context.Background()
+	gw := newGateway(t)
+	clock := now
+	gw.SetClock(func() time.Time { return clock })
+	gw.SetIdempotencyTTL(time.Hour)
+	req := payment.AuthorizeRequest{Card: card(simulator.CardApproved), Amount: 500, Currency: "USD"}
+
+	first, err := gw.Authorize(ctx, "order-1", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(time.Hour - time.Second)
+	if again, _ := gw.Authorize(ctx, "order-1", req); again.ID != first.ID {
+		t.Fatalf("replay within the TTL created %s, want %s", again.ID, first.ID)
+	}
+
+	clock = clock.Add(time.Second)
+	again, err := gw.Authorize(ctx, "order-1", req)
+	if err != nil || again.ID == first.ID {
+		t.Fatalf("reuse after the TTL = %v, %v; want a new payment", again, err)
+	}
+	// The expired key was replaced, so the new outcome is what replays get.
+	if replay, _ := gw.Authorize(ctx, "order-1", req); replay.ID != again.ID {
+		t.Fatalf("replay = %s, want %s", replay.ID, again.ID)
+	}
+}
+
+func TestDeclinesAndPartialApproval(t *testing.T) {
+	ctx := context.Background()
+	gw := newGateway(t)
+
+	p, err := gw.Authorize(ctx, "", payment.AuthorizeRequest{Card: card(simulator.CardInsufficientFunds), Amount: 500})
+	var decline *payment.DeclineError
+	if !errors.As(err, &decline) || decline.Code != "insufficient_funds" || p.Status != payment.StatusDeclined {
+		t.Fatalf("Authorize = %+v, %v", p, err)
+	}
+
+	p = authorize(t, gw, simulator.CardPartialApproval, 1000)
+	if p.Amount != 500 {
+		t.Fatalf("partially approved amount = %d, want 500", p.Amount)
+	}
+	if _, err := gw.Capture(ctx, "", p.ID, 501); !errors.Is(err, payment.ErrInvalidAmount) {
+		t.Fatalf("capture above authorization = %v", err)
+	}
+
+	p = authorize(t, gw, simulator.CardCaptureLimit, 1000)
+	if _, err := gw.Capture(ctx, "", p.ID, 600); !errors.As(err, &decline) {
+		t.Fatalf("capture above the issuer limit = %v", err)
+	}
+	if got, _ := gw.Get(p.ID); got.Status != payment.StatusAuthorized || got.Captured != 0 {
+		t.Fatalf("failed capture changed the payment: %+v", got)
+	}
+
+	if _, err := gw.Authorize(ctx, "", payment.AuthorizeRequest{Card: card("4242424242424241"), Amount: 1}); !errors.Is(err, payment.ErrInvalidNumber) {
+		t.Fatalf("bad Luhn = %v", err)
+	}
+}
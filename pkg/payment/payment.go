@@ -0,0 +1,134 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Status is the state of a payment.
+type Status string
+
+const (
+	StatusAuthorized        Status = "authorized"
+	StatusPartiallyCaptured Status = "partially_captured"
+	StatusCaptured          Status = "captured"
+	StatusPartiallyRefunded Status = "partially_refunded"
+	StatusRefunded          Status = "refunded"
+	StatusVoided            Status = "voided"
+	StatusDeclined          Status = "declined"
+)
+
+// ErrInvalidTransition is returned for operations the payment's status
+// does not allow, such as refunding an uncaptured payment.
+var ErrInvalidTransition = errors.New("payment: invalid state transition")
+
+// ErrInvalidAmount is returned for amounts that are not positive or
+// exceed what is available to capture or refund.
+var ErrInvalidAmount = errors.New("payment: invalid amount")
+
+// Payment is the gateway's record of one authorization and everything
+// that happened to it afterwards.
+type Payment struct {
+	ID              string
+	Status          Status
+	Currency        string
+	Amount          int64 // authorized amount
+	Captured        int64
+	Refunded        int64
+	Card            string // masked, e.g. "visa ****4242"
+	AuthorizationID string
+	Captures        []Capture
+	RefundIDs       []string
+	Decline         *DeclineError
+	History         []Event
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Capture is one collection from the authorization and how much of it
+// has been refunded.
+type Capture struct {
+	ID       string
+	Amount   int64
+	Refunded int64
+}
+
+// Event records one status change.
+type Event struct {
+	From   Status
+	To     Status
+	Amount int64
+	At     time.Time
+}
+
+func (p *Payment) clone() *Payment {
+	c := *p
+	c.Captures = slices.Clone(p.Captures)
+	c.RefundIDs = slices.Clone(p.RefundIDs)
+	c.History = slices.Clone(p.History)
+	return &c
+}
+
+func (p *Payment) transition(to Status, amount int64, now time.Time) {
+	p.History = append(p.History, Event{From: p.Status, To: to, Amount: amount, At: now})
+	p.Status = to
+	p.UpdatedAt = now
+}
+
+// checkCapture validates a capture of amount against the state machine.
+func (p *Payment) checkCapture(amount int64) error {
+	if p.Status != StatusAuthorized && p.Status != StatusPartiallyCaptured {
+		return fmt.Errorf("%w: cannot capture a %s payment", ErrInvalidTransition, p.Status)
+	}
+	if amount <= 0 || amount > p.Amount-p.Captured {
+		return fmt.Errorf("%w: %d exceeds capturable %d", ErrInvalidAmount, amount, p.Amount-p.Captured)
+	}
+	return nil
+}
+
+func (p *Payment) applyCapture(id string, amount int64, now time.Time) {
+	p.Captured += amount
+	p.Captures = append(p.Captures, Capture{ID: id, Amount: amount})
+	if p.Captured == p.Amount {
+		p.transition(StatusCaptured, amount, now)
+	} else {
+		p.transition(StatusPartiallyCaptured, amount, now)
+	}
+}
+
+// checkRefund validates a refund of amount against the state machine.
+func (p *Payment) checkRefund(amount int64) error {
+	switch p.Status {
+	case StatusCaptured, StatusPartiallyCaptured, StatusPartiallyRefunded:
+	default:
+		return fmt.Errorf("%w: cannot refund a %s payment", ErrInvalidTransition, p.Status)
+	}
+	if amount <= 0 || amount > p.Captured-p.Refunded {
+		return fmt.Errorf("%w: %d exceeds refundable %d", ErrInvalidAmount, amount, p.Captured-p.Refunded)
+	}
+	return nil
+}
+
+// applyRefund records a refund of amount from the i-th capture. Only a
+// fully captured payment becomes StatusRefunded; refunding everything
+// collected by partial captures leaves it partially refunded, since part
+// of the authorization was never captured.
+func (p *Payment) applyRefund(i int, id string, amount int64, now time.Time) {
+	p.Captures[i].Refunded += amount
+	p.Refunded += amount
+	p.RefundIDs = append(p.RefundIDs, id)
+	if p.Refunded == p.Amount {
+		p.transition(StatusRefunded, amount, now)
+	} else {
+		p.transition(StatusPartiallyRefunded, amount, now)
+	}
+}
+
+func (p *Payment) checkVoid() error {
+	if p.Status != StatusAuthorized {
+		return fmt.Errorf("%w: cannot void a %s payment", ErrInvalidTransition, p.Status)
+	}
+	return nil
+}
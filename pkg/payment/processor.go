@@ -0,0 +1,127 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Processor talks to a payment network. Amounts are in minor units of
+// the request's currency, for example cents.
+type Processor interface {
+	// Authorize places a hold on the card. The approved amount may be
+	// lower than requested when the processor supports partial approval.
+	Authorize(ctx context.Context, req AuthorizeRequest) (Authorization, error)
+	// Capture collects part or all of an authorization.
+	Capture(ctx context.Context, authID string, amount int64) (string, error)
+	// Refund returns part or all of a capture.
+	Refund(ctx context.Context, captureID string, amount int64) (string, error)
+	// Void releases an uncaptured authorization.
+	Void(ctx context.Context, authID string) error
+}
+
+// AuthorizeRequest asks a processor to hold funds.
+type AuthorizeRequest struct {
+	Card        CreditCard
+	Amount      int64
+	Currency    string
+	Description string
+}
+
+// Authorization is a successful hold.
+type Authorization struct {
+	ID     string
+	Amount int64
+}
+
+// DeclineError is returned when the card issuer refuses a transaction.
+// Declines are final and must not be retried.
+type DeclineError struct {
+	Code    string
+	Message string
+}
+
+func (e *DeclineError) Error() string {
+	return fmt.Sprintf("payment: declined (%s): %s", e.Code, e.Message)
+}
+
+// ErrTimeout means the processor did not answer in time. The outcome is
+// unknown, so the request may be retried with the same idempotency key.
+var ErrTimeout = errors.New("payment: processor timeout")
+
+// Config configures a processor when it is opened.
+type Config map[string]string
+
+// Factory creates a processor from its configuration.
+type Factory func(cfg Config) (Processor, error)
+
+// Registry maps processor names to factories.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Register adds a factory. It panics if name is already registered,
+// which catches two packages claiming the same name at init time.
+func (r *Registry) Register(name string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if factory == nil {
+		panic("payment: Register factory is nil")
+	}
+	if _, dup := r.factories[name]; dup {
+		panic("payment: Register called twice for processor " + name)
+	}
+	r.factories[name] = factory
+}
+
+// Open creates the processor registered under name.
+func (r *Registry) Open(name string, cfg Config) (Processor, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("payment: unknown processor %q", name)
+	}
+	return factory(cfg)
+}
+
+// Names returns the registered processor names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds a factory to the default registry. Processor packages
+// call it from init.
+func Register(name string, factory Factory) {
+	defaultRegistry.Register(name, factory)
+}
+
+// Open creates a processor from the default registry.
+func Open(name string, cfg Config) (Processor, error) {
+	return defaultRegistry.Open(name, cfg)
+}
+
+// Processors lists the names in the default registry.
+func Processors() []string {
+	return defaultRegistry.Names()
+}
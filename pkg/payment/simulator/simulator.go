@@ -0,0 +1,233 @@
+// Package simulator registers a deterministic "simulator" payment
+// processor whose behaviour is chosen by magic card numbers, so
+// integration tests can exercise every path without a real network.
+//
+//	Card number        Behaviour
+//	4242424242424242   approved
+//	5555555555554444   approved
+//	4000000000000002   declined: card_declined
+//	4000000000009995   declined: insufficient_funds
+//	4000000000000069   declined: expired_card
+//	4000000000000119   processing error, not a decline
+//	4000000000000093   partially approved: half the requested amount
+//	4000000000000101   approved, but captures of more than half fail
+//	4000000000000259   approved, but refunds are declined
+//	4000000000000341   authorization times out
+//
+// Any other valid number is approved. IDs are sequential, so two runs
+// of the same test produce the same IDs.
+//
+// Configuration keys:
+//
+//	timeout  how long a timing-out call waits before returning
+//	         payment.ErrTimeout (default "100ms")
+//	latency  delay added to every call (default "0s")
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/payment"
+)
+
+// Magic card numbers.
+const (
+	CardApproved          = "4242424242424242"
+	CardApprovedMC        = "5555555555554444"
+	CardDeclined          = "4000000000000002"
+	CardInsufficientFunds = "4000000000009995"
+	CardExpired           = "4000000000000069"
+	CardProcessingError   = "4000000000000119"
+	CardPartialApproval   = "4000000000000093"
+	CardCaptureLimit      = "4000000000000101"
+	CardRefundDeclined    = "4000000000000259"
+	CardTimeout           = "4000000000000341"
+)
+
+// ErrProcessing is returned for CardProcessingError.
+var ErrProcessing = errors.New("simulator: processing error")
+
+func init() {
+	payment.Register("simulator", func(cfg payment.Config) (payment.Processor, error) {
+		return New(cfg)
+	})
+}
+
+// Processor is the simulator. Create it with New or payment.Open.
+type Processor struct {
+	timeout time.Duration
+	latency time.Duration
+
+	mu       sync.Mutex
+	seq      int
+	auths    map[string]*auth
+	captures map[string]*capture
+}
+
+type auth struct {
+	card     string
+	amount   int64
+	captured int64
+	voided   bool
+}
+
+type capture struct {
+	authID   string
+	amount   int64
+	refunded int64
+}
+
+// New creates a simulator from its configuration.
+func New(cfg payment.Config) (*Processor, error) {
+	p := &Processor{
+		timeout:  100 * time.Millisecond,
+		auths:    make(map[string]*auth),
+		captures: make(map[string]*capture),
+	}
+	if err := duration(cfg, "timeout", &p.timeout); err != nil {
+		return nil, err
+	}
+	if err := duration(cfg, "latency", &p.latency); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Processor) Authorize(ctx context.Context, req payment.AuthorizeRequest) (payment.Authorization, error) {
+	if err := p.wait(ctx, p.latency); err != nil {
+		return payment.Authorization{}, err
+	}
+
+	card := strings.NewReplacer(" ", "", "-", "").Replace(req.Card.Number)
+	amount := req.Amount
+	switch card {
+	case CardDeclined:
+		return payment.Authorization{}, &payment.DeclineError{Code: "card_declined", Message: "the card was declined"}
+	case CardInsufficientFunds:
+		return payment.Authorization{}, &payment.DeclineError{Code: "insufficient_funds", Message: "the card has insufficient funds"}
+	case CardExpired:
+		return payment.Authorization{}, &payment.DeclineError{Code: "expired_card", Message: "the card has expired"}
+	case CardProcessingError:
+		return payment.Authorization{}, ErrProcessing
+	case CardTimeout:
+		return payment.Authorization{}, p.timeoutAfter(ctx)
+	case CardPartialApproval:
+		amount = (amount + 1) / 2
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := p.nextID("auth")
+	p.auths[id] = &auth{card: card, amount: amount}
+	return payment.Authorization{ID: id, Amount: amount}, nil
+}
+
+func (p *Processor) Capture(ctx context.Context, authID string, amount int64) (string, error) {
+	if err := p.wait(ctx, p.latency); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	a, ok := p.auths[authID]
+	if !ok || a.voided {
+		return "", fmt.Errorf("simulator: unknown authorization %q", authID)
+	}
+	limit := a.amount
+	if a.card == CardCaptureLimit {
+		limit = a.amount / 2
+	}
+	if a.captured+amount > limit {
+		return "", &payment.DeclineError{Code: "capture_exceeds_limit", Message: "capture amount exceeds the issuer limit"}
+	}
+
+	a.captured += amount
+	id := p.nextID("cap")
+	p.captures[id] = &capture{authID: authID, amount: amount}
+	return id, nil
+}
+
+func (p *Processor) Refund(ctx context.Context, captureID string, amount int64) (string, error) {
+	if err := p.wait(ctx, p.latency); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.captures[captureID]
+	if !ok {
+		return "", fmt.Errorf("simulator: unknown capture %q", captureID)
+	}
+	if p.auths[c.authID].card == CardRefundDeclined {
+		return "", &payment.DeclineError{Code: "refund_declined", Message: "the issuer refused the refund"}
+	}
+	if c.refunded+amount > c.amount {
+		return "", &payment.DeclineError{Code: "refund_exceeds_capture", Message: "refund amount exceeds the captured amount"}
+	}
+
+	c.refunded += amount
+	return p.nextID("ref"), nil
+}
+
+func (p *Processor) Void(ctx context.Context, authID string) error {
+	if err := p.wait(ctx, p.latency); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	a, ok := p.auths[authID]
+	if !ok {
+		return fmt.Errorf("simulator: unknown authorization %q", authID)
+	}
+	a.voided = true
+	return nil
+}
+
+func (p *Processor) nextID(prefix string) string {
+	p.seq++
+	return fmt.Sprintf("sim_%s_%06d", prefix, p.seq)
+}
+
+func (p *Processor) timeoutAfter(ctx context.Context) error {
+	if err := p.wait(ctx, p.timeout); err != nil {
+		return err
+	}
+	return payment.ErrTimeout
+}
+
+func (p *Processor) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func duration(cfg payment.Config, key string, dst *time.Duration) error {
+	s, ok := cfg[key]
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("simulator: invalid %s %q: %w", key, s, err)
+	}
+	*dst = d
+	return nil
+}
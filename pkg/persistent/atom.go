@@ -0,0 +1,51 @@
+package persistent
+
+import "sync/atomic"
+
+// Atom holds an immutable value that readers load without locks and
+// writers replace with compare-and-swap. It is the copy-on-write holder
+// from the mutexes chapter without the full copy: pair it with Map or
+// Vector so each update shares structure with the previous version.
+//
+// The zero value holds the zero value of T.
+type Atom[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// NewAtom creates an Atom holding value.
+func NewAtom[T any](value T) *Atom[T] {
+	a := &Atom[T]{}
+	a.Store(value)
+	return a
+}
+
+// Load returns the current value.
+func (a *Atom[T]) Load() T {
+	if p := a.p.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store replaces the current value.
+func (a *Atom[T]) Store(value T) {
+	a.p.Store(&value)
+}
+
+// Update applies fn to the current value and stores the result, retrying
+// if another writer got there first. fn may run more than once and must
+// not have side effects.
+func (a *Atom[T]) Update(fn func(T) T) T {
+	for {
+		old := a.p.Load()
+		var current T
+		if old != nil {
+			current = *old
+		}
+		next := fn(current)
+		if a.p.CompareAndSwap(old, &next) {
+			return next
+		}
+	}
+}
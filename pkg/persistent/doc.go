@@ -0,0 +1,25 @@
+// Package persistent provides immutable collections with structural
+// sharing, plus an atomic holder for copy-on-write state.
+//
+// The CopyOnWrite example in the mutexes chapter clones the whole map on
+// every write. A persistent Map or Vector instead returns a new version
+// that shares all untouched nodes with the old one, so a write costs
+// O(log32 n) and readers holding the old version are never affected.
+//
+//	var routes persistent.Atom[persistent.Map[string, Handler]]
+//	routes.Store(persistent.NewMap[string, Handler]())
+//
+//	// Writers
+//	routes.Update(func(m persistent.Map[string, Handler]) persistent.Map[string, Handler] {
+//	    return m.Set("/users", usersHandler)
+//	})
+//
+//	// Readers: lock-free
+//	h, ok := routes.Load().Get("/users")
+package persistent
+
+const (
+	levelBits = 5
+	width     = 1 << levelBits
+	mask      = width - 1
+)
@@ -0,0 +1,261 @@
+package persistent
+
+import (
+	"iter"
+	"math/bits"
+
+	"github.com/thanhnamdk2710/go-handbook/internal/hashkey"
+)
+
+// Map is an immutable hash array mapped trie. Every modifying method
+// returns a new Map and leaves the receiver unchanged. The zero value is
+// an empty map that hashes keys with the package default.
+type Map[K comparable, V any] struct {
+	root *hamtNode[K, V]
+	size int
+	hash func(K) uint64
+}
+
+// hamtNode is a bitmap-indexed node. Each set bit in bitmap marks an
+// occupied slot; slots are stored compactly in popcount order and hold
+// either a leaf entry or a child node.
+type hamtNode[K comparable, V any] struct {
+	bitmap uint32
+	slots  []hamtSlot[K, V]
+}
+
+type hamtSlot[K comparable, V any] struct {
+	child *hamtNode[K, V]
+	// leaves holds one entry, or several when full 64-bit hashes collide.
+	leaves []hamtLeaf[K, V]
+}
+
+type hamtLeaf[K comparable, V any] struct {
+	hash  uint64
+	key   K
+	value V
+}
+
+// maxShift is the deepest level before hash bits run out.
+const maxShift = 60
+
+// NewMap creates an empty map.
+func NewMap[K comparable, V any]() Map[K, V] {
+	return Map[K, V]{}
+}
+
+// NewMapFunc creates an empty map that uses hash for its keys.
+func NewMapFunc[K comparable, V any](hash func(K) uint64) Map[K, V] {
+	return Map[K, V]{hash: hash}
+}
+
+// Len returns the number of entries.
+func (m Map[K, V]) Len() int {
+	return m.size
+}
+
+// Get returns the value stored under key.
+func (m Map[K, V]) Get(key K) (V, bool) {
+	h := m.hashOf(key)
+	n := m.root
+	for shift := uint(0); n != nil; shift += levelBits {
+		bit, ok := n.lookup(h, shift)
+		if !ok {
+			break
+		}
+		slot := &n.slots[n.index(bit)]
+		if slot.child == nil {
+			for _, l := range slot.leaves {
+				if l.hash == h && l.key == key {
+					return l.value, true
+				}
+			}
+			break
+		}
+		n = slot.child
+	}
+	var zero V
+	return zero, false
+}
+
+// Has reports whether key is present.
+func (m Map[K, V]) Has(key K) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Set returns a map with key bound to value.
+func (m Map[K, V]) Set(key K, value V) Map[K, V] {
+	leaf := hamtLeaf[K, V]{hash: m.hashOf(key), key: key, value: value}
+	root, added := m.root.set(leaf, 0)
+	if added {
+		m.size++
+	}
+	m.root = root
+	return m
+}
+
+// Delete returns a map without key. The receiver is returned unchanged
+// if key is absent.
+func (m Map[K, V]) Delete(key K) Map[K, V] {
+	if m.root == nil {
+		return m
+	}
+	root, removed := m.root.delete(m.hashOf(key), key, 0)
+	if !removed {
+		return m
+	}
+	m.root = root
+	m.size--
+	return m
+}
+
+// All iterates over entries in hash order.
+func (m Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.walk(yield)
+	}
+}
+
+func (m Map[K, V]) hashOf(key K) uint64 {
+	if m.hash != nil {
+		return m.hash(key)
+	}
+	return hashkey.Of(key)
+}
+
+func (n *hamtNode[K, V]) lookup(h uint64, shift uint) (uint32, bool) {
+	bit := uint32(1) << ((h >> shift) & mask)
+	return bit, n.bitmap&bit != 0
+}
+
+func (n *hamtNode[K, V]) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// set returns a copy of n with leaf inserted along its path.
+func (n *hamtNode[K, V]) set(leaf hamtLeaf[K, V], shift uint) (*hamtNode[K, V], bool) {
+	if n == nil {
+		n = &hamtNode[K, V]{}
+	}
+	bit, ok := n.lookup(leaf.hash, shift)
+	i := n.index(bit)
+
+	if !ok {
+		c := &hamtNode[K, V]{bitmap: n.bitmap | bit, slots: make([]hamtSlot[K, V], len(n.slots)+1)}
+		copy(c.slots, n.slots[:i])
+		c.slots[i] = hamtSlot[K, V]{leaves: []hamtLeaf[K, V]{leaf}}
+		copy(c.slots[i+1:], n.slots[i:])
+		return c, true
+	}
+
+	c := n.clone()
+	slot := &c.slots[i]
+	if slot.child != nil {
+		child, added := slot.child.set(leaf, shift+levelBits)
+		slot.child = child
+		return c, added
+	}
+
+	for j, l := range slot.leaves {
+		if l.hash == leaf.hash && l.key == leaf.key {
+			slot.leaves = append([]hamtLeaf[K, V](nil), slot.leaves...)
+			slot.leaves[j] = leaf
+			return c, false
+		}
+	}
+
+	if slot.leaves[0].hash == leaf.hash || shift >= maxShift {
+		// Full hash collision: keep the entries side by side.
+		slot.leaves = append(append([]hamtLeaf[K, V](nil), slot.leaves...), leaf)
+		return c, true
+	}
+
+	// Push the existing leaf down a level and retry there.
+	var child *hamtNode[K, V]
+	for _, l := range slot.leaves {
+		child, _ = child.set(l, shift+levelBits)
+	}
+	child, _ = child.set(leaf, shift+levelBits)
+	*slot = hamtSlot[K, V]{child: child}
+	return c, true
+}
+
+// delete returns a copy of n without key, or nil if n becomes empty.
+func (n *hamtNode[K, V]) delete(h uint64, key K, shift uint) (*hamtNode[K, V], bool) {
+	bit, ok := n.lookup(h, shift)
+	if !ok {
+		return n, false
+	}
+	i := n.index(bit)
+	slot := n.slots[i]
+
+	if slot.child != nil {
+		child, removed := slot.child.delete(h, key, shift+levelBits)
+		if !removed {
+			return n, false
+		}
+		c := n.clone()
+		switch {
+		case child == nil:
+			return c.without(bit, i), true
+		case len(child.slots) == 1 && child.slots[0].child == nil:
+			// Collapse a child holding a single leaf slot into this level.
+			c.slots[i] = child.slots[0]
+		default:
+			c.slots[i].child = child
+		}
+		return c, true
+	}
+
+	for j, l := range slot.leaves {
+		if l.hash != h || l.key != key {
+			continue
+		}
+		if len(slot.leaves) == 1 {
+			return n.without(bit, i), true
+		}
+		c := n.clone()
+		leaves := make([]hamtLeaf[K, V], 0, len(slot.leaves)-1)
+		leaves = append(leaves, slot.leaves[:j]...)
+		c.slots[i].leaves = append(leaves, slot.leaves[j+1:]...)
+		return c, true
+	}
+	return n, false
+}
+
+func (n *hamtNode[K, V]) without(bit uint32, i int) *hamtNode[K, V] {
+	if len(n.slots) == 1 {
+		return nil
+	}
+	c := &hamtNode[K, V]{bitmap: n.bitmap &^ bit, slots: make([]hamtSlot[K, V], 0, len(n.slots)-1)}
+	c.slots = append(c.slots, n.slots[:i]...)
+	c.slots = append(c.slots, n.slots[i+1:]...)
+	return c
+}
+
+func (n *hamtNode[K, V]) clone() *hamtNode[K, V] {
+	c := &hamtNode[K, V]{bitmap: n.bitmap, slots: make([]hamtSlot[K, V], len(n.slots))}
+	copy(c.slots, n.slots)
+	return c
+}
+
+func (n *hamtNode[K, V]) walk(yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	for _, slot := range n.slots {
+		if slot.child != nil {
+			if !slot.child.walk(yield) {
+				return false
+			}
+			continue
+		}
+		for _, l := range slot.leaves {
+			if !yield(l.key, l.value) {
+				return false
+			}
+		}
+	}
+	return true
+}
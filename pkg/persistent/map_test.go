@@ -0,0 +1,31 @@
+package persistent_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/persistent"
+)
+
+type session struct{ user string }
+
+func (s *session) String() string { return s.user }
+
+func TestMapPointerKeySurvivesMutation(t *testing.T) {
+	s := &session{user: "alice"}
+	m := persistent.NewMap[*session, int]().Set(s, 1)
+	s.user = "bob"
+	if v, ok := m.Get(s); !ok || v != 1 {
+		t.Fatalf("Get after mutating the key's pointee = %d, %v", v, ok)
+	}
+}
+
+func TestMapNegativeZeroKey(t *testing.T) {
+	m := persistent.NewMap[float64, string]().Set(0, "zero")
+	if v, ok := m.Get(math.Copysign(0, -1)); !ok || v != "zero" {
+		t.Fatalf("Get(-0) = %q, %v", v, ok)
+	}
+	if m.Set(math.Copysign(0, -1), "again").Len() != 1 {
+		t.Fatal("-0 and +0 stored as separate keys")
+	}
+}
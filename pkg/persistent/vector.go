@@ -0,0 +1,210 @@
+package persistent
+
+import "iter"
+
+// Vector is an immutable indexed sequence implemented as a 32-way
+// bit-partitioned trie with a tail buffer, so Append and Pop are
+// amortized O(1) and Get and Set are O(log32 n). The zero value is an
+// empty vector.
+type Vector[T any] struct {
+	root  *vecNode[T]
+	tail  []T
+	size  int
+	shift uint
+}
+
+type vecNode[T any] struct {
+	children []*vecNode[T]
+	values   []T
+}
+
+// NewVector creates a vector holding items.
+func NewVector[T any](items ...T) Vector[T] {
+	var v Vector[T]
+	for _, item := range items {
+		v = v.Append(item)
+	}
+	return v
+}
+
+// Len returns the number of elements.
+func (v Vector[T]) Len() int {
+	return v.size
+}
+
+// Get returns the element at index i. It panics if i is out of range.
+func (v Vector[T]) Get(i int) T {
+	v.check(i)
+	if i >= v.tailOffset() {
+		return v.tail[i-v.tailOffset()]
+	}
+	n := v.root
+	for level := v.shift; level > 0; level -= levelBits {
+		n = n.children[(i>>level)&mask]
+	}
+	return n.values[i&mask]
+}
+
+// Set returns a vector with element i replaced. It panics if i is out
+// of range.
+func (v Vector[T]) Set(i int, value T) Vector[T] {
+	v.check(i)
+	if i >= v.tailOffset() {
+		tail := append([]T(nil), v.tail...)
+		tail[i-v.tailOffset()] = value
+		v.tail = tail
+		return v
+	}
+	v.root = v.root.set(v.shift, i, value)
+	return v
+}
+
+// Append returns a vector with value added at the end.
+func (v Vector[T]) Append(value T) Vector[T] {
+	if len(v.tail) < width {
+		// Copy so appending to an older version never clobbers a newer one
+		// that shares the same backing array.
+		tail := make([]T, len(v.tail), len(v.tail)+1)
+		copy(tail, v.tail)
+		v.tail = append(tail, value)
+		v.size++
+		return v
+	}
+
+	leaf := &vecNode[T]{values: v.tail}
+	if v.root == nil {
+		v.root = &vecNode[T]{children: []*vecNode[T]{leaf}}
+		v.shift = levelBits
+	} else if (v.size-width)>>levelBits >= 1<<v.shift {
+		// The trie is full: grow a new root.
+		v.root = &vecNode[T]{children: []*vecNode[T]{v.root, newPath(v.shift, leaf)}}
+		v.shift += levelBits
+	} else {
+		v.root = v.root.pushLeaf(v.shift, v.size-width, leaf)
+	}
+	v.tail = []T{value}
+	v.size++
+	return v
+}
+
+// Pop returns a vector without its last element. It panics if the
+// vector is empty.
+func (v Vector[T]) Pop() Vector[T] {
+	if v.size == 0 {
+		panic("persistent: Pop on empty vector")
+	}
+	if v.size == 1 {
+		return Vector[T]{}
+	}
+	if len(v.tail) > 1 {
+		v.tail = v.tail[: len(v.tail)-1 : len(v.tail)-1]
+		v.size--
+		return v
+	}
+
+	// The tail is now empty: pull the last leaf out of the trie.
+	v.size--
+	last := v.size - 1
+	n := v.root
+	for level := v.shift; level > 0; level -= levelBits {
+		n = n.children[(last>>level)&mask]
+	}
+	v.tail = n.values[:len(n.values):len(n.values)]
+
+	if v.size <= width {
+		v.root, v.shift = nil, 0
+		return v
+	}
+	v.root = v.root.popLeaf(v.shift, last)
+	for v.shift > levelBits && len(v.root.children) == 1 {
+		v.root = v.root.children[0]
+		v.shift -= levelBits
+	}
+	return v
+}
+
+// All iterates over indexes and elements in order.
+func (v Vector[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := 0; i < v.size; i++ {
+			if !yield(i, v.Get(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Slice copies the elements into a new slice.
+func (v Vector[T]) Slice() []T {
+	out := make([]T, 0, v.size)
+	for _, item := range v.All() {
+		out = append(out, item)
+	}
+	return out
+}
+
+func (v Vector[T]) tailOffset() int {
+	return v.size - len(v.tail)
+}
+
+func (v Vector[T]) check(i int) {
+	if i < 0 || i >= v.size {
+		panic("persistent: vector index out of range")
+	}
+}
+
+func newPath[T any](shift uint, leaf *vecNode[T]) *vecNode[T] {
+	if shift == 0 {
+		return leaf
+	}
+	return &vecNode[T]{children: []*vecNode[T]{newPath(shift-levelBits, leaf)}}
+}
+
+func (n *vecNode[T]) pushLeaf(shift uint, i int, leaf *vecNode[T]) *vecNode[T] {
+	c := &vecNode[T]{children: append([]*vecNode[T](nil), n.children...)}
+	sub := (i >> shift) & mask
+	if shift == levelBits {
+		c.children = append(c.children, leaf)
+		return c
+	}
+	if sub < len(c.children) {
+		c.children[sub] = c.children[sub].pushLeaf(shift-levelBits, i, leaf)
+	} else {
+		c.children = append(c.children, newPath(shift-levelBits, leaf))
+	}
+	return c
+}
+
+func (n *vecNode[T]) popLeaf(shift uint, i int) *vecNode[T] {
+	sub := (i >> shift) & mask
+	if shift == levelBits {
+		if sub == 0 {
+			return nil
+		}
+		return &vecNode[T]{children: append([]*vecNode[T](nil), n.children[:sub]...)}
+	}
+
+	child := n.children[sub].popLeaf(shift-levelBits, i)
+	if child == nil && sub == 0 {
+		return nil
+	}
+	c := &vecNode[T]{children: append([]*vecNode[T](nil), n.children[:sub+1]...)}
+	if child == nil {
+		c.children = c.children[:sub]
+	} else {
+		c.children[sub] = child
+	}
+	return c
+}
+
+func (n *vecNode[T]) set(shift uint, i int, value T) *vecNode[T] {
+	if shift == 0 {
+		c := &vecNode[T]{values: append([]T(nil), n.values...)}
+		c.values[i&mask] = value
+		return c
+	}
+	c := &vecNode[T]{children: append([]*vecNode[T](nil), n.children...)}
+	sub := (i >> shift) & mask
+	c.children[sub] = c.children[sub].set(shift-levelBits, i, value)
+	return c
+}
@@ -0,0 +1,167 @@
+package persistent_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/persistent"
+)
+
+// Sizes around the boundaries of the 32-element tail and the trie levels.
+var vectorSizes = []int{0, 1, 31, 32, 33, 64, 65, 1024, 1056, 1057, 1088, 32*32*32 + 32, 32*32*32 + 33}
+
+func checkVector(t *testing.T, v persistent.Vector[int], want []int) {
+	t.Helper()
+	if v.Len() != len(want) {
+		t.Fatalf("Len = %d, want %d", v.Len(), len(want))
+	}
+	for i, w := range want {
+		if got := v.Get(i); got != w {
+			t.Fatalf("Get(%d) = %d, want %d (len %d)", i, got, w, len(want))
+		}
+	}
+}
+
+func upTo(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
+
+func TestVectorAppendAndPop(t *testing.T) {
+	for _, n := range vectorSizes {
+		v := persistent.NewVector(upTo(n)...)
+		checkVector(t, v, upTo(n))
+		if got := v.Slice(); !slices.Equal(got, upTo(n)) {
+			t.Fatalf("Slice of %d differs", n)
+		}
+
+		// Pop back across every tail and trie boundary.
+		for m := n; m > 0; m-- {
+			v = v.Pop()
+			if v.Len() != m-1 {
+				t.Fatalf("Len after Pop = %d, want %d", v.Len(), m-1)
+			}
+			if m-1 > 0 && v.Get(m-2) != m-2 {
+				t.Fatalf("last after Pop from %d = %d", m, v.Get(m-2))
+			}
+		}
+		if n > 0 {
+			// Growing again after shrinking reuses none of the popped state.
+			v = v.Append(7).Append(8)
+			checkVector(t, v, []int{7, 8})
+		}
+	}
+}
+
+func TestVectorPopReturnsEveryPrefix(t *testing.T) {
+	const n = 1100
+	want := upTo(n)
+	v := persistent.NewVector(want...)
+	for m := n; m > 0; m -= 37 {
+		checkVector(t, v, want[:m])
+		for range min(37, m) {
+			v = v.Pop()
+		}
+	}
+}
+
+func TestVectorSet(t *testing.T) {
+	for _, n := range vectorSizes[1:] {
+		v := persistent.NewVector(upTo(n)...)
+		want := upTo(n)
+		w := v
+		for i := 0; i < n; i += max(1, n/50) {
+			w = w.Set(i, -i)
+			want[i] = -i
+		}
+		w = w.Set(n-1, -1000)
+		want[n-1] = -1000
+		checkVector(t, w, want)
+		checkVector(t, v, upTo(n)) // the original is untouched
+	}
+}
+
+func TestVectorVersionsAreIndependent(t *testing.T) {
+	base := persistent.NewVector(upTo(40)...)
+	// Two appends to the same version must not share a tail slot.
+	a := base.Append(100)
+	b := base.Append(200)
+	if a.Get(40) != 100 || b.Get(40) != 200 {
+		t.Fatalf("branches clobbered each other: %d, %d", a.Get(40), b.Get(40))
+	}
+
+	// Appends after a Pop must not write into the popped version's tail.
+	popped := a.Pop()
+	c := popped.Append(300)
+	if a.Get(40) != 100 || c.Get(40) != 300 {
+		t.Fatalf("append after Pop clobbered the original: %d, %d", a.Get(40), c.Get(40))
+	}
+
+	// Keep every version of a growing vector and check them all at the
+	// end, across the first two trie levels.
+	versions := []persistent.Vector[int]{{}}
+	for i := range 1100 {
+		versions = append(versions, versions[len(versions)-1].Append(i))
+	}
+	changed := versions[len(versions)-1].Set(0, -1).Set(1099, -1)
+	for n, v := range versions {
+		if v.Len() != n {
+			t.Fatalf("version %d has Len %d", n, v.Len())
+		}
+		if n > 0 && (v.Get(0) != 0 || v.Get(n-1) != n-1) {
+			t.Fatalf("version %d changed: first %d, last %d", n, v.Get(0), v.Get(n-1))
+		}
+	}
+	if changed.Get(0) != -1 || changed.Get(1099) != -1 || changed.Get(500) != 500 {
+		t.Fatal("Set on the newest version lost elements")
+	}
+	checkVector(t, base, upTo(40))
+}
+
+func TestVectorAll(t *testing.T) {
+	v := persistent.NewVector(upTo(70)...)
+	var got []int
+	for i, x := range v.All() {
+		if i != x {
+			t.Fatalf("All yielded %d at index %d", x, i)
+		}
+		got = append(got, x)
+		if i == 49 {
+			break
+		}
+	}
+	if len(got) != 50 {
+		t.Fatalf("All yielded %d elements after break", len(got))
+	}
+}
+
+func TestVectorPanics(t *testing.T) {
+	v := persistent.NewVector(1, 2)
+	for name, f := range map[string]func(){
+		"Get(-1)":   func() { v.Get(-1) },
+		"Get(2)":    func() { v.Get(2) },
+		"Set(2)":    func() { v.Set(2, 0) },
+		"empty Pop": func() { persistent.Vector[int]{}.Pop() },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func BenchmarkVectorAppend(b *testing.B) {
+	for range b.N {
+		var v persistent.Vector[int]
+		for i := range 1024 {
+			v = v.Append(i)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package pipeline builds typed channel pipelines from composable,
+// context-aware stages, generalising the producer, fan-out and fan-in
+// patterns that the channels and select chapters write by hand.
+//
+// A Pipeline owns every goroutine its stages start. The first stage error
+// or panic cancels the pipeline's context, every stage stops at its next
+// send or receive, and Wait returns that error once all goroutines have
+// exited, so a failed pipeline never leaks goroutines:
+//
+//	p := pipeline.New(ctx)
+//	urls := pipeline.From(p, list...)
+//	pages := pipeline.OrderedFanOut(p, urls, 8, fetch)
+//	batches := pipeline.Batch(p, pages, 100, time.Second)
+//	err := pipeline.ForEach(p, batches, store)
+//
+// Stages take the Pipeline and their input channels and return output
+// channels, which they close when done:
+//
+//   - sources: From, FromSeq, Generate
+//   - transforms: Map, Filter, FlatMap, Batch, Throttle
+//   - parallelism: FanOut (unordered), OrderedFanOut (order-preserving)
+//   - topology: Tee, Merge
+//   - sinks: ForEach, Collect, Drain
+//
+// A consumer that stops reading early must cancel the pipeline with Stop,
+// or cancel the parent context, before calling Wait.
+package pipeline
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// FanOut applies fn to values on n goroutines. Results are emitted in
+// completion order; use OrderedFanOut to keep input order.
+func FanOut[T, U any](p *Pipeline, in <-chan T, n int, fn func(ctx context.Context, v T) (U, error)) <-chan U {
+	outs := make([]<-chan U, max(n, 1))
+	for i := range outs {
+		outs[i] = Map(p, in, fn)
+	}
+	return Merge(p, outs...)
+}
+
+// OrderedFanOut applies fn to values on n goroutines and emits results in
+// input order. At most n values are in progress or waiting to be emitted,
+// so one slow value holds back at most n-1 others.
+func OrderedFanOut[T, U any](p *Pipeline, in <-chan T, n int, fn func(ctx context.Context, v T) (U, error)) <-chan U {
+	n = max(n, 1)
+	type job struct {
+		v    T
+		slot chan U
+	}
+	jobs := make(chan job)
+	// pending holds each value's result slot in input order; its
+	// capacity bounds how far workers can run ahead of the emitter.
+	pending := make(chan chan U, n)
+	out := makeChan[U](p)
+
+	p.Go(func(ctx context.Context) error {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			slot := make(chan U, 1)
+			if !send(ctx, pending, slot) || !send(ctx, jobs, job{v, slot}) {
+				return nil
+			}
+		}
+	})
+	for range n {
+		p.Go(func(ctx context.Context) error {
+			for j := range jobs {
+				u, err := fn(ctx, j.v)
+				if err != nil {
+					return err
+				}
+				j.slot <- u
+			}
+			return nil
+		})
+	}
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for {
+			slot, ok := recv(ctx, pending)
+			if !ok {
+				return nil
+			}
+			u, ok := recv(ctx, slot)
+			if !ok || !send(ctx, out, u) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Merge emits the values of all ins in arrival order and closes when all
+// of them have closed.
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := makeChan[T](p)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		p.Go(func(ctx context.Context) error {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	p.Go(func(context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// Tee copies every value of in to n outputs. Each value is delivered to
+// all outputs before the next is read, so the slowest reader sets the
+// pace; every output must be consumed.
+func Tee[T any](p *Pipeline, in <-chan T, n int) []<-chan T {
+	outs := make([]chan T, n)
+	views := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = makeChan[T](p)
+		views[i] = outs[i]
+	}
+	p.Go(func(ctx context.Context) error {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			for _, out := range outs {
+				if !send(ctx, out, v) {
+					return nil
+				}
+			}
+		}
+	})
+	return views
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrStopped is the cause recorded when Stop cancels a pipeline. Wait
+// does not report it as an error.
+var ErrStopped = errors.New("pipeline: stopped")
+
+type options struct {
+	buffer int
+}
+
+// Option configures a Pipeline.
+type Option func(*options)
+
+// WithBuffer sets the buffer size of every channel the pipeline's stages
+// create. The default is unbuffered.
+func WithBuffer(n int) Option {
+	return func(o *options) {
+		o.buffer = max(n, 0)
+	}
+}
+
+// Pipeline tracks the goroutines of a set of connected stages and cancels
+// them all on the first error.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	opts   options
+	wg     sync.WaitGroup
+}
+
+// New returns a pipeline whose context is derived from ctx.
+func New(ctx context.Context, opts ...Option) *Pipeline {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel, opts: o}
+}
+
+// Context returns the context stages run under. It is cancelled on the
+// first error, on Stop, and when Wait returns.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Go runs fn in a goroutine owned by the pipeline. A non-nil error or a
+// panic cancels the pipeline.
+func (p *Pipeline) Go(fn func(ctx context.Context) error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				p.cancel(fmt.Errorf("pipeline: stage panicked: %v", r))
+			}
+		}()
+		if err := fn(p.ctx); err != nil {
+			p.cancel(err)
+		}
+	}()
+}
+
+// Stop cancels the pipeline without an error, for consumers that have
+// seen enough.
+func (p *Pipeline) Stop() {
+	p.cancel(ErrStopped)
+}
+
+// Wait blocks until every goroutine has exited and returns the error that
+// cancelled the pipeline, if any.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	err := context.Cause(p.ctx)
+	p.cancel(nil)
+	if errors.Is(err, ErrStopped) {
+		return nil
+	}
+	return err
+}
+
+func makeChan[T any](p *Pipeline) chan T {
+	return make(chan T, p.opts.buffer)
+}
+
+// send delivers v unless ctx ends first.
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv receives from in unless ctx ends first. ok is false when in is
+// closed or ctx has ended.
+func recv[T any](ctx context.Context, in <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-in:
+		return v, ok
+	case <-ctx.Done():
+		return v, false
+	}
+}
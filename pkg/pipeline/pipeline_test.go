@@ -0,0 +1,149 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/leakcheck"
+	"github.com/thanhnamdk2710/go-handbook/pkg/pipeline"
+)
+
+func TestMain(m *testing.M) {
+	leakcheck.VerifyTestMain(m)
+}
+
+var errBoom = errors.New("boom")
+
+// endless emits 0, 1, 2, ... until the pipeline is cancelled.
+func endless(p *pipeline.Pipeline) <-chan int {
+	return pipeline.Generate(p, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+func TestStages(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+
+	p := pipeline.New(context.Background())
+	words := pipeline.From(p, "the", "quick", "brown", "fox", "jumps")
+	lengths := pipeline.OrderedFanOut(p, words, 4, func(_ context.Context, w string) (int, error) {
+		time.Sleep(time.Duration(6-len(w)) * time.Millisecond)
+		return len(w), nil
+	})
+	long := pipeline.Filter(p, lengths, func(n int) bool { return n > 3 })
+	got, err := pipeline.Collect(p, pipeline.Batch(p, long, 2, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !slices.Equal(got[0], []int{5, 5}) || !slices.Equal(got[1], []int{5}) {
+		t.Fatalf("batches = %v, want [[5 5] [5]]", got)
+	}
+}
+
+func TestTeeMerge(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+
+	p := pipeline.New(context.Background(), pipeline.WithBuffer(1))
+	outs := pipeline.Tee(p, pipeline.From(p, 1, 2, 3), 2)
+	tens := pipeline.Map(p, outs[1], func(_ context.Context, n int) (int, error) { return n * 10, nil })
+	got, err := pipeline.Collect(p, pipeline.Merge(p, outs[0], tens))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3, 10, 20, 30}) {
+		t.Fatalf("merged = %v", got)
+	}
+}
+
+func TestErrorCancelsEveryStage(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+
+	p := pipeline.New(context.Background())
+	checked := pipeline.FanOut(p, endless(p), 4, func(_ context.Context, n int) (int, error) {
+		if n == 100 {
+			return 0, errBoom
+		}
+		return n, nil
+	})
+	outs := pipeline.Tee(p, pipeline.Batch(p, checked, 8, time.Millisecond), 2)
+	go pipeline.Drain(p, outs[1])
+	if err := pipeline.Drain(p, outs[0]); !errors.Is(err, errBoom) {
+		t.Fatalf("Drain = %v, want errBoom", err)
+	}
+}
+
+func TestSinkErrorCancels(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+
+	p := pipeline.New(context.Background())
+	err := pipeline.ForEach(p, endless(p), func(_ context.Context, n int) error {
+		if n == 10 {
+			return errBoom
+		}
+		return nil
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("ForEach = %v, want errBoom", err)
+	}
+}
+
+func TestStopEarly(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+
+	p := pipeline.New(context.Background())
+	squares := pipeline.OrderedFanOut(p, endless(p), 8, func(_ context.Context, n int) (int, error) {
+		return n * n, nil
+	})
+	var first []int
+	for v := range squares {
+		first = append(first, v)
+		if len(first) == 5 {
+			p.Stop()
+			break
+		}
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatalf("Wait after Stop = %v, want nil", err)
+	}
+	if !slices.Equal(first, []int{0, 1, 4, 9, 16}) {
+		t.Fatalf("first squares = %v", first)
+	}
+}
+
+func TestParentContextCancel(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := pipeline.New(ctx)
+	// Nobody reads the output, so every stage is blocked on a send when
+	// the parent is cancelled.
+	pipeline.Map(p, endless(p), func(_ context.Context, n int) (int, error) {
+		return 2 * n, nil
+	})
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, want context.Canceled", err)
+	}
+}
+
+func TestPanicBecomesError(t *testing.T) {
+	defer leakcheck.Verify(t, leakcheck.IgnoreCurrent())
+
+	p := pipeline.New(context.Background())
+	out := pipeline.Map(p, pipeline.From(p, "a", "bb", "ccc"), func(_ context.Context, w string) (byte, error) {
+		return w[1], nil
+	})
+	err := pipeline.Drain(p, out)
+	if err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Fatalf("Drain = %v, want a panic error", err)
+	}
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import "context"
+
+// ForEach calls fn for each value of in on the calling goroutine, then
+// waits for the pipeline and returns its error. An error from fn cancels
+// the pipeline.
+func ForEach[T any](p *Pipeline, in <-chan T, fn func(ctx context.Context, v T) error) error {
+	ctx := p.Context()
+	for {
+		v, ok := recv(ctx, in)
+		if !ok {
+			break
+		}
+		if err := fn(ctx, v); err != nil {
+			p.cancel(err)
+			break
+		}
+	}
+	return p.Wait()
+}
+
+// Collect gathers the values of in, then waits for the pipeline. On
+// error it returns the values received before the failure.
+func Collect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var all []T
+	err := ForEach(p, in, func(_ context.Context, v T) error {
+		all = append(all, v)
+		return nil
+	})
+	return all, err
+}
+
+// Drain discards the values of in, then waits for the pipeline.
+func Drain[T any](p *Pipeline, in <-chan T) error {
+	return ForEach(p, in, func(context.Context, T) error { return nil })
+}
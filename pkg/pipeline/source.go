@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"context"
+	"iter"
+)
+
+// From emits items in order.
+func From[T any](p *Pipeline, items ...T) <-chan T {
+	return FromSeq(p, func(yield func(T) bool) {
+		for _, v := range items {
+			if !yield(v) {
+				return
+			}
+		}
+	})
+}
+
+// FromSeq emits the values of seq in order.
+func FromSeq[T any](p *Pipeline, seq iter.Seq[T]) <-chan T {
+	out := makeChan[T](p)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for v := range seq {
+			if !send(ctx, out, v) {
+				break
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Generate emits whatever fn passes to emit. emit returns the pipeline's
+// error once it is cancelled, and fn should return it.
+func Generate[T any](p *Pipeline, fn func(ctx context.Context, emit func(T) error) error) <-chan T {
+	out := makeChan[T](p)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		return fn(ctx, func(v T) error {
+			if !send(ctx, out, v) {
+				return context.Cause(ctx)
+			}
+			return nil
+		})
+	})
+	return out
+}
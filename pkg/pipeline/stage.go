@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit"
+)
+
+// Map applies fn to each value in order.
+func Map[T, U any](p *Pipeline, in <-chan T, fn func(ctx context.Context, v T) (U, error)) <-chan U {
+	return FlatMap(p, in, func(ctx context.Context, v T) ([]U, error) {
+		u, err := fn(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		return []U{u}, nil
+	})
+}
+
+// Filter passes on the values for which keep returns true.
+func Filter[T any](p *Pipeline, in <-chan T, keep func(v T) bool) <-chan T {
+	return FlatMap(p, in, func(_ context.Context, v T) ([]T, error) {
+		if keep(v) {
+			return []T{v}, nil
+		}
+		return nil, nil
+	})
+}
+
+// FlatMap applies fn to each value and emits every value it returns.
+func FlatMap[T, U any](p *Pipeline, in <-chan T, fn func(ctx context.Context, v T) ([]U, error)) <-chan U {
+	out := makeChan[U](p)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			us, err := fn(ctx, v)
+			if err != nil {
+				return err
+			}
+			for _, u := range us {
+				if !send(ctx, out, u) {
+					return nil
+				}
+			}
+		}
+	})
+	return out
+}
+
+// Batch groups values into slices of up to size, emitting a partial batch
+// once maxWait has passed since its first value or when in closes. A
+// non-positive maxWait only emits full batches and the final remainder.
+func Batch[T any](p *Pipeline, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	size = max(size, 1)
+	out := makeChan[[]T](p)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+
+		var (
+			batch    []T
+			timer    *time.Timer
+			deadline <-chan time.Time
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				deadline = nil
+			}
+			b := batch
+			batch = nil
+			return len(b) == 0 || send(ctx, out, b)
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return nil
+				}
+				if len(batch) == 0 && maxWait > 0 {
+					if timer == nil {
+						timer = time.NewTimer(maxWait)
+					} else {
+						timer.Reset(maxWait)
+					}
+					deadline = timer.C
+				}
+				batch = append(batch, v)
+				if len(batch) == size && !flush() {
+					return nil
+				}
+			case <-deadline:
+				deadline = nil
+				if !flush() {
+					return nil
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Throttle passes values on no faster than lim allows. An error from the
+// limiter, such as a deadline that cannot be met, fails the pipeline.
+func Throttle[T any](p *Pipeline, in <-chan T, lim *ratelimit.Limiter) <-chan T {
+	return FlatMap(p, in, func(ctx context.Context, v T) ([]T, error) {
+		if err := lim.Wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				// Cancelled elsewhere; that error is already recorded.
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []T{v}, nil
+	})
+}
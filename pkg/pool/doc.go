@@ -0,0 +1,24 @@
+// Package pool runs jobs on an autoscaling set of worker goroutines.
+//
+// It unifies the Pool, DynamicPool, PriorityPool and RateLimitedPool
+// fragments from the worker pools chapter:
+//
+//   - Submit returns a Future for the job's result
+//   - the queue is bounded, and a full queue blocks, drops or runs the
+//     job on the caller's goroutine depending on the Backpressure policy
+//   - High priority jobs are always dequeued before Normal and Low ones
+//   - workers scale between a minimum and maximum based on how long jobs
+//     wait in the queue, and idle workers above the minimum exit
+//   - Shutdown stops intake and drains queued jobs
+//   - Stats reports PoolStats at any time
+//
+// Usage:
+//
+//	p := pool.New(func(ctx context.Context, url string) (int, error) {
+//	    return fetch(ctx, url)
+//	}, pool.WithWorkers(2, 16), pool.WithQueueSize(100))
+//	defer p.Shutdown(context.Background())
+//
+//	f, err := p.Submit(ctx, pool.Job[string]{Payload: "https://go.dev"})
+//	status, err := f.Wait(ctx)
+package pool
@@ -0,0 +1,36 @@
+package pool
+
+import "context"
+
+// Future is the pending result of a submitted job.
+type Future[R any] struct {
+	done   chan struct{}
+	result R
+	err    error
+}
+
+func newFuture[R any]() *Future[R] {
+	return &Future[R]{done: make(chan struct{})}
+}
+
+func (f *Future[R]) resolve(result R, err error) {
+	f.result, f.err = result, err
+	close(f.done)
+}
+
+// Done is closed when the result is available.
+func (f *Future[R]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the job finishes or ctx ends. Abandoning the wait
+// does not cancel the job; cancel the context passed to Submit for that.
+func (f *Future[R]) Wait(ctx context.Context) (R, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		var zero R
+		return zero, ctx.Err()
+	}
+}
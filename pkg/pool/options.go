@@ -0,0 +1,89 @@
+package pool
+
+import "time"
+
+// Backpressure decides what Submit does when the queue is full.
+type Backpressure int
+
+const (
+	// Block waits for room in the queue or for the context to end.
+	Block Backpressure = iota
+	// Drop rejects the job with ErrQueueFull.
+	Drop
+	// CallerRuns runs the job on the submitting goroutine, which slows
+	// producers down naturally.
+	CallerRuns
+)
+
+// Priority orders jobs in the queue. Jobs of equal priority run in the
+// order they were submitted.
+type Priority int
+
+const (
+	// Normal is the default priority of a Job.
+	Normal Priority = iota
+	// High jobs are dequeued before any Normal or Low job.
+	High
+	// Low jobs are dequeued only when no High or Normal job is queued.
+	Low
+)
+
+type options struct {
+	minWorkers    int
+	maxWorkers    int
+	queueSize     int
+	backpressure  Backpressure
+	targetLatency time.Duration
+	scaleInterval time.Duration
+	idleTimeout   time.Duration
+	reportEvery   time.Duration
+	report        func(PoolStats)
+}
+
+// Option configures a Pool.
+type Option func(*options)
+
+// WithWorkers sets the minimum and maximum number of workers.
+// The default is 1 to runtime.NumCPU().
+func WithWorkers(min, max int) Option {
+	return func(o *options) {
+		o.minWorkers, o.maxWorkers = min, max
+	}
+}
+
+// WithQueueSize bounds the number of queued jobs across all priorities.
+func WithQueueSize(n int) Option {
+	return func(o *options) {
+		o.queueSize = n
+	}
+}
+
+// WithBackpressure sets the policy for a full queue. The default is Block.
+func WithBackpressure(b Backpressure) Option {
+	return func(o *options) {
+		o.backpressure = b
+	}
+}
+
+// WithTargetLatency adds workers while the oldest queued job has waited
+// longer than d. The queue is checked every interval.
+func WithTargetLatency(d, interval time.Duration) Option {
+	return func(o *options) {
+		o.targetLatency, o.scaleInterval = d, interval
+	}
+}
+
+// WithIdleTimeout stops workers above the minimum after d without work.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// WithStatsReporter calls report with fresh statistics every interval
+// until the pool shuts down.
+func WithStatsReporter(interval time.Duration, report func(PoolStats)) Option {
+	return func(o *options) {
+		o.reportEvery, o.report = interval, report
+	}
+}
@@ -0,0 +1,382 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+var (
+	// ErrQueueFull is returned by Submit under the Drop policy.
+	ErrQueueFull = errors.New("pool: queue full")
+	// ErrClosed is returned by Submit after Shutdown.
+	ErrClosed = errors.New("pool: closed")
+)
+
+// Job is a unit of work submitted to a Pool.
+type Job[T any] struct {
+	Payload  T
+	Priority Priority
+}
+
+// Handler processes one job payload.
+type Handler[T, R any] func(ctx context.Context, payload T) (R, error)
+
+// PoolStats is a snapshot of pool activity. It extends the chapter's
+// PoolStats with failure and scaling counters.
+type PoolStats struct {
+	Workers        int
+	ActiveWorkers  int
+	QueuedJobs     int
+	CompletedJobs  int
+	FailedJobs     int
+	DroppedJobs    int
+	AverageLatency time.Duration // time spent queued
+	AverageRunTime time.Duration
+}
+
+type task[T, R any] struct {
+	ctx      context.Context
+	payload  T
+	future   *Future[R]
+	enqueued time.Time
+}
+
+// Pool runs jobs with a Handler on a bounded, autoscaling set of workers.
+type Pool[T, R any] struct {
+	handler Handler[T, R]
+	opts    options
+
+	// slots holds one token per free queue position; ready holds one
+	// token per queued task.
+	slots chan struct{}
+	ready chan struct{}
+
+	mu      sync.Mutex
+	lanes   [3]containers.Queue[*task[T, R]]
+	workers int
+	closed  bool
+
+	active    atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+	waitTotal atomic.Int64
+	runTotal  atomic.Int64
+
+	ctx    context.Context // cancelled to abort running jobs
+	cancel context.CancelFunc
+	stop   chan struct{} // closed when intake stops
+	wg     sync.WaitGroup
+	bg     sync.WaitGroup
+}
+
+// New creates a pool and starts its minimum number of workers.
+func New[T, R any](handler Handler[T, R], opts ...Option) *Pool[T, R] {
+	o := options{
+		minWorkers:  1,
+		maxWorkers:  runtime.NumCPU(),
+		queueSize:   1024,
+		idleTimeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.minWorkers = max(o.minWorkers, 1)
+	o.maxWorkers = max(o.maxWorkers, o.minWorkers)
+	o.queueSize = max(o.queueSize, 1)
+	if o.targetLatency > 0 && o.scaleInterval <= 0 {
+		o.scaleInterval = o.targetLatency
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[T, R]{
+		handler: handler,
+		opts:    o,
+		slots:   make(chan struct{}, o.queueSize),
+		ready:   make(chan struct{}, o.queueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		stop:    make(chan struct{}),
+	}
+	for range o.queueSize {
+		p.slots <- struct{}{}
+	}
+
+	p.mu.Lock()
+	for range o.minWorkers {
+		p.spawnLocked()
+	}
+	p.mu.Unlock()
+
+	if o.targetLatency > 0 {
+		p.background(o.scaleInterval, p.autoscale)
+	}
+	if o.report != nil && o.reportEvery > 0 {
+		p.background(o.reportEvery, func() { o.report(p.Stats()) })
+	}
+	return p
+}
+
+// Submit queues a job. The job's handler receives ctx, so cancelling it
+// cancels the job whether it is queued or running.
+func (p *Pool[T, R]) Submit(ctx context.Context, job Job[T]) (*Future[R], error) {
+	select {
+	case <-p.stop:
+		return nil, ErrClosed
+	default:
+	}
+
+	select {
+	case <-p.slots:
+	default:
+		switch p.opts.backpressure {
+		case Drop:
+			p.dropped.Add(1)
+			return nil, ErrQueueFull
+		case CallerRuns:
+			f := newFuture[R]()
+			p.run(&task[T, R]{ctx: ctx, payload: job.Payload, future: f, enqueued: time.Now()})
+			return f, nil
+		}
+		select {
+		case <-p.slots:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-p.stop:
+			return nil, ErrClosed
+		}
+	}
+
+	t := &task[T, R]{ctx: ctx, payload: job.Payload, future: newFuture[R](), enqueued: time.Now()}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.slots <- struct{}{}
+		return nil, ErrClosed
+	}
+	p.lanes[lane(job.Priority)].Enqueue(t)
+	// Signalling under the lock guarantees draining workers see every
+	// task queued before Shutdown. It never blocks: ready has room for a
+	// full queue.
+	p.ready <- struct{}{}
+	p.mu.Unlock()
+	return t.future, nil
+}
+
+// Stats returns a snapshot of pool activity.
+func (p *Pool[T, R]) Stats() PoolStats {
+	p.mu.Lock()
+	workers := p.workers
+	queued := 0
+	for i := range p.lanes {
+		queued += p.lanes[i].Len()
+	}
+	p.mu.Unlock()
+
+	completed := p.completed.Load()
+	failed := p.failed.Load()
+	st := PoolStats{
+		Workers:       workers,
+		ActiveWorkers: int(p.active.Load()),
+		QueuedJobs:    queued,
+		CompletedJobs: int(completed),
+		FailedJobs:    int(failed),
+		DroppedJobs:   int(p.dropped.Load()),
+	}
+	if finished := completed + failed; finished > 0 {
+		st.AverageLatency = time.Duration(p.waitTotal.Load() / finished)
+		st.AverageRunTime = time.Duration(p.runTotal.Load() / finished)
+	}
+	return st
+}
+
+// Shutdown stops accepting jobs and waits for queued and running jobs to
+// finish. If ctx ends first, running and remaining queued jobs see a
+// cancelled context, and ctx's error is returned once workers exit.
+func (p *Pool[T, R]) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.stop)
+	}
+	p.mu.Unlock()
+	p.bg.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func lane(pr Priority) int {
+	switch pr {
+	case High:
+		return 0
+	case Low:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// spawnLocked starts a worker. The caller holds p.mu.
+func (p *Pool[T, R]) spawnLocked() {
+	p.workers++
+	p.wg.Add(1)
+	go p.worker()
+}
+
+func (p *Pool[T, R]) worker() {
+	defer p.wg.Done()
+
+	idle := time.NewTimer(p.opts.idleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-p.ready:
+			if t := p.dequeue(); t != nil {
+				p.run(t)
+			}
+		case <-p.stop:
+			// Drain whatever is still queued, then exit.
+			for {
+				select {
+				case <-p.ready:
+					if t := p.dequeue(); t != nil {
+						p.run(t)
+					}
+					continue
+				default:
+				}
+				p.mu.Lock()
+				p.workers--
+				p.mu.Unlock()
+				return
+			}
+		case <-idle.C:
+			p.mu.Lock()
+			if p.workers > p.opts.minWorkers {
+				p.workers--
+				p.mu.Unlock()
+				return
+			}
+			p.mu.Unlock()
+		}
+		idle.Reset(p.opts.idleTimeout)
+	}
+}
+
+// dequeue pops the highest-priority task and frees its queue slot.
+func (p *Pool[T, R]) dequeue() *task[T, R] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.lanes {
+		if t, ok := p.lanes[i].Dequeue(); ok {
+			p.slots <- struct{}{}
+			return t
+		}
+	}
+	return nil
+}
+
+func (p *Pool[T, R]) run(t *task[T, R]) {
+	start := time.Now()
+	p.waitTotal.Add(int64(start.Sub(t.enqueued)))
+	p.active.Add(1)
+	defer p.active.Add(-1)
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer func() {
+		stop()
+		cancel()
+	}()
+
+	var (
+		result R
+		err    error
+	)
+	if err = ctx.Err(); err == nil {
+		result, err = p.call(ctx, t.payload)
+	}
+	p.runTotal.Add(int64(time.Since(start)))
+	if err != nil {
+		p.failed.Add(1)
+	} else {
+		p.completed.Add(1)
+	}
+	t.future.resolve(result, err)
+}
+
+// call runs the handler and turns a panic into an error so one bad job
+// cannot take down the pool.
+func (p *Pool[T, R]) call(ctx context.Context, payload T) (result R, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pool: job panicked: %v", r)
+		}
+	}()
+	return p.handler(ctx, payload)
+}
+
+// autoscale adds workers while the oldest queued job has waited longer
+// than the target latency.
+func (p *Pool[T, R]) autoscale() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var oldest time.Time
+	queued := 0
+	for i := range p.lanes {
+		queued += p.lanes[i].Len()
+		if t, ok := p.lanes[i].Peek(); ok && (oldest.IsZero() || t.enqueued.Before(oldest)) {
+			oldest = t.enqueued
+		}
+	}
+	if queued == 0 || time.Since(oldest) < p.opts.targetLatency {
+		return
+	}
+
+	// Grow by half the backlog at a time so a burst is absorbed quickly
+	// without overshooting on a single slow job.
+	add := min(max(queued/2, 1), p.opts.maxWorkers-p.workers)
+	for range add {
+		p.spawnLocked()
+	}
+}
+
+func (p *Pool[T, R]) background(interval time.Duration, fn func()) {
+	p.bg.Add(1)
+	go func() {
+		defer p.bg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,332 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/leakcheck"
+	"github.com/thanhnamdk2710/go-handbook/pkg/pool"
+)
+
+func TestMain(m *testing.M) {
+	leakcheck.VerifyTestMain(m)
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// gated is a handler that blocks payloads starting with "gate" until
+// release is closed, and records the order payloads start in.
+type gated struct {
+	release chan struct{}
+	opened  sync.Once
+	started chan string
+
+	mu    sync.Mutex
+	order []string
+}
+
+// newGated returns a gated handler and a pool running it. Cleanup opens
+// the gate and shuts the pool down, even if the test fails.
+func newGated(t *testing.T, opts ...pool.Option) (*gated, *pool.Pool[string, string]) {
+	g := &gated{release: make(chan struct{}), started: make(chan string, 100)}
+	p := pool.New(g.handle, opts...)
+	t.Cleanup(func() {
+		g.open()
+		p.Shutdown(context.Background())
+	})
+	return g, p
+}
+
+// open lets gate jobs finish.
+func (g *gated) open() {
+	g.opened.Do(func() { close(g.release) })
+}
+
+func (g *gated) handle(ctx context.Context, payload string) (string, error) {
+	g.mu.Lock()
+	g.order = append(g.order, payload)
+	g.mu.Unlock()
+	g.started <- payload
+	if strings.HasPrefix(payload, "gate") {
+		select {
+		case <-g.release:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return strings.ToUpper(payload), nil
+}
+
+func (g *gated) ran() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.order)
+}
+
+// occupy submits a gate job and waits until a worker is running it.
+func occupy(t *testing.T, p *pool.Pool[string, string], g *gated) *pool.Future[string] {
+	t.Helper()
+	f, err := p.Submit(context.Background(), pool.Job[string]{Payload: "gate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-g.started; got != "gate" {
+		t.Fatalf("%s started before the gate", got)
+	}
+	return f
+}
+
+func submit(t *testing.T, p *pool.Pool[string, string], payload string, pr pool.Priority) *pool.Future[string] {
+	t.Helper()
+	f, err := p.Submit(context.Background(), pool.Job[string]{Payload: payload, Priority: pr})
+	if err != nil {
+		t.Fatalf("Submit(%s) = %v", payload, err)
+	}
+	return f
+}
+
+func TestPriorityLanes(t *testing.T) {
+	g, p := newGated(t, pool.WithWorkers(1, 1))
+
+	occupy(t, p, g)
+	var futures []*pool.Future[string]
+	for _, job := range []struct {
+		payload string
+		pr      pool.Priority
+	}{
+		{"low1", pool.Low}, {"normal1", pool.Normal}, {"high1", pool.High},
+		{"low2", pool.Low}, {"high2", pool.High}, {"normal2", pool.Normal},
+	} {
+		futures = append(futures, submit(t, p, job.payload, job.pr))
+	}
+	g.open()
+	for _, f := range futures {
+		f.Wait(context.Background())
+	}
+
+	want := []string{"gate", "high1", "high2", "normal1", "normal2", "low1", "low2"}
+	if got := g.ran(); !slices.Equal(got, want) {
+		t.Fatalf("ran %v, want %v", got, want)
+	}
+}
+
+func TestBackpressureDrop(t *testing.T) {
+	g, p := newGated(t, pool.WithWorkers(1, 1), pool.WithQueueSize(1), pool.WithBackpressure(pool.Drop))
+
+	occupy(t, p, g)
+	submit(t, p, "queued", pool.Normal)
+	if _, err := p.Submit(context.Background(), pool.Job[string]{Payload: "extra"}); !errors.Is(err, pool.ErrQueueFull) {
+		t.Fatalf("Submit to a full queue = %v, want ErrQueueFull", err)
+	}
+	if st := p.Stats(); st.DroppedJobs != 1 || st.QueuedJobs != 1 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestBackpressureBlock(t *testing.T) {
+	g, p := newGated(t, pool.WithWorkers(1, 1), pool.WithQueueSize(1))
+
+	occupy(t, p, g)
+	submit(t, p, "queued", pool.Normal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Submit(ctx, pool.Job[string]{Payload: "late"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Submit to a full queue = %v, want it to block until the deadline", err)
+	}
+
+	submitted := make(chan error, 1)
+	go func() {
+		_, err := p.Submit(context.Background(), pool.Job[string]{Payload: "waiting"})
+		submitted <- err
+	}()
+	select {
+	case err := <-submitted:
+		t.Fatalf("Submit returned %v with the queue still full", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	g.open()
+	if err := <-submitted; err != nil {
+		t.Fatalf("blocked Submit = %v once room was made", err)
+	}
+}
+
+func TestBackpressureCallerRuns(t *testing.T) {
+	g, p := newGated(t, pool.WithWorkers(1, 1), pool.WithQueueSize(1), pool.WithBackpressure(pool.CallerRuns))
+
+	occupy(t, p, g)
+	submit(t, p, "queued", pool.Normal)
+	f := submit(t, p, "inline", pool.Normal)
+	select {
+	case <-f.Done():
+	default:
+		t.Fatal("CallerRuns job not finished when Submit returned")
+	}
+	if v, err := f.Wait(context.Background()); v != "INLINE" || err != nil {
+		t.Fatalf("Wait = %q, %v", v, err)
+	}
+}
+
+func TestAutoscaleAndIdleShrink(t *testing.T) {
+	g, p := newGated(t,
+		pool.WithWorkers(1, 4),
+		pool.WithTargetLatency(2*time.Millisecond, 2*time.Millisecond),
+		pool.WithIdleTimeout(20*time.Millisecond),
+	)
+
+	var futures []*pool.Future[string]
+	for range 8 {
+		futures = append(futures, submit(t, p, "gate", pool.Normal))
+	}
+	waitFor(t, "the pool to grow to its maximum", func() bool { return p.Stats().ActiveWorkers == 4 })
+	if st := p.Stats(); st.Workers != 4 || st.QueuedJobs != 4 {
+		t.Fatalf("Stats at full size = %+v", st)
+	}
+
+	g.open()
+	for _, f := range futures {
+		if _, err := f.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, "idle workers to exit", func() bool { return p.Stats().Workers == 1 })
+	if st := p.Stats(); st.CompletedJobs != 8 || st.AverageLatency <= 0 {
+		t.Fatalf("Stats after the burst = %+v", st)
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	g, p := newGated(t, pool.WithWorkers(1, 1))
+
+	occupy(t, p, g)
+	var futures []*pool.Future[string]
+	for _, payload := range []string{"a", "b", "c"} {
+		futures = append(futures, submit(t, p, payload, pool.Normal))
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Shutdown(context.Background()) }()
+	waitFor(t, "intake to stop", func() bool {
+		_, err := p.Submit(context.Background(), pool.Job[string]{Payload: "late"})
+		return errors.Is(err, pool.ErrClosed)
+	})
+	g.open()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	for i, f := range futures {
+		if v, err := f.Wait(context.Background()); err != nil || v != strings.ToUpper([]string{"a", "b", "c"}[i]) {
+			t.Errorf("queued job %d = %q, %v; want it run before Shutdown returned", i, v, err)
+		}
+	}
+}
+
+func TestShutdownDeadlineCancelsJobs(t *testing.T) {
+	g, p := newGated(t, pool.WithWorkers(1, 1))
+
+	running := occupy(t, p, g)
+	queued := submit(t, p, "gate2", pool.Normal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want the deadline", err)
+	}
+	for _, f := range []*pool.Future[string]{running, queued} {
+		if _, err := f.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+			t.Errorf("job after an expired Shutdown = %v, want context.Canceled", err)
+		}
+	}
+}
+
+func TestFutureResults(t *testing.T) {
+	errBad := errors.New("bad payload")
+	p := pool.New(func(_ context.Context, n int) (int, error) {
+		switch {
+		case n < 0:
+			return 0, errBad
+		case n == 0:
+			panic("zero")
+		}
+		return n * n, nil
+	}, pool.WithWorkers(2, 2))
+	defer p.Shutdown(context.Background())
+
+	for _, tt := range []struct {
+		n, want int
+		err     string
+	}{
+		{3, 9, ""},
+		{-1, 0, errBad.Error()},
+		{0, 0, "pool: job panicked: zero"},
+	} {
+		f, err := p.Submit(context.Background(), pool.Job[int]{Payload: tt.n})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := f.Wait(context.Background())
+		if v != tt.want || (err == nil) != (tt.err == "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("job %d = %d, %v; want %d, %q", tt.n, v, err, tt.want, tt.err)
+		}
+	}
+	if st := p.Stats(); st.CompletedJobs != 1 || st.FailedJobs != 2 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	g, p := newGated(t, pool.WithWorkers(1, 1))
+
+	occupy(t, p, g)
+	ctx, cancel := context.WithCancel(context.Background())
+	f, err := p.Submit(ctx, pool.Job[string]{Payload: "never"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Abandoning a Wait leaves the job queued; cancelling its context
+	// cancels it.
+	wctx, wcancel := context.WithCancel(context.Background())
+	wcancel()
+	if _, err := f.Wait(wctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait with a cancelled context = %v", err)
+	}
+	cancel()
+	g.open()
+	if _, err := f.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled job = %v, want context.Canceled", err)
+	}
+	if slices.Contains(g.ran(), "never") {
+		t.Fatal("handler ran a job cancelled while queued")
+	}
+}
+
+func BenchmarkSubmit(b *testing.B) {
+	p := pool.New(func(_ context.Context, n int) (int, error) { return n, nil }, pool.WithWorkers(4, 4))
+	defer p.Shutdown(context.Background())
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			f, err := p.Submit(context.Background(), pool.Job[int]{Payload: 1})
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			f.Wait(context.Background())
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// NewTokenBucket returns a limiter that holds up to burst tokens, refills
+// them at rate and spends one per event. It starts full.
+func NewTokenBucket(rate Rate, burst int, opts ...Option) *Limiter {
+	return newLimiter(&tokenBucket{interval: rate.interval(), burst: max(burst, 1)}, opts)
+}
+
+type tokenBucket struct {
+	interval time.Duration
+	burst    int
+}
+
+func (b *tokenBucket) limit() int              { return b.burst }
+func (b *tokenBucket) recovery() time.Duration { return time.Duration(b.burst) * b.interval }
+
+func (b *tokenBucket) take(st *state, now time.Time, n int, maxDelay time.Duration) (time.Duration, bool) {
+	tokens := float64(b.burst)
+	if st.started {
+		elapsed := max(now.Sub(st.last), 0)
+		tokens = math.Min(tokens, st.tokens+float64(elapsed)/float64(b.interval))
+	}
+	tokens -= float64(n)
+
+	var delay time.Duration
+	if tokens < 0 {
+		delay = time.Duration(math.Ceil(-tokens * float64(b.interval)))
+	}
+	if delay > maxDelay {
+		return delay, false
+	}
+	st.started = true
+	st.tokens = tokens
+	st.last = now
+	return delay, true
+}
+
+// NewLeakyBucket returns a limiter that lets events out evenly at rate,
+// like water dripping from a bucket. Up to capacity events may be waiting
+// for their turn; Allow only succeeds when an event can go immediately.
+func NewLeakyBucket(rate Rate, capacity int, opts ...Option) *Limiter {
+	return newLimiter(&leakyBucket{interval: rate.interval(), capacity: max(capacity, 1)}, opts)
+}
+
+type leakyBucket struct {
+	interval time.Duration
+	capacity int
+}
+
+func (b *leakyBucket) limit() int              { return b.capacity }
+func (b *leakyBucket) recovery() time.Duration { return time.Duration(b.capacity) * b.interval }
+
+func (b *leakyBucket) take(st *state, now time.Time, n int, maxDelay time.Duration) (time.Duration, bool) {
+	next := st.tat
+	if next.Before(now) {
+		next = now
+	}
+	delay := next.Sub(now)
+
+	// Events already waiting, rounded up: each occupies one interval.
+	waiting := int((delay + b.interval - 1) / b.interval)
+	if overflow := waiting + n - b.capacity; overflow > 0 {
+		return time.Duration(overflow) * b.interval, false
+	}
+	if delay > maxDelay {
+		return delay, false
+	}
+	st.tat = next.Add(time.Duration(n) * b.interval)
+	return delay, true
+}
+
+// NewGCRA returns a limiter using the generic cell rate algorithm. It
+// behaves like a token bucket with the same rate and burst but stores a
+// single timestamp, which makes it cheap to keep in a shared store.
+func NewGCRA(rate Rate, burst int, opts ...Option) *Limiter {
+	return newLimiter(&gcra{interval: rate.interval(), burst: max(burst, 1)}, opts)
+}
+
+type gcra struct {
+	interval time.Duration
+	burst    int
+}
+
+func (g *gcra) limit() int              { return g.burst }
+func (g *gcra) recovery() time.Duration { return time.Duration(g.burst) * g.interval }
+
+func (g *gcra) take(st *state, now time.Time, n int, maxDelay time.Duration) (time.Duration, bool) {
+	tat := st.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(time.Duration(n) * g.interval)
+	allowAt := newTat.Add(-time.Duration(g.burst) * g.interval)
+
+	delay := max(allowAt.Sub(now), 0)
+	if delay > maxDelay {
+		return delay, false
+	}
+	st.tat = newTat
+	return delay, true
+}
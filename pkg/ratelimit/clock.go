@@ -0,0 +1,14 @@
+package ratelimit
+
+import "time"
+
+// Clock is the source of time for a Limiter.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
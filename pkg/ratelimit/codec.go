@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// stateVersion prefixes encoded state so the format can change without
+// misreading values written by older processes.
+const stateVersion = 1
+
+var errBadState = errors.New("ratelimit: malformed stored state")
+
+// encode serialises st for a Store. Times are stored as Unix nanoseconds,
+// with zero meaning the zero time.
+func (st *state) encode() []byte {
+	b := make([]byte, 0, 64+8*len(st.log))
+	b = append(b, stateVersion)
+	var flags byte
+	if st.started {
+		flags = 1
+	}
+	b = append(b, flags)
+	b = appendTime(b, st.tat)
+	b = appendTime(b, st.last)
+	b = binary.BigEndian.AppendUint64(b, math.Float64bits(st.tokens))
+	b = appendTime(b, st.window)
+	b = binary.AppendVarint(b, int64(st.count))
+	b = binary.AppendVarint(b, int64(st.prev))
+	b = binary.AppendUvarint(b, uint64(len(st.log)))
+	for _, t := range st.log {
+		b = appendTime(b, t)
+	}
+	return b
+}
+
+// decode parses a value written by encode.
+func (st *state) decode(b []byte) error {
+	d := decoder{b: b}
+	if d.byte() != stateVersion {
+		return errBadState
+	}
+	st.started = d.byte() == 1
+	st.tat = d.time()
+	st.last = d.time()
+	st.tokens = math.Float64frombits(d.uint64())
+	st.window = d.time()
+	st.count = int(d.varint())
+	st.prev = int(d.varint())
+	n := d.uvarint()
+	if d.err != nil || n > uint64(len(d.b)/8) {
+		return errBadState
+	}
+	st.log = make([]time.Time, n)
+	for i := range st.log {
+		st.log[i] = d.time()
+	}
+	return d.err
+}
+
+func appendTime(b []byte, t time.Time) []byte {
+	var ns int64
+	if !t.IsZero() {
+		ns = t.UnixNano()
+	}
+	return binary.BigEndian.AppendUint64(b, uint64(ns))
+}
+
+// decoder reads fields in order and remembers the first error.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) fail() {
+	d.err = errBadState
+	d.b = nil
+}
+
+func (d *decoder) byte() byte {
+	if len(d.b) < 1 {
+		d.fail()
+		return 0
+	}
+	v := d.b[0]
+	d.b = d.b[1:]
+	return v
+}
+
+func (d *decoder) uint64() uint64 {
+	if len(d.b) < 8 {
+		d.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint64(d.b)
+	d.b = d.b[8:]
+	return v
+}
+
+func (d *decoder) time() time.Time {
+	ns := int64(d.uint64())
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+func (d *decoder) varint() int64 {
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
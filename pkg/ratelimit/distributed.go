@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Distributed is a keyed limiter whose state lives in a Store, so every
+// process sharing the store enforces one limit per key. Each decision
+// reads the state, runs the algorithm and writes it back with
+// CompareAndSwap, retrying when another process got there first.
+type Distributed struct {
+	store  Store
+	alg    algorithm
+	clock  Clock
+	prefix string
+}
+
+// NewDistributed returns a limiter that applies proto's algorithm to
+// state kept in store. It uses proto's clock unless WithClock is given;
+// every process should agree on the time for the shared state to make
+// sense. Keys are namespaced with WithKeyPrefix.
+func NewDistributed(store Store, proto *Limiter, opts ...Option) *Distributed {
+	o := buildOptions(append([]Option{WithClock(proto.clock)}, opts...))
+	return &Distributed{store: store, alg: proto.alg, clock: o.clock, prefix: o.prefix}
+}
+
+// Allow reports whether one event for key may happen now.
+func (d *Distributed) Allow(ctx context.Context, key string) (bool, error) {
+	return d.AllowN(ctx, key, 1)
+}
+
+// AllowN reports whether n events for key may happen now.
+func (d *Distributed) AllowN(ctx context.Context, key string, n int) (bool, error) {
+	r, err := d.reserve(ctx, key, n, 0)
+	return r.OK(), err
+}
+
+// ReserveN admits n events for key as Limiter.ReserveN does.
+func (d *Distributed) ReserveN(ctx context.Context, key string, n int) (Reservation, error) {
+	return d.reserve(ctx, key, n, maxWait)
+}
+
+// Wait blocks until one event for key may happen.
+func (d *Distributed) Wait(ctx context.Context, key string) error {
+	return d.WaitN(ctx, key, 1)
+}
+
+// WaitN blocks until n events for key may happen, as Limiter.WaitN does.
+func (d *Distributed) WaitN(ctx context.Context, key string, n int) error {
+	return wait(ctx, d.clock, func(maxDelay time.Duration) (Reservation, error) {
+		return d.reserve(ctx, key, n, maxDelay)
+	})
+}
+
+func (d *Distributed) reserve(ctx context.Context, key string, n int, maxDelay time.Duration) (Reservation, error) {
+	if n > d.alg.limit() {
+		return Reservation{delay: -1}, nil
+	}
+	key = d.prefix + key
+	for {
+		if err := ctx.Err(); err != nil {
+			return Reservation{}, err
+		}
+		old, err := d.store.Get(ctx, key)
+		if err != nil {
+			return Reservation{}, fmt.Errorf("ratelimit: load %q: %w", key, err)
+		}
+		var st state
+		if old != nil {
+			if err := st.decode(old); err != nil {
+				return Reservation{}, fmt.Errorf("ratelimit: load %q: %w", key, err)
+			}
+		}
+
+		now := d.clock.Now()
+		delay, ok := d.alg.take(&st, now, n, maxDelay)
+		if !ok {
+			return Reservation{delay: delay}, nil
+		}
+		// Keep the state until the limiter would have fully recovered,
+		// counted from when the last admitted event happens.
+		ttl := delay + d.alg.recovery()
+		swapped, err := d.store.CompareAndSwap(ctx, key, old, st.encode(), ttl)
+		if err != nil {
+			return Reservation{}, fmt.Errorf("ratelimit: store %q: %w", key, err)
+		}
+		if swapped {
+			return Reservation{ok: true, delay: delay}, nil
+		}
+	}
+}
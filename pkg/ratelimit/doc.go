@@ -0,0 +1,36 @@
+// Package ratelimit implements the rate limiting algorithms discussed in
+// the rate limiting chapter behind one API, replacing the TokenBucket,
+// ClientLimiter, CleanLimiter and per-chapter RateLimiter examples from
+// the concurrency and web development chapters.
+//
+// Every Limiter supports Allow, Reserve and Wait, whichever algorithm it
+// uses:
+//
+//   - NewTokenBucket: refills continuously and allows bursts up to burst
+//   - NewLeakyBucket: spaces events evenly, queueing up to a capacity
+//   - NewFixedWindow: counts events per aligned window
+//   - NewSlidingLog: remembers every event in the trailing window
+//   - NewSlidingWindow: weights the previous window's count, an O(1)
+//     approximation of the sliding log
+//   - NewGCRA: the generic cell rate algorithm, a token bucket that
+//     stores a single timestamp
+//
+// Keyed holds one limiter per key, such as a client ID, and evicts keys
+// that have been idle long enough to have fully recovered.
+//
+// Distributed applies the same algorithms to state kept in a Store,
+// replacing the chapter's Redis-based DistributedLimiter. MemoryStore
+// serves one process, FileStore serves processes on one host, and the
+// resp subpackage provides a local RESP server and client for testing
+// multi-instance limiting without outside services.
+//
+// Time comes from a Clock so that limiters can be driven by a fake clock:
+//
+//	lim := ratelimit.NewTokenBucket(ratelimit.PerSecond(10), 20)
+//	if err := lim.Wait(ctx); err != nil {
+//	    return err
+//	}
+//
+//	clients := ratelimit.NewKeyed[string](ratelimit.NewGCRA(ratelimit.PerMinute(60), 10))
+//	mux.Handle("/", clients.Middleware(clientIP)(handler))
+package ratelimit
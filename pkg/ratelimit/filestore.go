@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// staleLock is how old a lock file must be before it is assumed to belong
+// to a crashed process and is broken.
+const staleLock = 10 * time.Second
+
+// FileStore is a Store kept in a directory, for processes on one host
+// that share a filesystem. Each key is a file named by the key's SHA-256
+// hash and holding its expiry and value. Writers serialise per key with
+// an exclusive lock file and replace values by atomic rename, so readers
+// never see a partial write.
+type FileStore struct {
+	dir   string
+	clock Clock
+}
+
+// NewFileStore returns a store in dir, creating it if needed. Only
+// WithClock applies.
+func NewFileStore(dir string, opts ...Option) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ratelimit: %w", err)
+	}
+	o := buildOptions(opts)
+	return &FileStore{dir: dir, clock: o.clock}, nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	return s.read(s.path(key))
+}
+
+// CompareAndSwap implements Store.
+func (s *FileStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	path := s.path(key)
+	unlock, err := s.lock(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	cur, err := s.read(path)
+	if err != nil {
+		return false, err
+	}
+	if (old == nil) != (cur == nil) || !bytes.Equal(cur, old) {
+		return false, nil
+	}
+
+	var expires int64
+	if ttl > 0 {
+		expires = s.clock.Now().Add(ttl).UnixNano()
+	}
+	data := binary.BigEndian.AppendUint64(nil, uint64(expires))
+	data = append(data, value...)
+	return true, s.write(path, data)
+}
+
+// DeleteExpired removes files whose values have expired. Expired values
+// are already invisible to Get; this only reclaims disk space.
+func (s *FileStore) DeleteExpired() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.Contains(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(s.dir, e.Name())
+		if v, err := s.read(path); err == nil && v == nil {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("ratelimit: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+// read returns the live value in path, or nil if it is missing or
+// expired.
+func (s *FileStore) read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: %w", err)
+	}
+	if len(data) < 8 {
+		return nil, errBadState
+	}
+	expires := int64(binary.BigEndian.Uint64(data))
+	if expires != 0 && s.clock.Now().UnixNano() >= expires {
+		return nil, nil
+	}
+	return data[8:], nil
+}
+
+func (s *FileStore) write(path string, data []byte) error {
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+	return nil
+}
+
+// lock takes the per-key lock file, polling until it is free, stale or
+// ctx ends. The file holds a random token, and a lock is only removed by
+// whoever finds its own token in it, so a holder whose lock was broken as
+// stale cannot remove the lock that replaced it, and two processes
+// breaking the same stale lock cannot both succeed.
+func (s *FileStore) lock(ctx context.Context, path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return nil, fmt.Errorf("ratelimit: %w", err)
+	}
+	token := []byte(hex.EncodeToString(buf[:]))
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = f.Write(token)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("ratelimit: %w", err)
+			}
+			return func() { removeLock(lockPath, token) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("ratelimit: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLock {
+			if owner, err := os.ReadFile(lockPath); err == nil {
+				removeLock(lockPath, owner)
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// removeLock removes the lock file at lockPath if it still holds token.
+func removeLock(lockPath string, token []byte) {
+	if cur, err := os.ReadFile(lockPath); err == nil && bytes.Equal(cur, token) {
+		os.Remove(lockPath)
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileStoreLockOwnership(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := s.path("k")
+	unlock, err := s.lock(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process broke our lock as stale and took it.
+	if err := os.WriteFile(path+".lock", []byte("someone else"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Fatalf("unlock removed a lock it did not own: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.lock(ctx, path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lock held by another process = %v, want to wait for it", err)
+	}
+}
+
+func TestFileStoreBreaksStaleLock(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockPath := s.path("k") + ".lock"
+	if err := os.WriteFile(lockPath, []byte("crashed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if ok, err := s.CompareAndSwap(ctx, "k", nil, []byte("v"), 0); !ok || err != nil {
+		t.Fatalf("CompareAndSwap behind a stale lock = %v, %v", ok, err)
+	}
+	if _, err := os.Stat(lockPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
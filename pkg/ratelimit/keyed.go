@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/internal/hashkey"
+)
+
+// Keyed keeps an independent limiter per key, all configured like a
+// prototype limiter. Idle keys are swept lazily, at most once per idle
+// timeout, by whichever call finds a sweep due, so no background
+// goroutine is needed.
+type Keyed[K comparable] struct {
+	proto     *Limiter
+	idle      time.Duration
+	shards    []keyedShard[K]
+	lastSweep atomic.Int64 // unix nanoseconds
+}
+
+type keyedShard[K comparable] struct {
+	mu      sync.Mutex
+	entries map[K]*keyedEntry
+}
+
+type keyedEntry struct {
+	lim     *Limiter
+	lastUse time.Time
+}
+
+// NewKeyed returns a Keyed limiter whose per-key limiters copy proto's
+// algorithm and clock. proto itself is never consumed.
+func NewKeyed[K comparable](proto *Limiter, opts ...Option) *Keyed[K] {
+	o := buildOptions(opts)
+	k := &Keyed[K]{
+		proto:  proto,
+		idle:   max(o.idleTimeout, proto.alg.recovery()),
+		shards: make([]keyedShard[K], o.shards),
+	}
+	for i := range k.shards {
+		k.shards[i].entries = make(map[K]*keyedEntry)
+	}
+	return k
+}
+
+// Limiter returns the limiter for key, creating it if needed.
+func (k *Keyed[K]) Limiter(key K) *Limiter {
+	now := k.proto.clock.Now()
+	if last := k.lastSweep.Load(); now.UnixNano()-last >= int64(k.idle) &&
+		k.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		k.sweep(now)
+	}
+
+	s := &k.shards[hashkey.Of(key)%uint64(len(k.shards))]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		e = &keyedEntry{lim: k.proto.clone()}
+		s.entries[key] = e
+	}
+	e.lastUse = now
+	return e.lim
+}
+
+func (k *Keyed[K]) sweep(now time.Time) {
+	for i := range k.shards {
+		s := &k.shards[i]
+		s.mu.Lock()
+		for key, e := range s.entries {
+			if now.Sub(e.lastUse) >= k.idle {
+				delete(s.entries, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Allow reports whether one event for key may happen now.
+func (k *Keyed[K]) Allow(key K) bool {
+	return k.Limiter(key).Allow()
+}
+
+// Reserve reserves one event for key.
+func (k *Keyed[K]) Reserve(key K) Reservation {
+	return k.Limiter(key).Reserve()
+}
+
+// Wait blocks until one event for key may happen.
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error {
+	return k.Limiter(key).Wait(ctx)
+}
+
+// Len returns the number of tracked keys, including idle keys that have
+// not been swept yet.
+func (k *Keyed[K]) Len() int {
+	n := 0
+	for i := range k.shards {
+		s := &k.shards[i]
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Middleware rejects requests with 429 Too Many Requests and a
+// Retry-After header once the key returned by keyFn is over its limit.
+func (k *Keyed[K]) Middleware(keyFn func(*http.Request) K) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := k.Limiter(keyFn(r)).reserve(1, 0)
+			if !res.OK() {
+				secs := int((res.Delay() + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrExceedsLimit is returned by Wait when n can never be admitted at
+// once because it is larger than the limiter's burst or limit.
+var ErrExceedsLimit = errors.New("ratelimit: n exceeds limit")
+
+// RateLimitError reports a rejected request and when to retry it.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("ratelimit: rate limit exceeded, retry after %v", e.RetryAfter)
+}
+
+// state holds everything any algorithm needs to remember. Each algorithm
+// uses only its own fields.
+type state struct {
+	tat     time.Time // theoretical arrival time (GCRA, leaky bucket)
+	last    time.Time // last refill (token bucket)
+	tokens  float64
+	window  time.Time // start of the current window
+	count   int
+	prev    int
+	log     []time.Time
+	started bool
+}
+
+// algorithm decides whether n events are admitted at now. On success it
+// updates st and reports how long the caller must wait before acting,
+// which is at most maxDelay. On failure it leaves st untouched and
+// reports when trying again could succeed.
+type algorithm interface {
+	take(st *state, now time.Time, n int, maxDelay time.Duration) (delay time.Duration, ok bool)
+	// limit is the largest n that can ever be admitted at once.
+	limit() int
+	// recovery is how long after its last use a limiter is back to its
+	// initial state.
+	recovery() time.Duration
+}
+
+// Limiter admits events according to one algorithm. It is safe for
+// concurrent use.
+type Limiter struct {
+	alg   algorithm
+	clock Clock
+
+	mu sync.Mutex
+	st state
+}
+
+func newLimiter(alg algorithm, opts []Option) *Limiter {
+	o := buildOptions(opts)
+	return &Limiter{alg: alg, clock: o.clock}
+}
+
+// clone returns a limiter with the same algorithm and clock and fresh
+// state.
+func (l *Limiter) clone() *Limiter {
+	return &Limiter{alg: l.alg, clock: l.clock}
+}
+
+// Limit returns the largest number of events admitted at once.
+func (l *Limiter) Limit() int {
+	return l.alg.limit()
+}
+
+// Allow reports whether one event may happen now.
+func (l *Limiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now.
+func (l *Limiter) AllowN(n int) bool {
+	return l.reserve(n, 0).OK()
+}
+
+// Reserve is ReserveN(1).
+func (l *Limiter) Reserve() Reservation {
+	return l.ReserveN(1)
+}
+
+// ReserveN admits n events if they can happen now. Algorithms that
+// schedule events (token bucket, leaky bucket, GCRA) admit them even if
+// they must wait, and Delay says for how long; the other algorithms only
+// admit events immediately.
+func (l *Limiter) ReserveN(n int) Reservation {
+	return l.reserve(n, maxWait)
+}
+
+const maxWait = time.Duration(1<<63 - 1)
+
+func (l *Limiter) reserve(n int, maxDelay time.Duration) Reservation {
+	if n > l.alg.limit() {
+		return Reservation{delay: -1}
+	}
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delay, ok := l.alg.take(&l.st, now, n, maxDelay)
+	return Reservation{ok: ok, delay: delay}
+}
+
+// Wait blocks until one event may happen.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen. It returns a *RateLimitError
+// without waiting if ctx's deadline would pass first, and ctx's error if
+// ctx ends while waiting.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	return wait(ctx, l.clock, func(maxDelay time.Duration) (Reservation, error) {
+		return l.reserve(n, maxDelay), nil
+	})
+}
+
+// wait retries reserve until it admits events that are due now, sleeping
+// for each reservation's delay in between.
+func wait(ctx context.Context, clock Clock, reserve func(maxDelay time.Duration) (Reservation, error)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		maxDelay := maxWait
+		if deadline, ok := ctx.Deadline(); ok {
+			maxDelay = deadline.Sub(clock.Now())
+		}
+		r, err := reserve(maxDelay)
+		if err != nil {
+			return err
+		}
+		if r.delay < 0 {
+			return ErrExceedsLimit
+		}
+		if r.OK() && r.Delay() == 0 {
+			return nil
+		}
+		if !r.OK() && r.Delay() > maxDelay {
+			return &RateLimitError{RetryAfter: r.Delay()}
+		}
+		select {
+		case <-clock.After(r.Delay()):
+			if r.OK() {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Reservation is the outcome of ReserveN.
+type Reservation struct {
+	ok    bool
+	delay time.Duration
+}
+
+// OK reports whether the events were admitted. It is false if n exceeds
+// the limiter's limit, in which case Delay is negative.
+func (r Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay is how long to wait before acting if OK, or before trying again
+// if not.
+func (r Reservation) Delay() time.Duration {
+	if !r.ok && r.delay == 0 {
+		// A rejection always carries a positive retry hint.
+		return time.Nanosecond
+	}
+	return r.delay
+}
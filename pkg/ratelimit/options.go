@@ -0,0 +1,59 @@
+package ratelimit
+
+import "time"
+
+type options struct {
+	clock       Clock
+	idleTimeout time.Duration
+	shards      int
+	prefix      string
+}
+
+// Option configures a Limiter or a Keyed limiter.
+type Option func(*options)
+
+// WithClock sets the clock. The default is the system clock.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithIdleTimeout makes Keyed evict keys unused for d. A key is never
+// evicted before its limiter has fully recovered, so eviction cannot
+// hand a client a fresh allowance early. The default is one minute.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// WithShards sets the number of lock shards used by Keyed. The default
+// is 16.
+func WithShards(n int) Option {
+	return func(o *options) {
+		o.shards = n
+	}
+}
+
+// WithKeyPrefix sets the prefix Distributed adds to keys in its Store.
+// The default is "ratelimit:".
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{
+		clock:       systemClock{},
+		idleTimeout: time.Minute,
+		shards:      16,
+		prefix:      "ratelimit:",
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.shards = max(o.shards, 1)
+	return o
+}
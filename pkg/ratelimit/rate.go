@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rate is a number of events per period.
+type Rate struct {
+	Count int
+	Per   time.Duration
+}
+
+// PerSecond returns a rate of n events per second.
+func PerSecond(n int) Rate { return Rate{Count: n, Per: time.Second} }
+
+// PerMinute returns a rate of n events per minute.
+func PerMinute(n int) Rate { return Rate{Count: n, Per: time.Minute} }
+
+// Every returns a rate of one event per interval.
+func Every(interval time.Duration) Rate { return Rate{Count: 1, Per: interval} }
+
+// interval is the time between two events at this rate.
+func (r Rate) interval() time.Duration {
+	if r.Count <= 0 || r.Per <= 0 {
+		panic(fmt.Sprintf("ratelimit: invalid rate %v", r))
+	}
+	return r.Per / time.Duration(r.Count)
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%v", r.Count, r.Per)
+}
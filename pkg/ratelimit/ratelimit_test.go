@@ -0,0 +1,302 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit"
+)
+
+// epoch is aligned to every window used below, so fixed windows start
+// at the fake clock's start.
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func allowed(l *ratelimit.Limiter, n int) int {
+	got := 0
+	for range n {
+		if l.Allow() {
+			got++
+		}
+	}
+	return got
+}
+
+func TestBurstAndRefill(t *testing.T) {
+	for name, newLimiter := range map[string]func(ratelimit.Rate, int, ...ratelimit.Option) *ratelimit.Limiter{
+		"TokenBucket": ratelimit.NewTokenBucket,
+		"GCRA":        ratelimit.NewGCRA,
+	} {
+		fc := clock.NewFake(epoch)
+		l := newLimiter(ratelimit.PerSecond(10), 3, ratelimit.WithClock(fc))
+
+		if got := allowed(l, 5); got != 3 {
+			t.Errorf("%s: allowed %d of a burst of 5, want 3", name, got)
+		}
+		fc.Advance(50 * time.Millisecond)
+		if l.Allow() {
+			t.Errorf("%s: allowed an event before a token refilled", name)
+		}
+		fc.Advance(50 * time.Millisecond)
+		if got := allowed(l, 2); got != 1 {
+			t.Errorf("%s: allowed %d after one interval, want 1", name, got)
+		}
+		// A long pause refills only up to the burst.
+		fc.Advance(time.Hour)
+		if got := allowed(l, 5); got != 3 {
+			t.Errorf("%s: allowed %d after an hour, want 3", name, got)
+		}
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	l := ratelimit.NewLeakyBucket(ratelimit.PerSecond(10), 3, ratelimit.WithClock(fc))
+
+	// Events leave one interval apart, and up to capacity may wait.
+	for i, want := range []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		if r := l.Reserve(); !r.OK() || r.Delay() != want {
+			t.Fatalf("Reserve %d = %v, %v; want a delay of %v", i, r.OK(), r.Delay(), want)
+		}
+	}
+	if r := l.Reserve(); r.OK() || r.Delay() != 100*time.Millisecond {
+		t.Fatalf("Reserve on a full bucket = %v, %v; want a retry after 100ms", r.OK(), r.Delay())
+	}
+	if l.Allow() {
+		t.Fatal("Allow succeeded with events waiting")
+	}
+
+	fc.Advance(300 * time.Millisecond)
+	if got := allowed(l, 2); got != 1 {
+		t.Fatalf("allowed %d once drained, want 1: Allow never queues", got)
+	}
+}
+
+func TestFixedWindowBoundary(t *testing.T) {
+	fc := clock.NewFake(epoch.Add(900 * time.Millisecond))
+	l := ratelimit.NewFixedWindow(3, time.Second, ratelimit.WithClock(fc))
+
+	if got := allowed(l, 4); got != 3 {
+		t.Fatalf("allowed %d in one window, want 3", got)
+	}
+	if r := l.Reserve(); r.OK() || r.Delay() != 100*time.Millisecond {
+		t.Fatalf("Reserve = %v, %v; want a retry at the next window in 100ms", r.OK(), r.Delay())
+	}
+	// The count resets at the boundary, so twice the limit passes within
+	// 100ms.
+	fc.Advance(100 * time.Millisecond)
+	if got := allowed(l, 4); got != 3 {
+		t.Fatalf("allowed %d after the boundary, want 3", got)
+	}
+}
+
+func TestSlidingLog(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	l := ratelimit.NewSlidingLog(3, time.Second, ratelimit.WithClock(fc))
+
+	for range 3 {
+		if !l.Allow() {
+			t.Fatal("event under the limit rejected")
+		}
+		fc.Advance(300 * time.Millisecond)
+	}
+	// At 900ms all three events are still inside the trailing second; the
+	// oldest leaves it at 1s.
+	if r := l.Reserve(); r.OK() || r.Delay() != 100*time.Millisecond {
+		t.Fatalf("Reserve = %v, %v; want a retry after 100ms", r.OK(), r.Delay())
+	}
+	fc.Advance(100 * time.Millisecond)
+	if got := allowed(l, 2); got != 1 {
+		t.Fatalf("allowed %d once the oldest event left the window, want 1", got)
+	}
+}
+
+func TestSlidingWindowWeighting(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	l := ratelimit.NewSlidingWindow(10, time.Second, ratelimit.WithClock(fc))
+
+	if !l.AllowN(10) {
+		t.Fatal("AllowN(10) rejected on a fresh limiter")
+	}
+	// 250ms into the next window the previous one still weighs 75%: 7.5
+	// events, leaving room for 2.
+	fc.Advance(1250 * time.Millisecond)
+	if !l.AllowN(2) {
+		t.Fatal("AllowN(2) rejected with 7.5 weighted events")
+	}
+	// A third needs the previous window to weigh at most 7, at 300ms.
+	if r := l.Reserve(); r.OK() || r.Delay() != 50*time.Millisecond {
+		t.Fatalf("Reserve = %v, %v; want a retry after 50ms", r.OK(), r.Delay())
+	}
+	fc.Advance(50 * time.Millisecond)
+	if got := allowed(l, 2); got != 1 {
+		t.Fatalf("allowed %d at 300ms, want 1", got)
+	}
+
+	// Once a whole window has passed the previous count no longer weighs.
+	fc.Advance(2 * time.Second)
+	if !l.AllowN(10) {
+		t.Fatal("AllowN(10) rejected after two idle windows")
+	}
+}
+
+func TestReserveDelay(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	l := ratelimit.NewTokenBucket(ratelimit.PerSecond(10), 2, ratelimit.WithClock(fc))
+
+	for i, want := range []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		if r := l.Reserve(); !r.OK() || r.Delay() != want {
+			t.Fatalf("Reserve %d = %v, %v; want a delay of %v", i, r.OK(), r.Delay(), want)
+		}
+	}
+	if r := l.ReserveN(3); r.OK() || r.Delay() >= 0 {
+		t.Fatalf("ReserveN over the burst = %v, %v; want a negative delay", r.OK(), r.Delay())
+	}
+	// Reservations are spent even though they were not yet due.
+	fc.Advance(200 * time.Millisecond)
+	if l.Allow() {
+		t.Fatal("Allow took a token already reserved")
+	}
+}
+
+func TestWait(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	l := ratelimit.NewTokenBucket(ratelimit.PerSecond(10), 1, ratelimit.WithClock(fc))
+	l.Allow()
+
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(context.Background()) }()
+	fc.BlockUntil(1)
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v before a token refilled", err)
+	default:
+	}
+	fc.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+
+	if err := l.WaitN(context.Background(), 2); !errors.Is(err, ratelimit.ErrExceedsLimit) {
+		t.Fatalf("WaitN over the burst = %v, want ErrExceedsLimit", err)
+	}
+}
+
+func TestWaitDeadline(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	l := ratelimit.NewTokenBucket(ratelimit.PerSecond(10), 1, ratelimit.WithClock(fc))
+	l.Allow()
+
+	ctx, cancel := fc.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var rle *ratelimit.RateLimitError
+	if err := l.Wait(ctx); !errors.As(err, &rle) || rle.RetryAfter != 100*time.Millisecond {
+		t.Fatalf("Wait past the deadline = %v, want a RateLimitError to retry after 100ms", err)
+	}
+	// The rejected wait took nothing.
+	fc.Advance(100 * time.Millisecond)
+	if !l.Allow() {
+		t.Fatal("token missing after a rejected Wait")
+	}
+}
+
+func TestWaitCancel(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	l := ratelimit.NewFixedWindow(1, time.Second, ratelimit.WithClock(fc))
+	l.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx) }()
+	fc.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Wait = %v, want context.Canceled", err)
+	}
+}
+
+func TestKeyedEvictsIdleKeys(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	proto := ratelimit.NewTokenBucket(ratelimit.PerSecond(1), 1, ratelimit.WithClock(fc))
+	k := ratelimit.NewKeyed[string](proto, ratelimit.WithIdleTimeout(time.Minute))
+
+	if !k.Allow("a") || !k.Allow("b") {
+		t.Fatal("first event for a key rejected")
+	}
+	if k.Allow("a") {
+		t.Fatal("keys do not have their own limiters")
+	}
+	if !proto.Allow() {
+		t.Fatal("Keyed consumed the prototype")
+	}
+
+	fc.Advance(30 * time.Second)
+	k.Allow("a")
+	fc.Advance(31 * time.Second)
+	k.Allow("c") // sweeps b, idle for over a minute
+	if n := k.Len(); n != 2 {
+		t.Fatalf("Len = %d after the sweep, want a and c", n)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	k := ratelimit.NewKeyed[string](ratelimit.NewTokenBucket(ratelimit.Every(1500*time.Millisecond), 1, ratelimit.WithClock(fc)))
+	h := k.Middleware(func(r *http.Request) string { return r.Header.Get("X-Client") })(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+
+	serve := func(client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Client", client)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := serve("a"); rec.Code != http.StatusNoContent {
+		t.Fatalf("first request = %d", rec.Code)
+	}
+	rec := serve("a")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", rec.Code)
+	}
+	// 1.5s rounds up to whole seconds.
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want 2", got)
+	}
+	if rec := serve("b"); rec.Code != http.StatusNoContent {
+		t.Fatalf("another client's request = %d", rec.Code)
+	}
+}
+
+func TestInvalidWindowPanics(t *testing.T) {
+	for name, newLimiter := range map[string]func(int, time.Duration, ...ratelimit.Option) *ratelimit.Limiter{
+		"FixedWindow":   ratelimit.NewFixedWindow,
+		"SlidingLog":    ratelimit.NewSlidingLog,
+		"SlidingWindow": ratelimit.NewSlidingWindow,
+	} {
+		for _, window := range []time.Duration{0, -time.Second} {
+			func() {
+				defer func() {
+					if r, _ := recover().(string); !strings.Contains(r, "invalid window") {
+						t.Errorf("%s(%v) panicked with %q, want an invalid window", name, window, r)
+					}
+				}()
+				newLimiter(10, window)
+			}()
+		}
+	}
+}
+
+func BenchmarkAllow(b *testing.B) {
+	l := ratelimit.NewGCRA(ratelimit.PerSecond(1e9), 1e6)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Allow()
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by a Client after Close.
+var ErrClosed = errors.New("resp: client closed")
+
+// Client is a ratelimit.Store that talks to a Server over one
+// connection, reconnecting after network errors. It is safe for
+// concurrent use; commands are serialised.
+type Client struct {
+	addr string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	r      *bufio.Reader
+	w      *bufio.Writer
+	closed bool
+}
+
+// Dial connects to the server at addr.
+func Dial(addr string) (*Client, error) {
+	c := &Client{addr: addr}
+	if err := c.connect(context.Background()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get implements ratelimit.Store.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	rep, err := c.do(ctx, []byte("GET"), []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	if rep.kind != '$' {
+		return nil, unexpected(rep)
+	}
+	return rep.str, nil
+}
+
+// CompareAndSwap implements ratelimit.Store using the CAS extension.
+func (c *Client) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	args := [][]byte{[]byte("CAS"), []byte(key), old, value}
+	if ttl > 0 {
+		// PX counts whole milliseconds; round up so a short TTL does not
+		// become no expiry at all.
+		ms := int64((ttl + time.Millisecond - 1) / time.Millisecond)
+		args = append(args, []byte("PX"), strconv.AppendInt(nil, ms, 10))
+	}
+	rep, err := c.do(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+	if rep.kind != ':' {
+		return false, unexpected(rep)
+	}
+	return rep.num == 1, nil
+}
+
+// Ping checks that the server is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	rep, err := c.do(ctx, []byte("PING"))
+	if err == nil && rep.kind != '+' {
+		err = unexpected(rep)
+	}
+	return err
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) do(ctx context.Context, args ...[]byte) (reply, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return reply{}, ErrClosed
+	}
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return reply{}, err
+		}
+	}
+
+	// The AfterFunc may run after a failure below has cleared c.conn.
+	conn := c.conn
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	err := writeCommand(c.w, args...)
+	var rep reply
+	if err == nil {
+		rep, err = readReply(c.r)
+	}
+	if err != nil {
+		// The connection state is unknown; start afresh next time.
+		c.conn.Close()
+		c.conn = nil
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		} else if !deadline.IsZero() && errors.Is(err, os.ErrDeadlineExceeded) {
+			// The connection's deadline can pass before ctx notices.
+			err = context.DeadlineExceeded
+		}
+		return reply{}, fmt.Errorf("resp: %w", err)
+	}
+	if rep.kind == '-' {
+		return reply{}, fmt.Errorf("resp: server error: %s", rep.str)
+	}
+	return rep, nil
+}
+
+// connect dials the server. The caller holds c.mu.
+func (c *Client) connect(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("resp: %w", err)
+	}
+	c.conn = conn
+	c.r = bufio.NewReader(conn)
+	c.w = bufio.NewWriter(conn)
+	return nil
+}
+
+func unexpected(rep reply) error {
+	return fmt.Errorf("resp: unexpected reply type %q", rep.kind)
+}
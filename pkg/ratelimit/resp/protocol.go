@@ -0,0 +1,142 @@
+// Package resp is a minimal RESP (Redis serialization protocol) server
+// and client that back a ratelimit.Distributed limiter, so multi-instance
+// limiting can be exercised on one machine without a Redis server.
+//
+//	srv := resp.NewServer()
+//	defer srv.Close()
+//
+//	store, _ := resp.Dial(srv.Addr)
+//	defer store.Close()
+//	lim := ratelimit.NewDistributed(store, ratelimit.NewGCRA(ratelimit.PerSecond(100), 20))
+//
+// The server understands PING, GET, SET (with PX), DEL, QUIT and one
+// extension, CAS key old new [PX ms], which replaces key's value if it
+// equals old; a null bulk string for old means the key must be absent.
+// Commands may be sent as RESP arrays or inline.
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxBulk bounds a single bulk string so a bad client cannot make the
+// server allocate without limit.
+const maxBulk = 1 << 20
+
+var errProtocol = errors.New("resp: protocol error")
+
+// readCommand reads one command as a list of arguments. Null bulk
+// strings are returned as nil.
+func readCommand(r *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return bytes.Fields(line), nil
+	}
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n < 0 || n > 1024 {
+		return nil, errProtocol
+	}
+	args := make([][]byte, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errProtocol
+		}
+		if args[i], err = readBulk(r, line[1:]); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// readBulk reads the payload of a bulk string whose length header has
+// already been read.
+func readBulk(r *bufio.Reader, header []byte) ([]byte, error) {
+	size, err := strconv.Atoi(string(header))
+	if err != nil || size < -1 || size > maxBulk {
+		return nil, errProtocol
+	}
+	if size == -1 {
+		return nil, nil
+	}
+	buf := make([]byte, size+2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(buf, []byte("\r\n")) {
+		return nil, errProtocol
+	}
+	return buf[:size], nil
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// reply is a decoded server reply.
+type reply struct {
+	kind byte // '+', '-', ':' or '$'
+	str  []byte
+	num  int64
+}
+
+func readReply(r *bufio.Reader) (reply, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return reply{}, err
+	}
+	if len(line) == 0 {
+		return reply{}, errProtocol
+	}
+	rep := reply{kind: line[0]}
+	switch rep.kind {
+	case '+', '-':
+		rep.str = bytes.Clone(line[1:])
+	case ':':
+		if rep.num, err = strconv.ParseInt(string(line[1:]), 10, 64); err != nil {
+			return reply{}, errProtocol
+		}
+	case '$':
+		if rep.str, err = readBulk(r, line[1:]); err != nil {
+			return reply{}, err
+		}
+	default:
+		return reply{}, errProtocol
+	}
+	return rep, nil
+}
+
+// writeCommand writes args as a RESP array, encoding nil as a null bulk
+// string.
+func writeCommand(w *bufio.Writer, args ...[]byte) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, a := range args {
+		writeBulk(w, a)
+	}
+	return w.Flush()
+}
+
+func writeBulk(w *bufio.Writer, b []byte) {
+	if b == nil {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	fmt.Fprintf(w, "$%d\r\n", len(b))
+	w.Write(b)
+	w.WriteString("\r\n")
+}
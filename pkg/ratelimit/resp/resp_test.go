@@ -0,0 +1,168 @@
+package resp_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit"
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit/resp"
+)
+
+func TestCommands(t *testing.T) {
+	srv := resp.NewServer()
+	defer srv.Close()
+	conn, err := net.Dial("tcp", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	for _, tt := range []struct{ cmd, want string }{
+		{"*1\r\n$4\r\nPING\r\n", "+PONG\r\n"},
+		{"*2\r\n$4\r\nPING\r\n$2\r\nhi\r\n", "$2\r\nhi\r\n"},
+		{"*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n", "+OK\r\n"},
+		{"*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", "$1\r\nv\r\n"},
+		{"*4\r\n$3\r\nCAS\r\n$1\r\nk\r\n$1\r\nx\r\n$1\r\ny\r\n", ":0\r\n"},
+		{"*4\r\n$3\r\nCAS\r\n$1\r\nk\r\n$1\r\nv\r\n$1\r\ny\r\n", ":1\r\n"},
+		{"*3\r\n$3\r\nDEL\r\n$1\r\nk\r\n$7\r\nmissing\r\n", ":1\r\n"},
+		{"*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", "$-1\r\n"},
+		{"*5\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n$2\r\nPX\r\n$1\r\n0\r\n", "-ERR syntax error\r\n"},
+		{"*1\r\n$4\r\nNOPE\r\n", "-ERR unknown command or wrong number of arguments for 'NOPE'\r\n"},
+		{"*1\r\n$4\r\nQUIT\r\n", "+OK\r\n"},
+	} {
+		if _, err := conn.Write([]byte(tt.cmd)); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(tt.want))
+		if _, err := io.ReadFull(r, got); err != nil || string(got) != tt.want {
+			t.Fatalf("%q = %q, %v; want %q", tt.cmd, got, err, tt.want)
+		}
+	}
+}
+
+func TestClientShortTTL(t *testing.T) {
+	fc := clock.NewFake(time.Unix(0, 0))
+	srv, err := resp.Listen("127.0.0.1:0", ratelimit.NewMemoryStore(ratelimit.WithClock(fc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	c, err := resp.Dial(srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if ok, err := c.CompareAndSwap(ctx, "k", nil, []byte("v"), 300*time.Microsecond); !ok || err != nil {
+		t.Fatalf("CompareAndSwap = %v, %v", ok, err)
+	}
+	fc.Advance(time.Millisecond)
+	if v, err := c.Get(ctx, "k"); v != nil || err != nil {
+		t.Fatalf("Get = %q, %v; want the value expired after 1ms", v, err)
+	}
+}
+
+func TestClientReconnects(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	srv, err := resp.Listen("127.0.0.1:0", store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := resp.Dial(srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	if err := c.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart the server on the same address and store.
+	srv.Close()
+	if err := c.Ping(ctx); err == nil {
+		t.Fatal("Ping succeeded with the server closed")
+	}
+	srv, err = resp.Listen(srv.Addr, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping after the server came back = %v", err)
+	}
+
+	c.Close()
+	if err := c.Ping(ctx); !errors.Is(err, resp.ErrClosed) {
+		t.Fatalf("Ping after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	// A listener that accepts but never replies.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c, err := resp.Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Ping to a silent server = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestServerCloseWithClients(t *testing.T) {
+	srv := resp.NewServer()
+	var clients []*resp.Client
+	for range 3 {
+		c, err := resp.Dial(srv.Addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		if err := c.Ping(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, c)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		srv.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close hung with open connections")
+	}
+	for _, c := range clients {
+		if err := c.Ping(context.Background()); err == nil {
+			t.Fatal("Ping succeeded after the server closed")
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit"
+)
+
+// Server is a local RESP server backed by a ratelimit.MemoryStore.
+type Server struct {
+	// Addr is the host:port the server listens on.
+	Addr string
+
+	store    *ratelimit.MemoryStore
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server on a random loopback port.
+func NewServer() *Server {
+	s, err := Listen("127.0.0.1:0", nil)
+	if err != nil {
+		panic(fmt.Sprintf("resp: failed to listen: %v", err))
+	}
+	return s
+}
+
+// Listen starts a server on addr using store, or a new MemoryStore if
+// store is nil.
+func Listen(addr string, store *ratelimit.MemoryStore) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		store = ratelimit.NewMemoryStore()
+	}
+	s := &Server{
+		Addr:     l.Addr().String(),
+		store:    store,
+		listener: l,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the server and waits for open connections to end.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			// Accepted before Close stopped the listener, but after it
+			// closed the open connections.
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.session(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (s *Server) session(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err == errProtocol {
+				w.WriteString("-ERR protocol error\r\n")
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		quit := s.exec(w, strings.ToUpper(string(args[0])), args[1:])
+		if w.Flush() != nil || quit {
+			return
+		}
+	}
+}
+
+// exec runs one command and reports whether the connection should close.
+func (s *Server) exec(w *bufio.Writer, cmd string, args [][]byte) (quit bool) {
+	switch {
+	case cmd == "PING" && len(args) == 0:
+		w.WriteString("+PONG\r\n")
+	case cmd == "PING" && len(args) == 1:
+		writeBulk(w, args[0])
+	case cmd == "QUIT":
+		w.WriteString("+OK\r\n")
+		return true
+	case cmd == "GET" && len(args) == 1:
+		v, _ := s.store.Get(context.Background(), string(args[0]))
+		writeBulk(w, v)
+	case cmd == "SET" && (len(args) == 2 || len(args) == 4):
+		ttl, ok := parseTTL(args[2:])
+		if !ok {
+			w.WriteString("-ERR syntax error\r\n")
+			break
+		}
+		s.store.Set(string(args[0]), args[1], ttl)
+		w.WriteString("+OK\r\n")
+	case cmd == "DEL" && len(args) > 0:
+		n := 0
+		for _, k := range args {
+			if s.store.Delete(string(k)) {
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case cmd == "CAS" && (len(args) == 3 || len(args) == 5):
+		ttl, ok := parseTTL(args[3:])
+		if !ok || args[2] == nil {
+			w.WriteString("-ERR syntax error\r\n")
+			break
+		}
+		swapped, _ := s.store.CompareAndSwap(context.Background(), string(args[0]), args[1], args[2], ttl)
+		if swapped {
+			w.WriteString(":1\r\n")
+		} else {
+			w.WriteString(":0\r\n")
+		}
+	default:
+		fmt.Fprintf(w, "-ERR unknown command or wrong number of arguments for '%s'\r\n", cmd)
+	}
+	return false
+}
+
+// parseTTL parses an optional "PX milliseconds" suffix.
+func parseTTL(args [][]byte) (time.Duration, bool) {
+	if len(args) == 0 {
+		return 0, true
+	}
+	if !strings.EqualFold(string(args[0]), "PX") {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
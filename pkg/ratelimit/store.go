@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store holds limiter state shared by several processes. Implementations
+// must make CompareAndSwap atomic with respect to every other client of
+// the same store.
+type Store interface {
+	// Get returns the value stored under key, or nil if there is none or
+	// it has expired.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// CompareAndSwap stores value under key if the current value equals
+	// old, where a nil old means the key must be absent. The value
+	// expires after ttl if ttl is positive. It reports whether the swap
+	// happened.
+	CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error)
+}
+
+// MemoryStore is a Store for limiters within one process, and the
+// backing store of the resp test server.
+type MemoryStore struct {
+	clock Clock
+
+	mu      sync.Mutex
+	entries map[string]storeEntry
+}
+
+type storeEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore. Only WithClock applies.
+func NewMemoryStore(opts ...Option) *MemoryStore {
+	o := buildOptions(opts)
+	return &MemoryStore{clock: o.clock, entries: make(map[string]storeEntry)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(key), nil
+}
+
+// CompareAndSwap implements Store.
+func (s *MemoryStore) CompareAndSwap(_ context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := s.load(key)
+	if (old == nil) != (cur == nil) || !bytes.Equal(cur, old) {
+		return false, nil
+	}
+	s.set(key, value, ttl)
+	return true, nil
+}
+
+// Set stores value under key unconditionally.
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(key, value, ttl)
+}
+
+// Delete removes key and reports whether it was present.
+func (s *MemoryStore) Delete(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	present := s.load(key) != nil
+	delete(s.entries, key)
+	return present
+}
+
+// set stores value. The caller must hold s.mu.
+func (s *MemoryStore) set(key string, value []byte, ttl time.Duration) {
+	e := storeEntry{value: bytes.Clone(value)}
+	if ttl > 0 {
+		e.expires = s.clock.Now().Add(ttl)
+	}
+	s.entries[key] = e
+}
+
+// load returns the live value for key. The caller must hold s.mu.
+func (s *MemoryStore) load(key string) []byte {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !e.expires.IsZero() && !s.clock.Now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e.value
+}
@@ -0,0 +1,178 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit"
+	"github.com/thanhnamdk2710/go-handbook/pkg/ratelimit/resp"
+)
+
+// backends returns, for each Store implementation, a function opening a
+// new client of one shared backend, as separate processes would.
+func backends(t *testing.T, fc *clock.Fake) map[string]func() ratelimit.Store {
+	t.Helper()
+	mem := ratelimit.NewMemoryStore(ratelimit.WithClock(fc))
+	dir := t.TempDir()
+	srv, err := resp.Listen("127.0.0.1:0", ratelimit.NewMemoryStore(ratelimit.WithClock(fc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+
+	return map[string]func() ratelimit.Store{
+		"Memory": func() ratelimit.Store { return mem },
+		"File": func() ratelimit.Store {
+			s, err := ratelimit.NewFileStore(dir, ratelimit.WithClock(fc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		"RESP": func() ratelimit.Store {
+			c, err := resp.Dial(srv.Addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { c.Close() })
+			return c
+		},
+	}
+}
+
+func TestStoreCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFake(epoch)
+	for name, open := range backends(t, fc) {
+		s := open()
+		if ok, err := s.CompareAndSwap(ctx, "k", []byte("x"), []byte("a"), 0); ok || err != nil {
+			t.Errorf("%s: swap from a missing value = %v, %v", name, ok, err)
+		}
+		if ok, err := s.CompareAndSwap(ctx, "k", nil, []byte("a"), 0); !ok || err != nil {
+			t.Errorf("%s: create = %v, %v", name, ok, err)
+		}
+		if ok, _ := s.CompareAndSwap(ctx, "k", nil, []byte("b"), 0); ok {
+			t.Errorf("%s: create over an existing value succeeded", name)
+		}
+		if ok, _ := s.CompareAndSwap(ctx, "k", []byte("a"), []byte("b"), time.Second); !ok {
+			t.Errorf("%s: swap from the current value failed", name)
+		}
+		if v, err := s.Get(ctx, "k"); string(v) != "b" || err != nil {
+			t.Errorf("%s: Get = %q, %v; want b", name, v, err)
+		}
+
+		fc.Advance(time.Second)
+		if v, err := s.Get(ctx, "k"); v != nil || err != nil {
+			t.Errorf("%s: Get after the TTL = %q, %v; want nil", name, v, err)
+		}
+		if ok, _ := s.CompareAndSwap(ctx, "k", nil, []byte("c"), 0); !ok {
+			t.Errorf("%s: expired value not treated as missing", name)
+		}
+	}
+}
+
+func TestStoreShortTTLExpires(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFake(epoch)
+	for name, open := range backends(t, fc) {
+		s := open()
+		if ok, err := s.CompareAndSwap(ctx, "short", nil, []byte("v"), 500*time.Microsecond); !ok || err != nil {
+			t.Fatalf("%s: CompareAndSwap = %v, %v", name, ok, err)
+		}
+		fc.Advance(time.Millisecond)
+		if v, _ := s.Get(ctx, "short"); v != nil {
+			t.Errorf("%s: a sub-millisecond TTL never expired", name)
+		}
+	}
+}
+
+func TestDistributedSharesOneLimit(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFake(epoch)
+	for name, open := range backends(t, fc) {
+		// Two instances, each with its own prototype and store client.
+		var instances []*ratelimit.Distributed
+		for range 2 {
+			proto := ratelimit.NewGCRA(ratelimit.PerSecond(10), 3, ratelimit.WithClock(fc))
+			instances = append(instances, ratelimit.NewDistributed(open(), proto, ratelimit.WithKeyPrefix("shared:")))
+		}
+
+		got := 0
+		for i := range 6 {
+			ok, err := instances[i%2].Allow(ctx, "user")
+			if err != nil {
+				t.Fatalf("%s: Allow = %v", name, err)
+			}
+			if ok {
+				got++
+			}
+		}
+		if got != 3 {
+			t.Errorf("%s: two instances allowed %d, want the shared burst of 3", name, got)
+		}
+		if ok, _ := instances[0].Allow(ctx, "other"); !ok {
+			t.Errorf("%s: keys share state", name)
+		}
+
+		fc.Advance(100 * time.Millisecond)
+		if ok, _ := instances[1].Allow(ctx, "user"); !ok {
+			t.Errorf("%s: no refill seen by the other instance", name)
+		}
+		if r, err := instances[0].ReserveN(ctx, "user", 1); !r.OK() || r.Delay() != 100*time.Millisecond || err != nil {
+			t.Errorf("%s: ReserveN = %v, %v, %v; want a delay of 100ms", name, r.OK(), r.Delay(), err)
+		}
+	}
+}
+
+func TestDistributedConcurrentInstances(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFake(epoch)
+	for name, open := range backends(t, fc) {
+		var admitted atomic.Int32
+		var wg sync.WaitGroup
+		for range 2 {
+			proto := ratelimit.NewFixedWindow(20, time.Minute, ratelimit.WithClock(fc))
+			d := ratelimit.NewDistributed(open(), proto)
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range 10 {
+						ok, err := d.Allow(ctx, "hot")
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						if ok {
+							admitted.Add(1)
+						}
+					}
+				}()
+			}
+		}
+		wg.Wait()
+		if n := admitted.Load(); n != 20 {
+			t.Errorf("%s: admitted %d of 80 concurrent events, want the limit of 20", name, n)
+		}
+	}
+}
+
+func TestDistributedWait(t *testing.T) {
+	fc := clock.NewFake(epoch)
+	proto := ratelimit.NewTokenBucket(ratelimit.PerSecond(10), 1, ratelimit.WithClock(fc))
+	d := ratelimit.NewDistributed(ratelimit.NewMemoryStore(ratelimit.WithClock(fc)), proto)
+	ctx := context.Background()
+	d.Allow(ctx, "k")
+
+	done := make(chan error, 1)
+	go func() { done <- d.Wait(ctx, "k") }()
+	fc.BlockUntil(1)
+	fc.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkWindow panics on a window that is not positive, as Rate does for
+// an invalid rate.
+func checkWindow(window time.Duration) time.Duration {
+	if window <= 0 {
+		panic(fmt.Sprintf("ratelimit: invalid window %v", window))
+	}
+	return window
+}
+
+// NewFixedWindow returns a limiter that admits limit events per window.
+// Windows are aligned to multiples of window since the zero time, so up
+// to twice the limit can pass around a window boundary.
+func NewFixedWindow(limit int, window time.Duration, opts ...Option) *Limiter {
+	return newLimiter(&fixedWindow{max: max(limit, 1), window: checkWindow(window)}, opts)
+}
+
+type fixedWindow struct {
+	max    int
+	window time.Duration
+}
+
+func (w *fixedWindow) limit() int              { return w.max }
+func (w *fixedWindow) recovery() time.Duration { return w.window }
+
+func (w *fixedWindow) take(st *state, now time.Time, n int, _ time.Duration) (time.Duration, bool) {
+	start := now.Truncate(w.window)
+	count := st.count
+	if !start.Equal(st.window) {
+		count = 0
+	}
+	if count+n > w.max {
+		return start.Add(w.window).Sub(now), false
+	}
+	st.window = start
+	st.count = count + n
+	return 0, true
+}
+
+// NewSlidingLog returns a limiter that admits limit events in any
+// trailing window. It is exact but stores a timestamp per admitted event.
+func NewSlidingLog(limit int, window time.Duration, opts ...Option) *Limiter {
+	return newLimiter(&slidingLog{max: max(limit, 1), window: checkWindow(window)}, opts)
+}
+
+type slidingLog struct {
+	max    int
+	window time.Duration
+}
+
+func (w *slidingLog) limit() int              { return w.max }
+func (w *slidingLog) recovery() time.Duration { return w.window }
+
+func (w *slidingLog) take(st *state, now time.Time, n int, _ time.Duration) (time.Duration, bool) {
+	cutoff := now.Add(-w.window)
+	i := 0
+	for i < len(st.log) && !st.log[i].After(cutoff) {
+		i++
+	}
+	st.log = append(st.log[:0], st.log[i:]...)
+
+	if over := len(st.log) + n - w.max; over > 0 {
+		// The over-th oldest event must leave the window first.
+		return st.log[over-1].Add(w.window).Sub(now), false
+	}
+	for range n {
+		st.log = append(st.log, now)
+	}
+	return 0, true
+}
+
+// NewSlidingWindow returns a limiter that approximates a sliding log by
+// weighting the previous fixed window's count by how much of it still
+// overlaps the trailing window.
+func NewSlidingWindow(limit int, window time.Duration, opts ...Option) *Limiter {
+	return newLimiter(&slidingWindow{max: max(limit, 1), window: checkWindow(window)}, opts)
+}
+
+type slidingWindow struct {
+	max    int
+	window time.Duration
+}
+
+func (w *slidingWindow) limit() int              { return w.max }
+func (w *slidingWindow) recovery() time.Duration { return 2 * w.window }
+
+func (w *slidingWindow) take(st *state, now time.Time, n int, _ time.Duration) (time.Duration, bool) {
+	start := now.Truncate(w.window)
+	count, prev := st.count, st.prev
+	switch {
+	case start.Equal(st.window):
+	case start.Sub(st.window) == w.window:
+		count, prev = 0, count
+	default:
+		count, prev = 0, 0
+	}
+
+	elapsed := now.Sub(start)
+	overlap := 1 - float64(elapsed)/float64(w.window)
+	if float64(prev)*overlap+float64(count+n) > float64(w.max) {
+		return w.retryAfter(start, elapsed, count, prev, n), false
+	}
+	st.window = start
+	st.count, st.prev = count+n, prev
+	return 0, true
+}
+
+// retryAfter finds when the previous window's weight has decayed enough
+// to admit n more events, or the next window if it never will in this one.
+func (w *slidingWindow) retryAfter(start time.Time, elapsed time.Duration, count, prev, n int) time.Duration {
+	room := w.max - count - n
+	if room < 0 || prev == 0 {
+		return w.window - elapsed
+	}
+	// prev * (1 - t/window) <= room  =>  t >= window * (1 - room/prev)
+	t := time.Duration(float64(w.window) * (1 - float64(room)/float64(prev)))
+	return max(t-elapsed, time.Nanosecond)
+}
@@ -0,0 +1,35 @@
+// Package respool is a generic pool of expensive, reusable resources such
+// as network connections, parsers or compiled templates. It replaces the
+// hand-rolled ResourcePool and ResourceManager of the synchronization
+// chapter, the buffer Pool of the mutex chapter and the ConnectionFactory
+// of the design patterns chapter.
+//
+// Unlike sync.Pool, a Pool owns its resources: it bounds how many exist,
+// validates them on borrow and return, retires them after an idle timeout
+// or a maximum lifetime, keeps a minimum number idle and ready, and
+// queues callers in FIFO order when every resource is in use.
+//
+//	p := respool.New(func(ctx context.Context) (net.Conn, error) {
+//	    var d net.Dialer
+//	    return d.DialContext(ctx, "tcp", addr)
+//	},
+//	    respool.WithMaxSize(16),
+//	    respool.WithMinIdle(4),
+//	    respool.WithIdleTimeout(time.Minute),
+//	    respool.WithMaxLifetime(30*time.Minute),
+//	)
+//	defer p.Close()
+//
+//	r, err := p.Get(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	defer r.Release()
+//	_, err = r.Value().Write(req)
+//	if err != nil {
+//	    r.Destroy() // do not hand a broken connection to the next caller
+//	}
+//
+// Resources that implement io.Closer are closed when they leave the pool
+// unless WithDestroy says otherwise.
+package respool
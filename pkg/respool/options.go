@@ -0,0 +1,101 @@
+package respool
+
+import (
+	"context"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+type options struct {
+	maxSize     int
+	minIdle     int
+	idleTimeout time.Duration
+	maxLifetime time.Duration
+	maintenance time.Duration
+	clock       clock.Clock
+
+	// The following hold typed functions and are asserted in New.
+	onBorrow any
+	onReturn any
+	destroy  any
+}
+
+// Option configures a Pool.
+type Option func(*options)
+
+// WithMaxSize caps the number of resources, idle and in use. The default
+// is 10.
+func WithMaxSize(n int) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// WithMinIdle keeps at least n resources idle, creating them in the
+// background when the pool starts and whenever maintenance finds fewer.
+func WithMinIdle(n int) Option {
+	return func(o *options) {
+		o.minIdle = n
+	}
+}
+
+// WithIdleTimeout destroys resources that have been idle for longer
+// than d. Zero, the default, keeps them forever.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// WithMaxLifetime destroys resources older than d when they are next
+// borrowed, returned or found idle. Zero, the default, means no limit.
+func WithMaxLifetime(d time.Duration) Option {
+	return func(o *options) {
+		o.maxLifetime = d
+	}
+}
+
+// WithMaintenanceInterval sets how often idle resources are checked for
+// expiry and the minimum idle count is restored. The default is 30
+// seconds, which is also used when d is not positive; maintenance only
+// runs when a timeout, lifetime or minimum idle count is set.
+func WithMaintenanceInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.maintenance = d
+	}
+}
+
+// WithCheckOnBorrow validates an idle resource before Get hands it out, for
+// example by pinging a connection. Resources that fail are destroyed and
+// Get tries another.
+func WithCheckOnBorrow[T any](fn func(ctx context.Context, v T) error) Option {
+	return func(o *options) {
+		o.onBorrow = fn
+	}
+}
+
+// WithCheckOnReturn validates a resource on Release, for example to
+// reject a parser left in an error state. Resources that fail are
+// destroyed.
+func WithCheckOnReturn[T any](fn func(v T) error) Option {
+	return func(o *options) {
+		o.onReturn = fn
+	}
+}
+
+// WithDestroy sets how resources are disposed of. The default calls
+// Close on resources that implement io.Closer.
+func WithDestroy[T any](fn func(v T)) Option {
+	return func(o *options) {
+		o.destroy = fn
+	}
+}
+
+// WithClock sets the clock used for timeouts, lifetimes and maintenance.
+// The default is clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
@@ -0,0 +1,464 @@
+package respool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+// ErrClosed is returned by Get after Close.
+var ErrClosed = errors.New("respool: closed")
+
+// Factory creates a resource.
+type Factory[T any] func(ctx context.Context) (T, error)
+
+// Stats is a snapshot of pool activity.
+type Stats struct {
+	MaxSize int
+	Open    int // idle plus in use, including resources being created
+	Idle    int
+	InUse   int
+	Waiting int
+
+	Created            int
+	CreateFailures     int
+	Destroyed          int
+	Expired            int // destroyed for idle timeout or max lifetime
+	ValidationFailures int
+	Borrowed           int
+	Waited             int // Gets that had to queue
+	WaitTime           time.Duration
+	Canceled           int // queued Gets whose context ended
+}
+
+// Pool holds up to a fixed number of resources of type T. It is safe for
+// concurrent use.
+type Pool[T any] struct {
+	factory  Factory[T]
+	o        options
+	onBorrow func(context.Context, T) error
+	onReturn func(T) error
+	destroy  func(T)
+
+	mu      sync.Mutex
+	idle    []*entry[T] // most recently used last
+	open    int
+	waiters containers.Queue[*waiter[T]]
+	waiting int
+	closed  bool
+	stats   Stats
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type entry[T any] struct {
+	value    T
+	created  time.Time
+	lastUsed time.Time
+}
+
+// grant is what a waiter receives: an idle resource, permission to
+// create one (entry and err both nil), or an error.
+type grant[T any] struct {
+	e   *entry[T]
+	err error
+}
+
+type waiter[T any] struct {
+	ch   chan grant[T]
+	gone bool // gave up; skipped by handOff
+}
+
+// New returns a pool that creates resources with factory.
+func New[T any](factory Factory[T], opts ...Option) *Pool[T] {
+	const defaultMaintenance = 30 * time.Second
+	o := options{maxSize: 10, maintenance: defaultMaintenance, clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.maxSize = max(o.maxSize, 1)
+	if o.maintenance <= 0 {
+		o.maintenance = defaultMaintenance
+	}
+	o.minIdle = min(o.minIdle, o.maxSize)
+
+	p := &Pool[T]{
+		factory:  factory,
+		o:        o,
+		onBorrow: typed[func(context.Context, T) error](o.onBorrow, "WithCheckOnBorrow"),
+		onReturn: typed[func(T) error](o.onReturn, "WithCheckOnReturn"),
+		destroy:  typed[func(T)](o.destroy, "WithDestroy"),
+		stop:     make(chan struct{}),
+	}
+	if p.destroy == nil {
+		p.destroy = closeIfCloser[T]
+	}
+	p.stats.MaxSize = o.maxSize
+	if o.minIdle > 0 || o.idleTimeout > 0 || o.maxLifetime > 0 {
+		p.wg.Add(1)
+		go p.maintain()
+	}
+	return p
+}
+
+// Resource is a borrowed resource. Exactly one of Release or Destroy must
+// be called when the caller is done with it; later calls are no-ops.
+type Resource[T any] struct {
+	p    *Pool[T]
+	e    *entry[T]
+	once sync.Once
+}
+
+// Value returns the resource.
+func (r *Resource[T]) Value() T { return r.e.value }
+
+// CreatedAt returns when the resource was created.
+func (r *Resource[T]) CreatedAt() time.Time { return r.e.created }
+
+// Release returns the resource to the pool.
+func (r *Resource[T]) Release() {
+	r.once.Do(func() { r.p.put(r.e) })
+}
+
+// Destroy removes the resource from the pool and disposes of it, for
+// resources found broken while in use.
+func (r *Resource[T]) Destroy() {
+	r.once.Do(func() {
+		r.p.mu.Lock()
+		r.p.stats.InUse--
+		r.p.drop(r.e)
+		r.p.mu.Unlock()
+		r.p.destroy(r.e.value)
+	})
+}
+
+// Get borrows a resource, creating one if the pool is below its maximum
+// size and none is idle. When the pool is full Get waits, first come
+// first served, until a resource is returned or ctx ends.
+func (p *Pool[T]) Get(ctx context.Context) (*Resource[T], error) {
+	for {
+		e, err := p.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			// We hold a slot but no resource.
+			if e, err = p.create(ctx); err != nil {
+				return nil, err
+			}
+		} else if p.onBorrow != nil {
+			if err := p.onBorrow(ctx, e.value); err != nil {
+				p.mu.Lock()
+				p.stats.ValidationFailures++
+				p.stats.InUse--
+				p.drop(e)
+				p.mu.Unlock()
+				p.destroy(e.value)
+				continue
+			}
+		}
+		p.mu.Lock()
+		p.stats.Borrowed++
+		p.mu.Unlock()
+		return &Resource[T]{p: p, e: e}, nil
+	}
+}
+
+// Stats returns a snapshot of pool activity.
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Open, s.Idle, s.Waiting = p.open, len(p.idle), p.waiting
+	return s
+}
+
+// Close destroys idle resources and fails waiting and future Gets with
+// ErrClosed. Resources in use are destroyed when they are released.
+func (p *Pool[T]) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.open -= len(idle)
+	p.stats.Destroyed += len(idle)
+	for {
+		w, ok := p.waiters.Dequeue()
+		if !ok {
+			break
+		}
+		if !w.gone {
+			w.ch <- grant[T]{err: ErrClosed}
+		}
+	}
+	p.waiting = 0
+	p.mu.Unlock()
+
+	close(p.stop)
+	p.wg.Wait()
+	p.destroyAll(idle)
+}
+
+// acquire returns an idle entry, or nil when the caller may create one.
+func (p *Pool[T]) acquire(ctx context.Context) (*entry[T], error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrClosed
+	}
+	now := p.o.clock.Now()
+	var stale []*entry[T]
+	for len(p.idle) > 0 {
+		e := p.idle[len(p.idle)-1]
+		p.idle[len(p.idle)-1] = nil
+		p.idle = p.idle[:len(p.idle)-1]
+		if p.expired(e, now) {
+			p.stats.Expired++
+			p.drop(e)
+			stale = append(stale, e)
+			continue
+		}
+		p.stats.InUse++
+		p.mu.Unlock()
+		p.destroyAll(stale)
+		return e, nil
+	}
+	if p.open < p.o.maxSize && p.waiting == 0 {
+		p.open++
+		p.stats.InUse++
+		p.mu.Unlock()
+		p.destroyAll(stale)
+		return nil, nil
+	}
+
+	w := &waiter[T]{ch: make(chan grant[T], 1)}
+	p.waiters.Enqueue(w)
+	p.waiting++
+	p.stats.Waited++
+	p.mu.Unlock()
+	p.destroyAll(stale)
+
+	start := p.o.clock.Now()
+	select {
+	case g := <-w.ch:
+		p.mu.Lock()
+		p.stats.WaitTime += p.o.clock.Since(start)
+		p.mu.Unlock()
+		return g.e, g.err
+	case <-ctx.Done():
+		p.mu.Lock()
+		p.stats.Canceled++
+		p.stats.WaitTime += p.o.clock.Since(start)
+		select {
+		case g := <-w.ch:
+			// Granted while giving up; pass it on.
+			if g.err == nil {
+				p.stats.InUse--
+				if g.e != nil {
+					p.putLocked(g.e)
+				} else {
+					p.open--
+					p.handOffSlot()
+				}
+			}
+		default:
+			w.gone = true
+			p.waiting--
+		}
+		p.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// create fills a slot reserved by acquire.
+func (p *Pool[T]) create(ctx context.Context) (*entry[T], error) {
+	v, err := p.factory(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.stats.CreateFailures++
+		p.stats.InUse--
+		p.open--
+		p.handOffSlot()
+		return nil, fmt.Errorf("respool: create: %w", err)
+	}
+	p.stats.Created++
+	now := p.o.clock.Now()
+	return &entry[T]{value: v, created: now, lastUsed: now}, nil
+}
+
+func (p *Pool[T]) put(e *entry[T]) {
+	var err error
+	if p.onReturn != nil {
+		err = p.onReturn(e.value)
+	}
+
+	p.mu.Lock()
+	p.stats.InUse--
+	keep := !p.closed && err == nil
+	if err != nil {
+		p.stats.ValidationFailures++
+	}
+	if keep && p.expired(e, p.o.clock.Now()) {
+		p.stats.Expired++
+		keep = false
+	}
+	if keep {
+		p.putLocked(e)
+		p.mu.Unlock()
+		return
+	}
+	p.drop(e)
+	p.mu.Unlock()
+	p.destroy(e.value)
+}
+
+// putLocked hands e to the first waiter or makes it idle.
+func (p *Pool[T]) putLocked(e *entry[T]) {
+	e.lastUsed = p.o.clock.Now()
+	if w := p.nextWaiter(); w != nil {
+		p.stats.InUse++
+		w.ch <- grant[T]{e: e}
+		return
+	}
+	p.idle = append(p.idle, e)
+}
+
+// drop frees the slot of e, which is neither idle nor in use. It must be
+// called with p.mu held; the caller disposes of e after unlocking so a
+// slow Close does not block the pool.
+func (p *Pool[T]) drop(e *entry[T]) {
+	p.open--
+	p.stats.Destroyed++
+	p.handOffSlot()
+}
+
+// handOffSlot lets the first waiter create a resource in a freed slot.
+func (p *Pool[T]) handOffSlot() {
+	if p.closed || p.open >= p.o.maxSize {
+		return
+	}
+	if w := p.nextWaiter(); w != nil {
+		p.open++
+		p.stats.InUse++
+		w.ch <- grant[T]{}
+	}
+}
+
+func (p *Pool[T]) nextWaiter() *waiter[T] {
+	for {
+		w, ok := p.waiters.Dequeue()
+		if !ok {
+			return nil
+		}
+		if !w.gone {
+			p.waiting--
+			return w
+		}
+	}
+}
+
+func (p *Pool[T]) expired(e *entry[T], now time.Time) bool {
+	return (p.o.maxLifetime > 0 && now.Sub(e.created) >= p.o.maxLifetime) ||
+		(p.o.idleTimeout > 0 && now.Sub(e.lastUsed) >= p.o.idleTimeout)
+}
+
+// maintain evicts expired idle resources and tops up the idle count.
+func (p *Pool[T]) maintain() {
+	defer p.wg.Done()
+	p.refill()
+	t := p.o.clock.NewTicker(p.o.maintenance)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C():
+			p.evict()
+			p.refill()
+		}
+	}
+}
+
+func (p *Pool[T]) evict() {
+	p.mu.Lock()
+	now := p.o.clock.Now()
+	var stale []*entry[T]
+	kept := p.idle[:0]
+	for _, e := range p.idle {
+		if p.expired(e, now) {
+			p.stats.Expired++
+			p.drop(e)
+			stale = append(stale, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	clear(p.idle[len(kept):])
+	p.idle = kept
+	p.mu.Unlock()
+	p.destroyAll(stale)
+}
+
+func (p *Pool[T]) destroyAll(entries []*entry[T]) {
+	for _, e := range entries {
+		p.destroy(e.value)
+	}
+}
+
+func (p *Pool[T]) refill() {
+	for {
+		p.mu.Lock()
+		if p.closed || len(p.idle) >= p.o.minIdle || p.open >= p.o.maxSize || p.waiting > 0 {
+			p.mu.Unlock()
+			return
+		}
+		p.open++
+		p.stats.InUse++
+		p.mu.Unlock()
+
+		e, err := p.create(context.Background())
+		if err != nil {
+			return
+		}
+		p.mu.Lock()
+		p.stats.InUse--
+		if p.closed {
+			p.drop(e)
+			p.mu.Unlock()
+			p.destroy(e.value)
+			return
+		}
+		p.putLocked(e)
+		p.mu.Unlock()
+	}
+}
+
+func closeIfCloser[T any](v T) {
+	if c, ok := any(v).(io.Closer); ok {
+		c.Close()
+	}
+}
+
+func typed[F any](fn any, option string) F {
+	var zero F
+	if fn == nil {
+		return zero
+	}
+	f, ok := fn.(F)
+	if !ok {
+		panic("respool: " + option + " does not match the pool's resource type")
+	}
+	return f
+}
@@ -0,0 +1,345 @@
+package respool_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/respool"
+)
+
+// counter is a factory numbering the resources it creates from 1, and
+// announcing each on created if it is not nil.
+type counter struct {
+	n       atomic.Int32
+	created chan int
+}
+
+func (c *counter) new(context.Context) (int, error) {
+	n := int(c.n.Add(1))
+	if c.created != nil {
+		c.created <- n
+	}
+	return n, nil
+}
+
+// destroyed returns an option reporting destroyed resources on a channel.
+func destroyed() (respool.Option, chan int) {
+	ch := make(chan int, 10)
+	return respool.WithDestroy(func(v int) { ch <- v }), ch
+}
+
+// receive returns the next value on ch, failing the test if none arrives.
+func receive(t *testing.T, ch chan int, what string) int {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+		return 0
+	}
+}
+
+// waitFor blocks until p's Stats satisfy cond. The pool signals nothing
+// observable when a Get queues or maintenance finishes, so it yields
+// rather than sleeping.
+func waitFor(t *testing.T, p *respool.Pool[int], what string, cond func(respool.Stats) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond(p.Stats()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s: %+v", what, p.Stats())
+		}
+		runtime.Gosched()
+	}
+}
+
+// waitForWaiting blocks until n Gets are queued.
+func waitForWaiting(t *testing.T, p *respool.Pool[int], n int) {
+	t.Helper()
+	waitFor(t, p, "queued Gets", func(st respool.Stats) bool { return st.Waiting == n })
+}
+
+func get(t *testing.T, p *respool.Pool[int]) *respool.Resource[int] {
+	t.Helper()
+	r, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestWaitersFIFO(t *testing.T) {
+	var c counter
+	p := respool.New(c.new, respool.WithMaxSize(1))
+	defer p.Close()
+
+	held := get(t, p)
+	order := make(chan int, 3)
+	for i := range 3 {
+		go func() {
+			r, err := p.Get(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			order <- i
+			r.Release()
+		}()
+		waitForWaiting(t, p, i+1)
+	}
+	held.Release()
+	for want := range 3 {
+		if got := receive(t, order, "a waiter"); got != want {
+			t.Fatalf("waiter %d served, want %d", got, want)
+		}
+	}
+
+	st := p.Stats()
+	if st.Created != 1 || st.Borrowed != 4 || st.Waited != 3 || st.Waiting != 0 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestCancelledWaiter(t *testing.T) {
+	var c counter
+	p := respool.New(c.new, respool.WithMaxSize(1))
+	defer p.Close()
+	held := get(t, p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := p.Get(ctx)
+		cancelled <- err
+	}()
+	waitForWaiting(t, p, 1)
+	served := make(chan int, 1)
+	go func() {
+		r, err := p.Get(context.Background())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		served <- r.Value()
+		r.Release()
+	}()
+	waitForWaiting(t, p, 2)
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Get = %v", err)
+	}
+	held.Release()
+	if v := receive(t, served, "the live waiter"); v != 1 {
+		t.Fatalf("live waiter got %d, want the released resource", v)
+	}
+	if st := p.Stats(); st.Canceled != 1 || st.Waiting != 0 || st.Created != 1 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestCheckOnBorrow(t *testing.T) {
+	var c counter
+	onDestroy, gone := destroyed()
+	p := respool.New(c.new,
+		respool.WithCheckOnBorrow(func(_ context.Context, v int) error {
+			if v == 1 {
+				return errors.New("connection reset")
+			}
+			return nil
+		}),
+		onDestroy,
+	)
+	defer p.Close()
+
+	// A new resource is not checked; an idle one is.
+	r := get(t, p)
+	r.Release()
+	if r := get(t, p); r.Value() != 2 {
+		t.Fatalf("Get = %d, want a new resource replacing the broken one", r.Value())
+	}
+	if v := receive(t, gone, "the broken resource"); v != 1 {
+		t.Fatalf("destroyed %d, want 1", v)
+	}
+	if st := p.Stats(); st.ValidationFailures != 1 || st.Destroyed != 1 || st.Open != 1 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestCheckOnReturn(t *testing.T) {
+	var c counter
+	onDestroy, gone := destroyed()
+	p := respool.New(c.new,
+		respool.WithCheckOnReturn(func(int) error { return errors.New("left in a transaction") }),
+		onDestroy,
+	)
+	defer p.Close()
+
+	get(t, p).Release()
+	receive(t, gone, "the rejected resource")
+	if st := p.Stats(); st.ValidationFailures != 1 || st.Idle != 0 || st.Open != 0 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	var c counter
+	p := respool.New(c.new,
+		respool.WithIdleTimeout(time.Minute),
+		respool.WithMaintenanceInterval(time.Hour),
+		respool.WithClock(clk),
+	)
+	defer p.Close()
+
+	get(t, p).Release()
+	clk.Advance(time.Minute - time.Second)
+	r := get(t, p)
+	if r.Value() != 1 {
+		t.Fatalf("Get = %d before the idle timeout, want the idle resource", r.Value())
+	}
+	r.Release()
+
+	// Idle time counts from the last Release, not from creation.
+	clk.Advance(time.Minute)
+	if r := get(t, p); r.Value() != 2 {
+		t.Fatalf("Get = %d after the idle timeout, want a new resource", r.Value())
+	}
+	if st := p.Stats(); st.Expired != 1 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestMaxLifetime(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	var c counter
+	onDestroy, gone := destroyed()
+	p := respool.New(c.new,
+		respool.WithMaxLifetime(time.Minute),
+		respool.WithMaintenanceInterval(time.Hour),
+		respool.WithClock(clk),
+		onDestroy,
+	)
+	defer p.Close()
+
+	r := get(t, p)
+	clk.Advance(time.Minute)
+	r.Release()
+	if v := receive(t, gone, "the old resource"); v != 1 {
+		t.Fatalf("destroyed %d", v)
+	}
+	if st := p.Stats(); st.Expired != 1 || st.Idle != 0 {
+		t.Fatalf("Stats = %+v, want a resource past its lifetime destroyed on Release", st)
+	}
+}
+
+func TestMinIdle(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	c := counter{created: make(chan int, 10)}
+	p := respool.New(c.new,
+		respool.WithMaxSize(3),
+		respool.WithMinIdle(2),
+		respool.WithMaintenanceInterval(time.Second),
+		respool.WithClock(clk),
+	)
+	defer p.Close()
+
+	receive(t, c.created, "the first prewarmed resource")
+	receive(t, c.created, "the second prewarmed resource")
+	// The maintenance ticker starts once prewarming is done.
+	clk.BlockUntil(1)
+	if st := p.Stats(); st.Idle != 2 || st.Created != 2 {
+		t.Fatalf("Stats after prewarming = %+v", st)
+	}
+
+	a, b := get(t, p), get(t, p)
+	defer a.Release()
+	defer b.Release()
+	clk.Advance(time.Second)
+	// Only one more fits under the maximum size.
+	receive(t, c.created, "a topped-up resource")
+	waitFor(t, p, "the new resource to go idle", func(st respool.Stats) bool { return st.Idle == 1 })
+	if st := p.Stats(); st.Created != 3 || st.Open != 3 {
+		t.Fatalf("Stats after topping up = %+v", st)
+	}
+}
+
+func TestNonPositiveMaintenanceInterval(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		clk := clock.NewFake(time.Unix(0, 0))
+		onDestroy, gone := destroyed()
+		p := respool.New(func(context.Context) (int, error) { return 1, nil },
+			respool.WithIdleTimeout(time.Minute),
+			respool.WithMaintenanceInterval(d),
+			respool.WithClock(clk),
+			onDestroy,
+		)
+		get(t, p).Release()
+
+		// Maintenance falls back to the default 30 second interval rather
+		// than spinning or never running.
+		clk.BlockUntil(1)
+		clk.Advance(time.Minute)
+		receive(t, gone, "the idle resource to expire")
+		if st := p.Stats(); st.Expired != 1 {
+			t.Fatalf("interval %v: Stats = %+v", d, st)
+		}
+		p.Close()
+	}
+}
+
+func TestClose(t *testing.T) {
+	var c counter
+	onDestroy, gone := destroyed()
+	p := respool.New(c.new, respool.WithMaxSize(1), onDestroy)
+	held := get(t, p)
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := p.Get(context.Background())
+		waiting <- err
+	}()
+	waitForWaiting(t, p, 1)
+	p.Close()
+	if err := <-waiting; !errors.Is(err, respool.ErrClosed) {
+		t.Fatalf("queued Get at Close = %v, want ErrClosed", err)
+	}
+	if _, err := p.Get(context.Background()); !errors.Is(err, respool.ErrClosed) {
+		t.Fatalf("Get after Close = %v", err)
+	}
+	held.Release()
+	receive(t, gone, "the resource released after Close")
+}
+
+func TestCreateFailure(t *testing.T) {
+	errDown := errors.New("database down")
+	p := respool.New(func(context.Context) (int, error) { return 0, errDown })
+	defer p.Close()
+	if _, err := p.Get(context.Background()); !errors.Is(err, errDown) {
+		t.Fatalf("Get = %v, want %v", err, errDown)
+	}
+	if st := p.Stats(); st.CreateFailures != 1 || st.Open != 0 || st.InUse != 0 {
+		t.Fatalf("Stats = %+v", st)
+	}
+}
+
+func TestDestroy(t *testing.T) {
+	var c counter
+	onDestroy, gone := destroyed()
+	p := respool.New(c.new, respool.WithMaxSize(1), onDestroy)
+	defer p.Close()
+
+	r := get(t, p)
+	r.Destroy()
+	r.Release() // no-op
+	receive(t, gone, "the destroyed resource")
+	if r := get(t, p); r.Value() != 2 {
+		t.Fatalf("Get after Destroy = %d, want a new resource", r.Value())
+	}
+}
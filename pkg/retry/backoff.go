@@ -0,0 +1,50 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before retry number attempt (starting at 1),
+// given the previous delay.
+type Backoff func(attempt int, prev time.Duration) time.Duration
+
+// Constant waits d between attempts.
+func Constant(d time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration { return d }
+}
+
+// Exponential waits base, 2×base, 4×base and so on, capped at maxDelay.
+func Exponential(base, maxDelay time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < maxDelay; i++ {
+			if d > maxDelay/2 {
+				return maxDelay // doubling would pass maxDelay, or overflow
+			}
+			d *= 2
+		}
+		return min(d, maxDelay)
+	}
+}
+
+// FullJitter waits a random duration between zero and b's delay.
+func FullJitter(b Backoff) Backoff {
+	return func(attempt int, prev time.Duration) time.Duration {
+		d := b(attempt, prev)
+		if d <= 0 {
+			return 0
+		}
+		return rand.N(d + 1)
+	}
+}
+
+// DecorrelatedJitter waits a random duration between base and three times
+// the previous delay, capped at maxDelay. Successive delays grow on
+// average but are not synchronised between clients.
+func DecorrelatedJitter(base, maxDelay time.Duration) Backoff {
+	return func(_ int, prev time.Duration) time.Duration {
+		hi := max(3*prev, base)
+		return min(base+rand.N(hi-base+1), maxDelay)
+	}
+}
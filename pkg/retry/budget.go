@@ -0,0 +1,90 @@
+package retry
+
+import (
+	"sync"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+// budgetWindow is how many seconds of history a Budget considers.
+const budgetWindow = 10
+
+// Budget limits retries across many calls to a fraction of first
+// attempts, plus a small steady allowance so that rarely used clients can
+// still retry. Over any ten-second window, retries may not exceed
+// ratio × first attempts + minPerSecond × 10.
+type Budget struct {
+	ratio        float64
+	minPerSecond int
+	clock        clock.Clock
+
+	mu      sync.Mutex
+	buckets [budgetWindow]budgetBucket
+}
+
+// budgetBucket counts one second of activity.
+type budgetBucket struct {
+	second   int64
+	attempts int
+	retries  int
+}
+
+// NewBudget returns a budget allowing retries worth ratio of first
+// attempts (0.1 allows one retry per ten calls) plus minPerSecond. Only
+// WithClock applies.
+func NewBudget(ratio float64, minPerSecond int, opts ...Option) *Budget {
+	o := buildOptions(opts)
+	return &Budget{ratio: ratio, minPerSecond: minPerSecond, clock: o.clock}
+}
+
+// Deposit records a first attempt.
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current().attempts++
+}
+
+// Withdraw takes the allowance for one retry, reporting false if the
+// budget is exhausted.
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cur := b.current()
+	if b.availableLocked() < 1 {
+		return false
+	}
+	cur.retries++
+	return true
+}
+
+// Available returns the number of retries currently allowed.
+func (b *Budget) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current()
+	return b.availableLocked()
+}
+
+// current returns the bucket for this second, recycling a stale one.
+// The caller holds b.mu.
+func (b *Budget) current() *budgetBucket {
+	sec := b.clock.Now().Unix()
+	// Unix seconds are negative before 1970; keep the index in range.
+	bk := &b.buckets[(sec%budgetWindow+budgetWindow)%budgetWindow]
+	if bk.second != sec {
+		*bk = budgetBucket{second: sec}
+	}
+	return bk
+}
+
+func (b *Budget) availableLocked() int {
+	now := b.clock.Now().Unix()
+	attempts, retries := 0, 0
+	for _, bk := range b.buckets {
+		if now-bk.second < budgetWindow {
+			attempts += bk.attempts
+			retries += bk.retries
+		}
+	}
+	return int(b.ratio*float64(attempts)) + b.minPerSecond*budgetWindow - retries
+}
@@ -0,0 +1,188 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Class is the verdict on whether an error is worth retrying.
+type Class int
+
+const (
+	// Unknown errors are retried, within the attempt, time and budget
+	// limits.
+	Unknown Class = iota
+	// Retryable errors are transient.
+	Retryable
+	// Permanent errors are returned without retrying.
+	Permanent
+)
+
+// Classifier classifies errors for WithClassifier. It returns Unknown to
+// defer to the built-in rules.
+type Classifier func(err error) Class
+
+// PermanentError marks an error as not worth retrying.
+type PermanentError struct {
+	Err error
+}
+
+// MarkPermanent wraps err so that it is returned without retrying. Do
+// unwraps it again before returning.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// StatusError is an HTTP response with an unsuccessful status.
+type StatusError struct {
+	Code   int
+	Status string
+	// Header is the raw Retry-After header, if any.
+	Header string
+}
+
+// FromResponse returns a *StatusError if resp's status is not 2xx or 3xx,
+// and nil otherwise.
+func FromResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	return &StatusError{Code: resp.StatusCode, Status: resp.Status, Header: resp.Header.Get("Retry-After")}
+}
+
+func (e *StatusError) Error() string {
+	if e.Status != "" {
+		return "http status " + e.Status
+	}
+	return "http status " + strconv.Itoa(e.Code)
+}
+
+// StatusCode returns the HTTP status code.
+func (e *StatusError) StatusCode() int { return e.Code }
+
+// RetryAfterAt parses the Retry-After header, which is either a number of
+// seconds or an HTTP date, relative to now.
+func (e *StatusError) RetryAfterAt(now time.Time) (time.Duration, bool) {
+	return ParseRetryAfter(e.Header, now)
+}
+
+// ParseRetryAfter parses a Retry-After header value relative to now.
+func ParseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(secs)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// retryAfter extracts a server's hint from err.
+func retryAfter(err error, now time.Time) (time.Duration, bool) {
+	var at interface {
+		RetryAfterAt(now time.Time) (time.Duration, bool)
+	}
+	if errors.As(err, &at) {
+		return at.RetryAfterAt(now)
+	}
+	var after interface{ RetryAfter() time.Duration }
+	if errors.As(err, &after) {
+		return after.RetryAfter(), true
+	}
+	return 0, false
+}
+
+// retryableStatus lists HTTP statuses that may succeed on retry.
+var retryableStatus = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooEarly:            true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// RetryableSQLState reports whether a SQLSTATE code denotes a transient
+// failure: serialization failures and deadlocks (class 40), connection
+// exceptions (class 08), insufficient resources (class 53), operator
+// intervention such as a server shutdown (class 57) and lock timeouts
+// (55P03).
+func RetryableSQLState(state string) bool {
+	if len(state) != 5 {
+		return false
+	}
+	switch state[:2] {
+	case "40", "08", "53", "57":
+		return true
+	}
+	return state == "55P03"
+}
+
+// Classify applies the built-in rules, in order:
+//
+//   - a PermanentError or context.Canceled is Permanent
+//   - context.DeadlineExceeded is Retryable: it comes from a per-attempt
+//     timeout, since Do stops by itself once its own ctx is done
+//   - an error with a Retryable() bool method is classified by it
+//   - an error with a StatusCode() int method, such as StatusError, is
+//     Retryable for 408, 425, 429, 500, 502, 503 and 504 and Permanent
+//     for other statuses
+//   - an error with a SQLState() string method, as pgx errors have, is
+//     classified by RetryableSQLState
+//   - network errors, connection resets and refusals, and unexpected EOF
+//     are Retryable
+//   - anything else is Unknown
+func Classify(err error) Class {
+	var perm *PermanentError
+	if errors.As(err, &perm) || errors.Is(err, context.Canceled) {
+		return Permanent
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Retryable
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return verdict(r.Retryable())
+	}
+	var status interface{ StatusCode() int }
+	if errors.As(err, &status) {
+		return verdict(retryableStatus[status.StatusCode()])
+	}
+	var sqlErr interface{ SQLState() string }
+	if errors.As(err, &sqlErr) {
+		return verdict(RetryableSQLState(sqlErr.SQLState()))
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return Retryable
+	}
+	return Unknown
+}
+
+func verdict(retryable bool) Class {
+	if retryable {
+		return Retryable
+	}
+	return Permanent
+}
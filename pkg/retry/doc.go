@@ -0,0 +1,26 @@
+// Package retry runs operations again after transient failures. It
+// replaces the WithRetry/RetryOptions loop from the transactions chapter
+// and the hand-written retry loops in the timeout and HTTP client
+// chapters.
+//
+//	err := retry.Do(ctx, func(ctx context.Context) error {
+//	    return saveEvents(ctx, events)
+//	}, retry.WithMaxAttempts(5), retry.WithMaxElapsed(10*time.Second))
+//
+// Delays come from a Backoff: Constant, Exponential, or
+// DecorrelatedJitter, and Exponential can be wrapped in FullJitter to
+// spread out clients that failed together.
+//
+// Errors are classified before each retry. Permanent errors, context
+// errors and HTTP or SQL errors that retrying cannot fix are returned at
+// once; Classify lists the rules, and WithClassifier adds
+// application-specific ones. A server's Retry-After hint, carried by
+// StatusError or any error with a RetryAfter method, replaces the backoff
+// delay.
+//
+// A Budget shared by many calls caps retries to a fraction of first
+// attempts, so an outage does not multiply load on the failing service.
+//
+// All waiting goes through a clock.Clock, so tests can drive retries with
+// a clock.Fake instead of sleeping.
+package retry
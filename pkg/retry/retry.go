@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+var (
+	// ErrMaxAttempts means the attempt limit was reached.
+	ErrMaxAttempts = errors.New("retry: max attempts reached")
+	// ErrMaxElapsed means the next attempt would start after the elapsed
+	// time limit.
+	ErrMaxElapsed = errors.New("retry: max elapsed time reached")
+	// ErrBudgetExhausted means the shared Budget allowed no more retries.
+	ErrBudgetExhausted = errors.New("retry: budget exhausted")
+)
+
+// Error is returned when Do gives up on a retryable error. It matches
+// both the last error and the reason for stopping with errors.Is.
+type Error struct {
+	Attempts int
+	Last     error
+	Reason   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v after %d attempts: %v", e.Reason, e.Attempts, e.Last)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Last, e.Reason}
+}
+
+type options struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	backoff     Backoff
+	classifier  Classifier
+	budget      *Budget
+	clock       clock.Clock
+	onRetry     func(attempt int, err error, delay time.Duration)
+}
+
+// Option configures Do and NewBudget.
+type Option func(*options)
+
+// WithMaxAttempts limits the number of attempts, including the first.
+// Zero means no limit. The default is 3.
+func WithMaxAttempts(n int) Option {
+	return func(o *options) {
+		o.maxAttempts = n
+	}
+}
+
+// WithMaxElapsed gives up rather than start an attempt more than d after
+// the first one. Zero, the default, means no limit.
+func WithMaxElapsed(d time.Duration) Option {
+	return func(o *options) {
+		o.maxElapsed = d
+	}
+}
+
+// WithBackoff sets the delay between attempts. The default is
+// FullJitter(Exponential(100ms, 10s)).
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithClassifier adds rules consulted before Classify.
+func WithClassifier(c Classifier) Option {
+	return func(o *options) {
+		o.classifier = c
+	}
+}
+
+// WithBudget draws retries from a budget shared with other calls.
+func WithBudget(b *Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithClock sets the clock used for delays and elapsed time. The default
+// is clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithOnRetry calls fn before each wait, for logging and metrics.
+func WithOnRetry(fn func(attempt int, err error, delay time.Duration)) Option {
+	return func(o *options) {
+		o.onRetry = fn
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{
+		maxAttempts: 3,
+		backoff:     FullJitter(Exponential(100*time.Millisecond, 10*time.Second)),
+		clock:       clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Do calls fn until it succeeds, fails permanently, or a limit is
+// reached. A permanent error is returned unwrapped from any
+// PermanentError; giving up on a retryable error returns an *Error; if
+// ctx ends during an attempt or while waiting, ctx's error is joined
+// with the last error. An attempt that fails with
+// context.DeadlineExceeded while ctx is still live, such as one run
+// under its own timeout, is retried.
+func Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	_, err := DoValue(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+	return err
+}
+
+// DoValue is Do for functions that return a value.
+func DoValue[T any](ctx context.Context, fn func(ctx context.Context) (T, error), opts ...Option) (T, error) {
+	o := buildOptions(opts)
+	start := o.clock.Now()
+	if o.budget != nil {
+		o.budget.Deposit()
+	}
+
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		v, err := fn(ctx)
+		if err == nil {
+			return v, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// The caller gave up; whatever fn returned, there is no point
+			// in another attempt.
+			if !errors.Is(err, ctxErr) {
+				err = errors.Join(err, ctxErr)
+			}
+			return v, err
+		}
+		if o.classify(err) == Permanent {
+			var perm *PermanentError
+			if errors.As(err, &perm) {
+				err = perm.Err
+			}
+			return v, err
+		}
+		if o.maxAttempts > 0 && attempt >= o.maxAttempts {
+			return v, &Error{Attempts: attempt, Last: err, Reason: ErrMaxAttempts}
+		}
+
+		now := o.clock.Now()
+		delay = o.backoff(attempt, delay)
+		if hint, ok := retryAfter(err, now); ok {
+			delay = hint
+		}
+		if o.maxElapsed > 0 && now.Add(delay).Sub(start) > o.maxElapsed {
+			return v, &Error{Attempts: attempt, Last: err, Reason: ErrMaxElapsed}
+		}
+		if o.budget != nil && !o.budget.Withdraw() {
+			return v, &Error{Attempts: attempt, Last: err, Reason: ErrBudgetExhausted}
+		}
+		if o.onRetry != nil {
+			o.onRetry(attempt, err, delay)
+		}
+
+		timer := o.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return v, errors.Join(err, ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
+
+func (o *options) classify(err error) Class {
+	if o.classifier != nil {
+		if c := o.classifier(err); c != Unknown {
+			return c
+		}
+	}
+	return Classify(err)
+}
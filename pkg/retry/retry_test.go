@@ -0,0 +1,333 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/retry"
+)
+
+// doFake runs retry.Do on fc, advancing the clock by each delay as soon
+// as Do waits on it, and returns the delays Do chose and its error.
+func doFake(ctx context.Context, fc *clock.Fake, fn func(context.Context) error, opts ...retry.Option) ([]time.Duration, error) {
+	delayc := make(chan time.Duration)
+	errc := make(chan error, 1)
+	opts = append(opts, retry.WithClock(fc), retry.WithOnRetry(func(_ int, _ error, d time.Duration) {
+		delayc <- d
+	}))
+	go func() { errc <- retry.Do(ctx, fn, opts...) }()
+
+	var delays []time.Duration
+	for {
+		select {
+		case d := <-delayc:
+			delays = append(delays, d)
+			if d > 0 {
+				fc.BlockUntil(1)
+				fc.Advance(d)
+			}
+		case err := <-errc:
+			return delays, err
+		}
+	}
+}
+
+// failing returns fn failing with errs in turn, then succeeding, and a
+// count of its calls.
+func failing(errs ...error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}, &calls
+}
+
+var errFlaky = errors.New("flaky")
+
+func TestBackoffShapes(t *testing.T) {
+	exp := retry.Exponential(100*time.Millisecond, time.Second)
+	var got []time.Duration
+	for attempt := 1; attempt <= 6; attempt++ {
+		got = append(got, exp(attempt, 0))
+	}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i := range want {
+		want[i] *= time.Millisecond
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Exponential = %v, want %v", got, want)
+	}
+
+	uncapped := retry.Exponential(time.Millisecond, math.MaxInt64)
+	for attempt := 1; attempt <= 100; attempt++ {
+		if d := uncapped(attempt, 0); d < time.Millisecond {
+			t.Fatalf("uncapped Exponential overflowed at attempt %d: %v", attempt, d)
+		}
+	}
+
+	if d := retry.Constant(time.Second)(5, time.Minute); d != time.Second {
+		t.Errorf("Constant = %v", d)
+	}
+
+	full := retry.FullJitter(retry.Constant(time.Second))
+	for range 1000 {
+		if d := full(1, 0); d < 0 || d > time.Second {
+			t.Fatalf("FullJitter = %v, outside [0, 1s]", d)
+		}
+	}
+
+	const base, maxDelay = 10 * time.Millisecond, 500 * time.Millisecond
+	decor := retry.DecorrelatedJitter(base, maxDelay)
+	var prev time.Duration
+	for attempt := 1; attempt <= 1000; attempt++ {
+		d := decor(attempt, prev)
+		if hi := min(max(3*prev, base), maxDelay); d < base || d > hi {
+			t.Fatalf("DecorrelatedJitter after %v = %v, outside [%v, %v]", prev, d, base, hi)
+		}
+		prev = d
+	}
+}
+
+type retryableErr bool
+
+func (e retryableErr) Error() string   { return "retryable error" }
+func (e retryableErr) Retryable() bool { return bool(e) }
+
+type sqlErr string
+
+func (e sqlErr) Error() string    { return "sql error " + string(e) }
+func (e sqlErr) SQLState() string { return string(e) }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want retry.Class
+	}{
+		{retry.MarkPermanent(errFlaky), retry.Permanent},
+		{context.Canceled, retry.Permanent},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), retry.Retryable},
+		{retryableErr(true), retry.Retryable},
+		{retryableErr(false), retry.Permanent},
+		{&retry.StatusError{Code: http.StatusServiceUnavailable}, retry.Retryable},
+		{&retry.StatusError{Code: http.StatusTooManyRequests}, retry.Retryable},
+		{&retry.StatusError{Code: http.StatusNotFound}, retry.Permanent},
+		{sqlErr("40001"), retry.Retryable},
+		{sqlErr("55P03"), retry.Retryable},
+		{sqlErr("23505"), retry.Permanent},
+		{&net.OpError{Op: "dial", Err: errFlaky}, retry.Retryable},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), retry.Retryable},
+		{io.ErrUnexpectedEOF, retry.Retryable},
+		{errFlaky, retry.Unknown},
+	}
+	for _, tt := range tests {
+		if got := retry.Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"7", 7 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-5", 0, true},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		if d, ok := retry.ParseRetryAfter(tt.header, now); d != tt.want || ok != tt.ok {
+			t.Errorf("ParseRetryAfter(%q) = %v, %v; want %v, %v", tt.header, d, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDoBacksOff(t *testing.T) {
+	fc := clock.NewFake(time.Unix(0, 0))
+	fn, calls := failing(errFlaky, errFlaky, errFlaky)
+	delays, err := doFake(context.Background(), fc, fn,
+		retry.WithMaxAttempts(5), retry.WithBackoff(retry.Exponential(100*time.Millisecond, time.Second)))
+	if err != nil || *calls != 4 {
+		t.Fatalf("Do = %v after %d calls, want success on the 4th", err, *calls)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	if !slices.Equal(delays, want) {
+		t.Fatalf("delays = %v, want %v", delays, want)
+	}
+	if got := fc.Since(time.Unix(0, 0)); got != 700*time.Millisecond {
+		t.Fatalf("elapsed %v, want 700ms", got)
+	}
+}
+
+func TestDoRetryAfter(t *testing.T) {
+	fc := clock.NewFake(time.Unix(0, 0))
+	fn, _ := failing(&retry.StatusError{Code: http.StatusTooManyRequests, Header: "7"})
+	delays, err := doFake(context.Background(), fc, fn, retry.WithBackoff(retry.Constant(time.Millisecond)))
+	if err != nil || !slices.Equal(delays, []time.Duration{7 * time.Second}) {
+		t.Fatalf("Do = %v, delays %v; want one 7s wait from Retry-After", err, delays)
+	}
+}
+
+func TestDoPermanent(t *testing.T) {
+	fc := clock.NewFake(time.Unix(0, 0))
+	fn, calls := failing(retry.MarkPermanent(errFlaky))
+	if _, err := doFake(context.Background(), fc, fn); err != errFlaky || *calls != 1 {
+		t.Fatalf("Do = %v after %d calls, want the unwrapped error at once", err, *calls)
+	}
+
+	notFound := &retry.StatusError{Code: http.StatusNotFound}
+	fn, calls = failing(notFound)
+	if _, err := doFake(context.Background(), fc, fn); err != notFound || *calls != 1 {
+		t.Fatalf("Do = %v after %d calls, want 404 returned at once", err, *calls)
+	}
+
+	// A classifier takes precedence over the built-in rules.
+	fn, calls = failing(errFlaky)
+	_, err := doFake(context.Background(), fc, fn, retry.WithClassifier(func(err error) retry.Class {
+		if errors.Is(err, errFlaky) {
+			return retry.Permanent
+		}
+		return retry.Unknown
+	}))
+	if err != errFlaky || *calls != 1 {
+		t.Fatalf("Do = %v after %d calls, want the classifier to stop it", err, *calls)
+	}
+}
+
+func TestDoLimits(t *testing.T) {
+	always := func(context.Context) error { return errFlaky }
+	tests := []struct {
+		name     string
+		opts     []retry.Option
+		reason   error
+		attempts int
+	}{
+		{"attempts", []retry.Option{retry.WithMaxAttempts(3)}, retry.ErrMaxAttempts, 3},
+		// Attempts start at 0s, 1s and 2s; the next would start at 3s.
+		{"elapsed", []retry.Option{retry.WithMaxAttempts(0), retry.WithMaxElapsed(2500 * time.Millisecond)}, retry.ErrMaxElapsed, 3},
+		{"budget", []retry.Option{retry.WithBudget(retry.NewBudget(0, 0))}, retry.ErrBudgetExhausted, 1},
+	}
+	for _, tt := range tests {
+		fc := clock.NewFake(time.Unix(0, 0))
+		opts := append(tt.opts, retry.WithBackoff(retry.Constant(time.Second)))
+		_, err := doFake(context.Background(), fc, always, opts...)
+		var re *retry.Error
+		if !errors.As(err, &re) || re.Attempts != tt.attempts {
+			t.Errorf("%s: Do = %v, want *Error after %d attempts", tt.name, err, tt.attempts)
+			continue
+		}
+		if !errors.Is(err, tt.reason) || !errors.Is(err, errFlaky) {
+			t.Errorf("%s: Do = %v, want it to match %v and the last error", tt.name, err, tt.reason)
+		}
+	}
+}
+
+func TestDoRetriesAttemptTimeout(t *testing.T) {
+	fc := clock.NewFake(time.Unix(0, 0))
+	timeout := fmt.Errorf("query: %w", context.DeadlineExceeded)
+	fn, calls := failing(timeout, timeout)
+	if _, err := doFake(context.Background(), fc, fn, retry.WithBackoff(retry.Constant(0))); err != nil || *calls != 3 {
+		t.Fatalf("Do = %v after %d calls, want per-attempt timeouts retried", err, *calls)
+	}
+}
+
+func TestDoStopsWhenContextEnds(t *testing.T) {
+	fc := clock.NewFake(time.Unix(0, 0))
+
+	// The caller's deadline passed during the attempt.
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	calls := 0
+	_, err := doFake(ctx, fc, func(ctx context.Context) error {
+		calls++
+		return fmt.Errorf("query: %w", ctx.Err())
+	})
+	var re *retry.Error
+	if !errors.Is(err, context.DeadlineExceeded) || errors.As(err, &re) || calls != 1 {
+		t.Fatalf("Do = %v after %d calls, want the deadline at once", err, calls)
+	}
+
+	// fn fails with an unrelated error as the caller cancels.
+	ctx, cancel = context.WithCancel(context.Background())
+	_, err = doFake(ctx, fc, func(context.Context) error {
+		cancel()
+		return errFlaky
+	})
+	if !errors.Is(err, errFlaky) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do = %v, want errFlaky joined with context.Canceled", err)
+	}
+
+	// The caller cancels during the wait; the fake clock never fires it.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	err = retry.Do(ctx, func(context.Context) error { return errFlaky },
+		retry.WithClock(fc),
+		retry.WithBackoff(retry.Constant(time.Hour)),
+		retry.WithOnRetry(func(int, error, time.Duration) { cancel() }))
+	if !errors.Is(err, errFlaky) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do = %v, want errFlaky joined with context.Canceled", err)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	fc := clock.NewFake(time.Unix(100, 0))
+	b := retry.NewBudget(0.5, 0, retry.WithClock(fc))
+	for range 4 {
+		b.Deposit()
+	}
+	if n := b.Available(); n != 2 {
+		t.Fatalf("Available = %d, want 2", n)
+	}
+	if !b.Withdraw() || !b.Withdraw() || b.Withdraw() {
+		t.Fatal("Withdraw did not allow exactly two retries")
+	}
+
+	fc.Advance(9 * time.Second)
+	if n := b.Available(); n != 0 {
+		t.Fatalf("Available = %d 9s later, want the window to hold 0", n)
+	}
+	fc.Advance(time.Second)
+	b.Deposit()
+	b.Deposit()
+	if n := b.Available(); n != 1 {
+		t.Fatalf("Available = %d after the window slid, want 1", n)
+	}
+
+	steady := retry.NewBudget(0, 1, retry.WithClock(fc))
+	if n := steady.Available(); n != 10 {
+		t.Fatalf("minPerSecond allowance = %d, want 10", n)
+	}
+}
+
+func TestBudgetBefore1970(t *testing.T) {
+	fc := clock.NewFake(time.Unix(-6, 0))
+	b := retry.NewBudget(1, 0, retry.WithClock(fc))
+	for i := range 12 {
+		if i > 0 {
+			fc.Advance(time.Second)
+		}
+		b.Deposit()
+	}
+	if n := b.Available(); n != 10 {
+		t.Fatalf("Available = %d across 1970, want the last 10 seconds", n)
+	}
+}
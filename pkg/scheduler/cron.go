@@ -0,0 +1,361 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule yields the times a job is due.
+type Schedule interface {
+	// Next returns the first due time strictly after after, or the zero
+	// time if there is none.
+	Next(after time.Time) time.Time
+}
+
+// Cron is a parsed cron expression.
+//
+// Wall-clock times that a daylight saving change skips run once, at the
+// moment the clocks jump. Wall-clock times that a change repeats run
+// once, at their first occurrence, unless the hour field is "*", in
+// which case the repeated hour is run through like any other, as in
+// cronie.
+type Cron struct {
+	spec     string
+	loc      *time.Location
+	second   uint64
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	domStar  bool
+	dowStar  bool
+	hourStar bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = field{name: "second", max: 59}
+	minuteField = field{name: "minute", max: 59}
+	hourField   = field{name: "hour", max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard cron expression: five fields (minute, hour,
+// day of month, month, day of week), or six with a leading seconds
+// field. Fields accept "*", "?", numbers, names such as MON and JAN,
+// ranges, lists and steps ("*/15", "1-5", "MON,WED,FRI"). The @yearly,
+// @monthly, @weekly, @daily and @hourly macros are also accepted.
+//
+// The expression is evaluated in loc, or time.Local if loc is nil. A
+// "CRON_TZ=Europe/Berlin " or "TZ=..." prefix overrides loc.
+func ParseCron(spec string, loc *time.Location) (*Cron, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	expr := strings.TrimSpace(spec)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if rest, ok := strings.CutPrefix(expr, prefix); ok {
+			name, tail, _ := strings.Cut(rest, " ")
+			l, err := time.LoadLocation(name)
+			if err != nil {
+				return nil, fmt.Errorf("scheduler: cron %q: %w", spec, err)
+			}
+			loc, expr = l, strings.TrimSpace(tail)
+			break
+		}
+	}
+	if m, ok := macros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("scheduler: cron %q: want 5 or 6 fields, got %d", spec, len(fields))
+	}
+
+	c := &Cron{spec: spec, loc: loc}
+	var err error
+	parse := func(s string, f field) uint64 {
+		if err != nil {
+			return 0
+		}
+		var set uint64
+		set, err = f.parse(s)
+		if err != nil {
+			err = fmt.Errorf("scheduler: cron %q: %w", spec, err)
+		}
+		return set
+	}
+	c.second = parse(fields[0], secondField)
+	c.minute = parse(fields[1], minuteField)
+	c.hour = parse(fields[2], hourField)
+	c.dom = parse(fields[3], domField)
+	c.month = parse(fields[4], monthField)
+	c.dow = parse(fields[5], dowField)
+	if err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	c.domStar = isStar(fields[3])
+	c.dowStar = isStar(fields[5])
+	c.hourStar = isStar(fields[2])
+	return c, nil
+}
+
+// MustParseCron is like ParseCron but panics on error. It is meant for
+// expressions fixed in code.
+func MustParseCron(spec string, loc *time.Location) *Cron {
+	c, err := ParseCron(spec, loc)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func isStar(s string) bool {
+	return s == "*" || s == "?"
+}
+
+// parse turns one field into a bit set.
+func (f field) parse(s string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: bad step %q", f.name, stepStr)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rng == "*" || rng == "?":
+			lo, hi = f.min, f.max
+		default:
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiStr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // "5/15" means from 5 to the end
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s: range %q is backwards", f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: bad value %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %d is outside %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression as given.
+func (c *Cron) String() string { return c.spec }
+
+// Location returns the time zone the expression is evaluated in.
+func (c *Cron) Location() *time.Location { return c.loc }
+
+// Next implements Schedule.
+func (c *Cron) Next(after time.Time) time.Time {
+	from := after.In(c.loc).Truncate(time.Second).Add(time.Second)
+	wall := wallOf(from)
+	for range 1000 {
+		w, ok := c.nextWall(wall)
+		if !ok {
+			return time.Time{}
+		}
+		// A backward transition between from and w's instant repeats
+		// wall times already passed; with a "*" hour they are run again.
+		if c.hourStar {
+			if t, ok := backwardTransition(from, c.loc); ok && !t.After(resolve(w, c.loc)[0]) {
+				from, wall = t, wallOf(t)
+				continue
+			}
+		}
+		for _, t := range resolve(w, c.loc) {
+			if t.Before(from) || (!c.hourStar && secondPass(t)) {
+				continue
+			}
+			return t
+		}
+		wall = w.Add(time.Second)
+	}
+	return time.Time{}
+}
+
+// nextWall returns the first wall-clock time at or after w, represented
+// in UTC, that matches the expression.
+func (c *Cron) nextWall(w time.Time) (time.Time, bool) {
+	limit := w.Year() + 5
+	for w.Year() <= limit {
+		switch {
+		case c.month&(1<<int(w.Month())) == 0:
+			w = time.Date(w.Year(), w.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(w):
+			w = time.Date(w.Year(), w.Month(), w.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hour&(1<<w.Hour()) == 0:
+			w = w.Truncate(time.Hour).Add(time.Hour)
+		case c.minute&(1<<w.Minute()) == 0:
+			w = w.Truncate(time.Minute).Add(time.Minute)
+		case c.second&(1<<w.Second()) == 0:
+			w = w.Add(time.Second)
+		default:
+			return w, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (c *Cron) dayMatches(w time.Time) bool {
+	dom := c.dom&(1<<w.Day()) != 0
+	dow := c.dow&(1<<int(w.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// wallOf returns t's wall-clock reading as a UTC time, which makes
+// calendar arithmetic immune to zone changes.
+func wallOf(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	return time.Date(y, mo, d, h, mi, s, 0, time.UTC)
+}
+
+// resolve returns the instants at which loc's clocks read w, in order:
+// one normally, two when a backward transition repeats w, and the
+// moment of the jump when a forward transition skips w.
+func resolve(w time.Time, loc *time.Location) []time.Time {
+	var out []time.Time
+	seen := map[int]bool{}
+	for _, probe := range []time.Time{w.Add(-48 * time.Hour), w, w.Add(48 * time.Hour)} {
+		_, off := probe.In(loc).Zone()
+		if seen[off] {
+			continue
+		}
+		seen[off] = true
+		t := w.Add(-time.Duration(off) * time.Second).In(loc)
+		if wallOf(t).Equal(w) {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		// Skipped: with the earlier offset the instant lands after the
+		// jump, and the zone it lands in starts at the jump.
+		_, off := w.Add(-48 * time.Hour).In(loc).Zone()
+		start, _ := w.Add(-time.Duration(off) * time.Second).In(loc).ZoneBounds()
+		return []time.Time{start}
+	}
+	if len(out) == 2 && out[1].Before(out[0]) {
+		out[0], out[1] = out[1], out[0]
+	}
+	return out
+}
+
+// secondPass reports whether t falls in a wall-clock period that a
+// backward transition is repeating.
+func secondPass(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+	_, before := start.Add(-time.Second).Zone()
+	_, now := t.Zone()
+	return before > now && t.Before(start.Add(time.Duration(before-now)*time.Second))
+}
+
+// backwardTransition returns the next time after from at which loc's
+// clocks go back, if it is within a few days.
+func backwardTransition(from time.Time, loc *time.Location) (time.Time, bool) {
+	_, end := from.In(loc).ZoneBounds()
+	if end.IsZero() || end.Sub(from) > 72*time.Hour {
+		return time.Time{}, false
+	}
+	_, before := from.In(loc).Zone()
+	_, after := end.In(loc).Zone()
+	return end.In(loc), after < before
+}
+
+// Every returns a schedule due every d after the previous due time.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("scheduler: Every needs a positive interval")
+	}
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+// At returns a schedule due once, at t.
+func At(t time.Time) Schedule {
+	return once(t)
+}
+
+type once time.Time
+
+func (o once) Next(after time.Time) time.Time {
+	if t := time.Time(o); t.After(after) {
+		return t
+	}
+	return time.Time{}
+}
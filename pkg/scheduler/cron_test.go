@@ -0,0 +1,184 @@
+package scheduler_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/scheduler"
+)
+
+const layout = "2006-01-02 15:04:05 MST"
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+// nextN formats the first n due times of s after from.
+func nextN(s scheduler.Schedule, from time.Time, n int) []string {
+	var out []string
+	for t := from; len(out) < n; {
+		if t = s.Next(t); t.IsZero() {
+			break
+		}
+		out = append(out, t.Format(layout))
+	}
+	return out
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string // in UTC
+		want []string
+	}{
+		{"*/15 * * * *", "2026-01-01 10:07:00", []string{
+			"2026-01-01 10:15:00 UTC", "2026-01-01 10:30:00 UTC", "2026-01-01 10:45:00 UTC",
+		}},
+		{"30 */20 9 * * *", "2026-01-01 09:00:30", []string{
+			"2026-01-01 09:20:30 UTC", "2026-01-01 09:40:30 UTC", "2026-01-02 09:00:30 UTC",
+		}},
+		{"0 9 * * MON-FRI", "2026-01-02 10:00:00", []string{ // a Friday
+			"2026-01-05 09:00:00 UTC", "2026-01-06 09:00:00 UTC",
+		}},
+		{"0 0 13 * FRI", "2026-02-01 00:00:00", []string{ // the 13th or any Friday
+			"2026-02-06 00:00:00 UTC", "2026-02-13 00:00:00 UTC", "2026-02-20 00:00:00 UTC",
+		}},
+		{"0 0 29 2 *", "2026-01-01 00:00:00", []string{"2028-02-29 00:00:00 UTC"}},
+		{"0 0 31 2 *", "2026-01-01 00:00:00", nil},
+		{"@monthly", "2026-01-15 00:00:00", []string{
+			"2026-02-01 00:00:00 UTC", "2026-03-01 00:00:00 UTC",
+		}},
+		{"0 12 * JAN,jul 0", "2026-06-29 00:00:00", []string{ // 7 and 0 are both Sunday
+			"2026-07-05 12:00:00 UTC", "2026-07-12 12:00:00 UTC",
+		}},
+	}
+	for _, tt := range tests {
+		c, err := scheduler.ParseCron(tt.spec, time.UTC)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+		}
+		from, _ := time.Parse(time.DateTime, tt.from)
+		if got := nextN(c, from, len(tt.want)); !slices.Equal(got, tt.want) {
+			t.Errorf("%q after %s:\n got %q\nwant %q", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronDaylightSaving(t *testing.T) {
+	tests := []struct {
+		name, zone, spec string
+		day              string // local midnight to start from
+		want             []string
+	}{
+		// Berlin springs forward from 02:00 to 03:00 on 29 March 2026.
+		{"skipped time runs at the jump", "Europe/Berlin", "30 2 * * *", "2026-03-29", []string{
+			"2026-03-29 03:00:00 CEST", "2026-03-30 02:30:00 CEST",
+		}},
+		{"skipped hour", "Europe/Berlin", "0 * * * *", "2026-03-29", []string{
+			"2026-03-29 01:00:00 CET", "2026-03-29 03:00:00 CEST", "2026-03-29 04:00:00 CEST",
+		}},
+		{"skipped half hours collapse", "Europe/Berlin", "*/30 1-3 * * *", "2026-03-29", []string{
+			"2026-03-29 01:00:00 CET", "2026-03-29 01:30:00 CET", "2026-03-29 03:00:00 CEST",
+			"2026-03-29 03:30:00 CEST", "2026-03-30 01:00:00 CEST",
+		}},
+		// Berlin falls back from 03:00 to 02:00 on 25 October 2026.
+		{"repeated time runs once", "Europe/Berlin", "30 2 * * *", "2026-10-25", []string{
+			"2026-10-25 02:30:00 CEST", "2026-10-26 02:30:00 CET",
+		}},
+		{"star hour reruns the repeated hour", "Europe/Berlin", "0 * * * *", "2026-10-25", []string{
+			"2026-10-25 01:00:00 CEST", "2026-10-25 02:00:00 CEST", "2026-10-25 02:00:00 CET",
+			"2026-10-25 03:00:00 CET",
+		}},
+		{"fixed hours run the repeated hour once", "Europe/Berlin", "*/30 1-3 * * *", "2026-10-25", []string{
+			"2026-10-25 01:00:00 CEST", "2026-10-25 01:30:00 CEST", "2026-10-25 02:00:00 CEST",
+			"2026-10-25 02:30:00 CEST", "2026-10-25 03:00:00 CET", "2026-10-25 03:30:00 CET",
+		}},
+		// New York springs forward from 02:00 to 03:00 on 8 March 2026
+		// and falls back from 02:00 to 01:00 on 1 November 2026.
+		{"skipped time runs at the jump", "America/New_York", "30 2 * * *", "2026-03-08", []string{
+			"2026-03-08 03:00:00 EDT", "2026-03-09 02:30:00 EDT",
+		}},
+		{"skipped hour", "America/New_York", "0 * * * *", "2026-03-08", []string{
+			"2026-03-08 01:00:00 EST", "2026-03-08 03:00:00 EDT",
+		}},
+		{"repeated time runs once", "America/New_York", "30 1 * * *", "2026-11-01", []string{
+			"2026-11-01 01:30:00 EDT", "2026-11-02 01:30:00 EST",
+		}},
+		{"star hour reruns the repeated hour", "America/New_York", "0 * * * *", "2026-11-01", []string{
+			"2026-11-01 01:00:00 EDT", "2026-11-01 01:00:00 EST", "2026-11-01 02:00:00 EST",
+		}},
+		{"fixed hours run the repeated hour once", "America/New_York", "*/30 0-2 * * *", "2026-11-01", []string{
+			"2026-11-01 00:30:00 EDT", "2026-11-01 01:00:00 EDT", "2026-11-01 01:30:00 EDT",
+			"2026-11-01 02:00:00 EST", "2026-11-01 02:30:00 EST", "2026-11-02 00:00:00 EST",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone+"/"+tt.name, func(t *testing.T) {
+			loc := mustLoad(t, tt.zone)
+			day, _ := time.ParseInLocation(time.DateOnly, tt.day, loc)
+			c := scheduler.MustParseCron(tt.spec, loc)
+			if got := nextN(c, day, len(tt.want)); !slices.Equal(got, tt.want) {
+				t.Errorf("%q:\n got %q\nwant %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronZone(t *testing.T) {
+	c, err := scheduler.ParseCron("CRON_TZ=America/New_York 0 9 * * *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Location().String(); got != "America/New_York" {
+		t.Fatalf("Location = %s", got)
+	}
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := c.Next(from), time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("Next = %v, want %v", got, want)
+	}
+	if c.String() != "CRON_TZ=America/New_York 0 9 * * *" {
+		t.Fatalf("String = %q", c.String())
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+		"TZ=Nowhere/Special * * * * *",
+	} {
+		if _, err := scheduler.ParseCron(spec, time.UTC); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestEveryAndAt(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := nextN(scheduler.Every(90*time.Second), start, 2); !slices.Equal(got, []string{
+		"2026-01-01 00:01:30 UTC", "2026-01-01 00:03:00 UTC",
+	}) {
+		t.Errorf("Every = %q", got)
+	}
+	if got := nextN(scheduler.At(start.Add(time.Hour)), start, 3); !slices.Equal(got, []string{
+		"2026-01-01 01:00:00 UTC",
+	}) {
+		t.Errorf("At = %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+// Package scheduler runs recurring and one-off jobs, taking the timeout
+// and worker pool chapters from single runs to schedules.
+//
+// Schedules come from cron expressions with optional seconds and time
+// zones (ParseCron), fixed intervals (Every) or a single time (At):
+//
+//	s, err := scheduler.New(scheduler.WithStateFile("/var/lib/app/schedule.json"))
+//	if err != nil {
+//	    return err
+//	}
+//	s.Add("report", scheduler.MustParseCron("CRON_TZ=Europe/Berlin 0 9 * * MON-FRI", nil), sendReport,
+//	    scheduler.WithJitter(time.Minute),
+//	    scheduler.WithTimeout(10*time.Minute),
+//	)
+//	s.Add("sweep", scheduler.Every(15*time.Minute), sweep, scheduler.WithOverlap(scheduler.Skip))
+//	s.Start()
+//	defer s.Stop(ctx)
+//
+// Each job has an overlap policy for runs that come due while the
+// previous one is still going, and a misfire policy for runs that are
+// late, usually because the process was down: catch up with a single run
+// or skip to the next due time.
+//
+// With a state file, the due time of every run is saved before the run
+// starts and the file is replaced atomically. A restarted scheduler
+// resumes each job after its last saved due time, so a run never fires
+// twice and runs missed during the downtime are handled by the misfire
+// policy.
+//
+// The scheduler takes its time from a clock.Clock, so schedules,
+// including their behaviour across daylight saving changes, can be
+// exercised with clock.Fake.
+package scheduler
@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+type options struct {
+	clock     clock.Clock
+	stateFile string
+	onError   func(job string, err error)
+}
+
+// Option configures a Scheduler.
+type Option func(*options)
+
+// WithClock sets the clock that drives the scheduler. The default is
+// clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithStateFile persists each job's last run to path, so a restarted
+// scheduler neither repeats runs nor forgets ones it missed.
+func WithStateFile(path string) Option {
+	return func(o *options) {
+		o.stateFile = path
+	}
+}
+
+// WithOnError is called with every failed run and every failure to save
+// state. Panics in jobs are reported as *PanicError. fn may run with the
+// scheduler's lock held and must not call back into it.
+func WithOnError(fn func(job string, err error)) Option {
+	return func(o *options) {
+		o.onError = fn
+	}
+}
+
+// Overlap decides what happens when a job is due while its previous run
+// is still going.
+type Overlap int
+
+const (
+	// Skip drops the run. This is the default.
+	Skip Overlap = iota
+	// Allow starts the run concurrently.
+	Allow
+	// Queue starts the run as soon as the previous one ends. At most one
+	// run is queued.
+	Queue
+)
+
+// Misfire decides what happens to runs that are late by more than the
+// misfire threshold, typically because the process was down.
+type Misfire int
+
+const (
+	// RunOnce runs once to catch up, however many runs were missed. This
+	// is the default.
+	RunOnce Misfire = iota
+	// SkipMissed drops missed runs and waits for the next due time.
+	SkipMissed
+)
+
+type jobOptions struct {
+	jitter    time.Duration
+	overlap   Overlap
+	misfire   Misfire
+	threshold time.Duration
+	timeout   time.Duration
+}
+
+// JobOption configures a job.
+type JobOption func(*jobOptions)
+
+// WithJitter delays each run by a random duration in [0, d), spreading
+// jobs that share a schedule across instances.
+func WithJitter(d time.Duration) JobOption {
+	return func(o *jobOptions) {
+		o.jitter = d
+	}
+}
+
+// WithOverlap sets the overlap policy. The default is Skip.
+func WithOverlap(p Overlap) JobOption {
+	return func(o *jobOptions) {
+		o.overlap = p
+	}
+}
+
+// WithMisfire sets the misfire policy and how late a run may be before
+// it counts as missed. The default is RunOnce with a one minute
+// threshold.
+func WithMisfire(p Misfire, threshold time.Duration) JobOption {
+	return func(o *jobOptions) {
+		o.misfire, o.threshold = p, threshold
+	}
+}
+
+// WithTimeout cancels each run's context after d.
+func WithTimeout(d time.Duration) JobOption {
+	return func(o *jobOptions) {
+		o.timeout = d
+	}
+}
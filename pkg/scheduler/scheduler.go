@@ -0,0 +1,375 @@
+package scheduler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+var (
+	// ErrDuplicate is returned by Add for a name already in use.
+	ErrDuplicate = errors.New("scheduler: duplicate job name")
+	// ErrStopped is returned by Add and Start after Stop.
+	ErrStopped = errors.New("scheduler: stopped")
+)
+
+// maxCatchUp bounds how many missed due times are stepped through when
+// working out how late a job is.
+const maxCatchUp = 10000
+
+// PanicError reports a run that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("scheduler: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+type dueKey struct{}
+
+// Due returns the due time of the run whose context is ctx, which may be
+// earlier than the time it started because of jitter, a queued overlap
+// or a misfire. Jobs can use it to name the period they process.
+func Due(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(dueKey{}).(time.Time)
+	return t, ok
+}
+
+// JobInfo describes a job.
+type JobInfo struct {
+	Name     string
+	Next     time.Time // zero when the schedule has ended
+	LastRun  time.Time
+	LastErr  error
+	Running  int
+	Runs     int
+	Failures int
+	Skipped  int // due while running, under the Skip policy
+	Missed   int // due times passed over by the misfire policy
+}
+
+type job struct {
+	name  string
+	sched Schedule
+	fn    func(context.Context) error
+	o     jobOptions
+
+	due     time.Time // next due time, zero when the schedule has ended
+	fireAt  time.Time // due plus jitter
+	lastDue time.Time
+	queued  bool
+	info    JobInfo
+}
+
+// Scheduler runs jobs on schedules. It is safe for concurrent use.
+type Scheduler struct {
+	o     options
+	clock clock.Clock
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	state   map[string]jobState
+	started bool
+	stopped bool
+	wake    chan struct{}
+
+	stop       chan struct{}
+	loopDone   chan struct{}
+	runs       sync.WaitGroup
+	runCtx     context.Context
+	cancelRuns context.CancelFunc
+}
+
+// New returns a stopped scheduler, loading persisted state if
+// WithStateFile is given.
+func New(opts ...Option) (*Scheduler, error) {
+	o := options{clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &Scheduler{
+		o:        o,
+		clock:    o.clock,
+		jobs:     make(map[string]*job),
+		state:    map[string]jobState{},
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		loopDone: make(chan struct{}),
+	}
+	s.runCtx, s.cancelRuns = context.WithCancel(context.Background())
+	if o.stateFile != "" {
+		st, err := loadState(o.stateFile)
+		if err != nil {
+			return nil, err
+		}
+		s.state = st
+	}
+	return s, nil
+}
+
+// Add registers a job. With persisted state the job resumes from its
+// last run; otherwise its first due time is the schedule's next after
+// now.
+func (s *Scheduler) Add(name string, sched Schedule, fn func(ctx context.Context) error, opts ...JobOption) error {
+	o := jobOptions{threshold: time.Minute}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrStopped
+	}
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicate, name)
+	}
+	j := &job{name: name, sched: sched, fn: fn, o: o, info: JobInfo{Name: name}}
+	if st, ok := s.state[name]; ok {
+		j.lastDue, j.info.LastRun = st.LastDue, st.LastRun
+		j.setDue(sched.Next(st.LastDue))
+	} else {
+		j.setDue(sched.Next(s.clock.Now()))
+	}
+	s.jobs[name] = j
+	s.signal()
+	return nil
+}
+
+// Remove unregisters a job. A run in progress is not interrupted. The
+// job's persisted state is kept so that adding it again resumes it.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.jobs[name]
+	delete(s.jobs, name)
+	s.signal()
+	return ok
+}
+
+// Jobs describes every job, sorted by name.
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		info := j.info
+		info.Next = j.fireAt
+		out = append(out, info)
+	}
+	slices.SortFunc(out, func(a, b JobInfo) int { return cmp.Compare(a.Name, b.Name) })
+	return out
+}
+
+// Start begins running jobs in a background goroutine.
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrStopped
+	}
+	if !s.started {
+		s.started = true
+		go s.loop()
+	}
+	return nil
+}
+
+// Stop stops starting runs and waits for those in progress. If ctx ends
+// first, their contexts are cancelled and Stop returns ctx.Err()
+// without waiting further.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	started := s.started
+	s.mu.Unlock()
+
+	close(s.stop)
+	if started {
+		<-s.loopDone
+	}
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancelRuns()
+		return nil
+	case <-ctx.Done():
+		s.cancelRuns()
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.loopDone)
+	for {
+		s.mu.Lock()
+		now := s.clock.Now()
+		var next time.Time
+		for _, j := range s.jobs {
+			if j.fireAt.IsZero() {
+				continue
+			}
+			if !j.fireAt.After(now) {
+				s.fire(j, now)
+			}
+			if !j.fireAt.IsZero() && (next.IsZero() || j.fireAt.Before(next)) {
+				next = j.fireAt
+			}
+		}
+		s.mu.Unlock()
+
+		var timer clock.Timer
+		var timeout <-chan time.Time
+		if !next.IsZero() {
+			timer = s.clock.NewTimer(next.Sub(now))
+			timeout = timer.C()
+		}
+		select {
+		case <-timeout:
+		case <-s.wake:
+		case <-s.stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// fire handles a job whose time has come. It must be called with s.mu
+// held.
+func (s *Scheduler) fire(j *job, now time.Time) {
+	slot := j.due
+	if now.Sub(j.fireAt) > j.o.threshold {
+		// Late: find the last due time that has passed.
+		missed := 0
+		for n := j.sched.Next(slot); !n.IsZero() && !n.After(now) && missed < maxCatchUp; n = j.sched.Next(n) {
+			slot = n
+			missed++
+		}
+		j.setDue(j.sched.Next(now))
+		if j.o.misfire == SkipMissed {
+			j.info.Missed += missed + 1
+			j.lastDue = slot
+			s.save(j)
+			return
+		}
+		j.info.Missed += missed
+	} else {
+		j.setDue(j.sched.Next(slot))
+	}
+
+	j.lastDue = slot
+	if j.info.Running > 0 {
+		switch j.o.overlap {
+		case Skip:
+			j.info.Skipped++
+			s.save(j)
+			return
+		case Queue:
+			j.queued = true
+			s.save(j)
+			return
+		}
+	}
+	s.start(j, slot, now)
+}
+
+// start launches a run. It must be called with s.mu held.
+func (s *Scheduler) start(j *job, due, now time.Time) {
+	j.info.Running++
+	j.info.LastRun = now
+	s.save(j)
+	s.runs.Add(1)
+	go s.run(j, due)
+}
+
+func (s *Scheduler) run(j *job, due time.Time) {
+	defer s.runs.Done()
+	ctx := context.WithValue(s.runCtx, dueKey{}, due)
+	if j.o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = s.clock.WithTimeout(ctx, j.o.timeout)
+		defer cancel()
+	}
+	err := call(ctx, j.fn)
+
+	s.mu.Lock()
+	j.info.Running--
+	j.info.Runs++
+	j.info.LastErr = err
+	if err != nil {
+		j.info.Failures++
+	}
+	if j.queued && j.info.Running == 0 && !s.stopped {
+		j.queued = false
+		s.start(j, j.lastDue, s.clock.Now())
+	}
+	s.mu.Unlock()
+	if err != nil {
+		s.report(j.name, err)
+	}
+}
+
+func call(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx)
+}
+
+// save records j's progress and writes the state file. It must be
+// called with s.mu held, which also serialises the writes.
+func (s *Scheduler) save(j *job) {
+	s.state[j.name] = jobState{LastDue: j.lastDue, LastRun: j.info.LastRun}
+	if s.o.stateFile == "" {
+		return
+	}
+	if err := saveState(s.o.stateFile, s.state); err != nil {
+		s.report(j.name, err)
+	}
+}
+
+func (s *Scheduler) report(name string, err error) {
+	if s.o.onError != nil {
+		s.o.onError(name, err)
+	}
+}
+
+// setDue sets the next due time and draws its jitter.
+func (j *job) setDue(due time.Time) {
+	j.due, j.fireAt = due, due
+	if !due.IsZero() && j.o.jitter > 0 {
+		j.fireAt = due.Add(rand.N(j.o.jitter))
+	}
+}
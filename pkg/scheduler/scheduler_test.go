@@ -0,0 +1,310 @@
+package scheduler_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+	"github.com/thanhnamdk2710/go-handbook/pkg/scheduler"
+)
+
+// recorder collects the due time of every run of a job.
+type recorder struct {
+	mu   sync.Mutex
+	dues []time.Time
+}
+
+func (r *recorder) job(ctx context.Context) error {
+	due, _ := scheduler.Due(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dues = append(r.dues, due)
+	return nil
+}
+
+// formatted returns the due times in order, formatted in loc.
+func (r *recorder) formatted(loc *time.Location) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dues := slices.Clone(r.dues)
+	slices.SortFunc(dues, time.Time.Compare)
+	var out []string
+	for _, d := range dues {
+		out = append(out, d.In(loc).Format(layout))
+	}
+	return out
+}
+
+func newScheduler(t *testing.T, fc *clock.Fake, opts ...scheduler.Option) *scheduler.Scheduler {
+	t.Helper()
+	s, err := scheduler.New(append([]scheduler.Option{
+		scheduler.WithClock(fc),
+		scheduler.WithOnError(func(job string, err error) { t.Errorf("%s: %v", job, err) }),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func add(t *testing.T, s *scheduler.Scheduler, name string, sched scheduler.Schedule, fn func(context.Context) error, opts ...scheduler.JobOption) {
+	t.Helper()
+	if err := s.Add(name, sched, fn, opts...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func start(t *testing.T, s *scheduler.Scheduler) {
+	t.Helper()
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func stop(t *testing.T, s *scheduler.Scheduler) {
+	t.Helper()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// advance moves the fake clock forward a step at a time. Before each step
+// it waits for the scheduler loop to arm its timer and for every run to
+// finish, so a slow goroutine never turns into a skipped overlap.
+func advance(t *testing.T, s *scheduler.Scheduler, fc *clock.Fake, d, step time.Duration) {
+	t.Helper()
+	for end := fc.Now().Add(d); fc.Now().Before(end); {
+		settle(t, s, fc)
+		fc.Advance(step)
+	}
+	settle(t, s, fc)
+}
+
+// settle waits until the scheduler loop is waiting on its timer and no
+// run is in progress.
+func settle(t *testing.T, s *scheduler.Scheduler, fc *clock.Fake) {
+	t.Helper()
+	fc.BlockUntil(1)
+	waitFor(t, "runs to finish", func() bool {
+		for _, j := range s.Jobs() {
+			if j.Running > 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func jobInfo(t *testing.T, s *scheduler.Scheduler, name string) scheduler.JobInfo {
+	t.Helper()
+	for _, j := range s.Jobs() {
+		if j.Name == name {
+			return j
+		}
+	}
+	t.Fatalf("no job %q", name)
+	return scheduler.JobInfo{}
+}
+
+func TestSchedulerDaylightSaving(t *testing.T) {
+	tests := []struct {
+		zone, day string
+		star      []string // "0 * * * *" over the four hours after 00:30
+		fixed     []string // "30 1,2 * * *"
+	}{
+		{"Europe/Berlin", "2026-03-29",
+			[]string{"2026-03-29 01:00:00 CET", "2026-03-29 03:00:00 CEST", "2026-03-29 04:00:00 CEST",
+				"2026-03-29 05:00:00 CEST"},
+			[]string{"2026-03-29 01:30:00 CET", "2026-03-29 03:00:00 CEST"}},
+		{"Europe/Berlin", "2026-10-25",
+			[]string{"2026-10-25 01:00:00 CEST", "2026-10-25 02:00:00 CEST", "2026-10-25 02:00:00 CET",
+				"2026-10-25 03:00:00 CET"},
+			[]string{"2026-10-25 01:30:00 CEST", "2026-10-25 02:30:00 CEST"}},
+		{"America/New_York", "2026-03-08",
+			[]string{"2026-03-08 01:00:00 EST", "2026-03-08 03:00:00 EDT", "2026-03-08 04:00:00 EDT",
+				"2026-03-08 05:00:00 EDT"},
+			[]string{"2026-03-08 01:30:00 EST", "2026-03-08 03:00:00 EDT"}},
+		{"America/New_York", "2026-11-01",
+			[]string{"2026-11-01 01:00:00 EDT", "2026-11-01 01:00:00 EST", "2026-11-01 02:00:00 EST",
+				"2026-11-01 03:00:00 EST"},
+			[]string{"2026-11-01 01:30:00 EDT", "2026-11-01 02:30:00 EST"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone+"/"+tt.day, func(t *testing.T) {
+			loc := mustLoad(t, tt.zone)
+			day, _ := time.ParseInLocation(time.DateOnly, tt.day, loc)
+			fc := clock.NewFake(day.Add(30 * time.Minute))
+			s := newScheduler(t, fc)
+			var star, fixed recorder
+			add(t, s, "star", scheduler.MustParseCron("0 * * * *", loc), star.job)
+			add(t, s, "fixed", scheduler.MustParseCron("30 1,2 * * *", loc), fixed.job)
+			start(t, s)
+			// Four real hours, whatever the wall clock says.
+			advance(t, s, fc, 4*time.Hour, time.Minute)
+			stop(t, s)
+
+			if got := star.formatted(loc); !slices.Equal(got, tt.star) {
+				t.Errorf("star hour:\n got %q\nwant %q", got, tt.star)
+			}
+			if got := fixed.formatted(loc); !slices.Equal(got, tt.fixed) {
+				t.Errorf("fixed hours:\n got %q\nwant %q", got, tt.fixed)
+			}
+		})
+	}
+}
+
+func TestSchedulerMisfire(t *testing.T) {
+	tests := []struct {
+		policy     scheduler.Misfire
+		want       []string
+		runs, miss int
+	}{
+		// Down from 10:30 to 13:30: the 11:00, 12:00 and 13:00 runs are
+		// late.
+		{scheduler.RunOnce, []string{"2026-01-01 13:00:00 UTC"}, 1, 2},
+		{scheduler.SkipMissed, nil, 0, 3},
+	}
+	for _, tt := range tests {
+		fc := clock.NewFake(time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC))
+		s := newScheduler(t, fc)
+		var r recorder
+		add(t, s, "hourly", scheduler.MustParseCron("0 * * * *", time.UTC), r.job,
+			scheduler.WithMisfire(tt.policy, time.Minute))
+		start(t, s)
+		settle(t, s, fc)
+		fc.Advance(3 * time.Hour)
+		settle(t, s, fc)
+		stop(t, s)
+
+		if got := r.formatted(time.UTC); !slices.Equal(got, tt.want) {
+			t.Errorf("policy %d: runs %q, want %q", tt.policy, got, tt.want)
+		}
+		info := jobInfo(t, s, "hourly")
+		if info.Runs != tt.runs || info.Missed != tt.miss {
+			t.Errorf("policy %d: Runs, Missed = %d, %d; want %d, %d", tt.policy, info.Runs, info.Missed, tt.runs, tt.miss)
+		}
+		if want := time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC); !info.Next.Equal(want) {
+			t.Errorf("policy %d: Next = %v, want %v", tt.policy, info.Next, want)
+		}
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		policy  scheduler.Overlap
+		want    []string
+		skipped int
+	}{
+		{scheduler.Skip, []string{"2026-01-01 00:01:00 UTC"}, 2},
+		// At most one run is queued, for the latest due time.
+		{scheduler.Queue, []string{"2026-01-01 00:01:00 UTC", "2026-01-01 00:03:00 UTC"}, 0},
+	}
+	for _, tt := range tests {
+		fc := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		s := newScheduler(t, fc)
+		var r recorder
+		started := make(chan struct{})
+		release := make(chan struct{})
+		var once sync.Once
+		add(t, s, "slow", scheduler.Every(time.Minute), func(ctx context.Context) error {
+			first := false
+			once.Do(func() { first = true })
+			if first {
+				close(started)
+				<-release
+			}
+			return r.job(ctx)
+		}, scheduler.WithOverlap(tt.policy))
+		start(t, s)
+
+		fc.BlockUntil(1)
+		fc.Advance(time.Minute)
+		<-started
+		// Two more due times pass while the first run is still going.
+		for range 2 {
+			fc.BlockUntil(1)
+			fc.Advance(time.Minute)
+		}
+		fc.BlockUntil(1)
+		if info := jobInfo(t, s, "slow"); info.Running != 1 || info.Skipped != tt.skipped {
+			t.Errorf("policy %d: Running, Skipped = %d, %d; want 1, %d", tt.policy, info.Running, info.Skipped, tt.skipped)
+		}
+		close(release)
+		waitFor(t, "runs to finish", func() bool { return jobInfo(t, s, "slow").Runs == len(tt.want) })
+		stop(t, s)
+
+		if got := r.formatted(time.UTC); !slices.Equal(got, tt.want) {
+			t.Errorf("policy %d: runs %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulerRestart(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state.json")
+	hourly := scheduler.MustParseCron("0 * * * *", time.UTC)
+	fc := clock.NewFake(time.Date(2026, 1, 1, 11, 59, 0, 0, time.UTC))
+
+	var r recorder
+	s := newScheduler(t, fc, scheduler.WithStateFile(state))
+	add(t, s, "hourly", hourly, r.job)
+	start(t, s)
+	advance(t, s, fc, 2*time.Minute, time.Minute)
+	stop(t, s)
+
+	// Restarted within the same hour, the 12:00 run is not repeated.
+	s = newScheduler(t, fc, scheduler.WithStateFile(state))
+	add(t, s, "hourly", hourly, r.job)
+	if info := jobInfo(t, s, "hourly"); !info.Next.Equal(time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Next after restart = %v, want 13:00", info.Next)
+	}
+	start(t, s)
+	advance(t, s, fc, 10*time.Minute, time.Minute)
+	stop(t, s)
+
+	// Down from 12:11 to 15:10: one catch-up run for 15:00.
+	fc.Set(time.Date(2026, 1, 1, 15, 10, 0, 0, time.UTC))
+	s = newScheduler(t, fc, scheduler.WithStateFile(state))
+	add(t, s, "hourly", hourly, r.job)
+	start(t, s)
+	advance(t, s, fc, time.Hour, time.Minute)
+	stop(t, s)
+
+	want := []string{"2026-01-01 12:00:00 UTC", "2026-01-01 15:00:00 UTC", "2026-01-01 16:00:00 UTC"}
+	if got := r.formatted(time.UTC); !slices.Equal(got, want) {
+		t.Fatalf("runs %q, want %q", got, want)
+	}
+	if info := jobInfo(t, s, "hourly"); info.Missed != 2 {
+		t.Fatalf("Missed = %d, want 2", info.Missed)
+	}
+}
+
+func TestSchedulerAddAfterStop(t *testing.T) {
+	fc := clock.NewFake(time.Unix(0, 0))
+	s := newScheduler(t, fc)
+	add(t, s, "a", scheduler.Every(time.Minute), func(context.Context) error { return nil })
+	if err := s.Add("a", scheduler.Every(time.Minute), nil); err == nil {
+		t.Fatal("duplicate Add succeeded")
+	}
+	stop(t, s)
+	if err := s.Add("b", scheduler.Every(time.Minute), nil); err != scheduler.ErrStopped {
+		t.Fatalf("Add after Stop = %v, want ErrStopped", err)
+	}
+	if err := s.Start(); err != scheduler.ErrStopped {
+		t.Fatalf("Start after Stop = %v, want ErrStopped", err)
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// jobState is what survives a restart.
+type jobState struct {
+	// LastDue is the due time of the last run that was started, or the
+	// last due time passed over by the misfire policy. The next run is
+	// computed from it, so a restart never repeats a run.
+	LastDue time.Time `json:"last_due"`
+	LastRun time.Time `json:"last_run"`
+}
+
+type stateFile struct {
+	Version int                 `json:"version"`
+	Jobs    map[string]jobState `json:"jobs"`
+}
+
+// loadState reads path; a missing file is an empty state.
+func loadState(path string) (map[string]jobState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]jobState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: %w", err)
+	}
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("scheduler: state file %s: %w", path, err)
+	}
+	if f.Jobs == nil {
+		f.Jobs = map[string]jobState{}
+	}
+	return f.Jobs, nil
+}
+
+// saveState replaces path atomically so a crash mid-write leaves the
+// previous state intact.
+func saveState(path string, jobs map[string]jobState) error {
+	data, err := json.MarshalIndent(stateFile{Version: 1, Jobs: jobs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("scheduler: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("scheduler: %w", err)
+	}
+	_, err = f.Write(data)
+	if serr := f.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("scheduler: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+// Package sets provides generic Set and Multiset types with set algebra.
+//
+// Both types are maps under the hood, but every operation that exposes
+// their contents in sequence (Sorted, String, MarshalJSON) uses a stable
+// order so that printed output and golden tests do not depend on Go's
+// randomized map iteration. Items without a natural order are sorted by
+// their %v text, so that order is only deterministic when distinct items
+// format differently; pointer items in particular should be sorted with
+// Set.SortedFunc.
+package sets
@@ -0,0 +1,227 @@
+package sets
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Multiset counts occurrences of items, like the Counter type from the
+// maps chapter. Items with a count of zero are removed.
+type Multiset[T comparable] map[T]int
+
+// Entry pairs an item with its count.
+type Entry[T comparable] struct {
+	Item  T   `json:"item"`
+	Count int `json:"count"`
+}
+
+// NewMultiset creates a multiset counting items.
+func NewMultiset[T comparable](items ...T) Multiset[T] {
+	m := make(Multiset[T])
+	for _, item := range items {
+		m[item]++
+	}
+	return m
+}
+
+// Add increases the count of item by n. It panics if n is negative; use
+// Remove to decrease counts.
+func (m Multiset[T]) Add(item T, n int) {
+	if n < 0 {
+		panic("sets: Multiset.Add with negative count")
+	}
+	m.set(item, m[item]+n)
+}
+
+// Remove decreases the count of item by n, never below zero. It panics
+// if n is negative.
+func (m Multiset[T]) Remove(item T, n int) {
+	if n < 0 {
+		panic("sets: Multiset.Remove with negative count")
+	}
+	m.set(item, m[item]-n)
+}
+
+// Count returns the number of occurrences of item.
+func (m Multiset[T]) Count(item T) int {
+	return m[item]
+}
+
+// Len returns the number of distinct items.
+func (m Multiset[T]) Len() int {
+	return len(m)
+}
+
+// Total returns the sum of all counts.
+func (m Multiset[T]) Total() int {
+	total := 0
+	for _, n := range m {
+		total += n
+	}
+	return total
+}
+
+// Union keeps the larger count of each item.
+func (m Multiset[T]) Union(other Multiset[T]) Multiset[T] {
+	result := m.Clone()
+	for item, n := range other {
+		result[item] = max(result[item], n)
+	}
+	return result
+}
+
+// Intersection keeps the smaller count of each item.
+func (m Multiset[T]) Intersection(other Multiset[T]) Multiset[T] {
+	result := make(Multiset[T])
+	for item, n := range m {
+		result.set(item, min(n, other[item]))
+	}
+	return result
+}
+
+// Sum adds the counts of both multisets.
+func (m Multiset[T]) Sum(other Multiset[T]) Multiset[T] {
+	result := m.Clone()
+	for item, n := range other {
+		result.Add(item, n)
+	}
+	return result
+}
+
+// Difference subtracts the counts of other, dropping items that reach zero.
+func (m Multiset[T]) Difference(other Multiset[T]) Multiset[T] {
+	result := m.Clone()
+	for item, n := range other {
+		result.Remove(item, n)
+	}
+	return result
+}
+
+// SymmetricDifference keeps the absolute difference of each count.
+func (m Multiset[T]) SymmetricDifference(other Multiset[T]) Multiset[T] {
+	result := make(Multiset[T])
+	for item, n := range m {
+		d := n - other[item]
+		if d < 0 {
+			d = -d
+		}
+		result.set(item, d)
+	}
+	for item, n := range other {
+		if _, ok := m[item]; !ok {
+			result[item] = n
+		}
+	}
+	return result
+}
+
+// IsSubset reports whether every count in m is at most the count in other.
+func (m Multiset[T]) IsSubset(other Multiset[T]) bool {
+	for item, n := range m {
+		if n > other[item] {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the multiset.
+func (m Multiset[T]) Clone() Multiset[T] {
+	c := make(Multiset[T], len(m))
+	for item, n := range m {
+		c[item] = n
+	}
+	return c
+}
+
+// Set returns the distinct items.
+func (m Multiset[T]) Set() Set[T] {
+	s := make(Set[T], len(m))
+	for item := range m {
+		s[item] = struct{}{}
+	}
+	return s
+}
+
+// MostCommon returns the n items with the highest counts. Ties are broken
+// by item order so the result is deterministic. A negative n returns all
+// items.
+func (m Multiset[T]) MostCommon(n int) []Entry[T] {
+	entries := m.Entries()
+	slices.SortStableFunc(entries, func(a, b Entry[T]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	if n >= 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// Entries returns all items with their counts in sorted item order.
+func (m Multiset[T]) Entries() []Entry[T] {
+	entries := make([]Entry[T], 0, len(m))
+	for _, item := range sortedKeys(m) {
+		entries = append(entries, Entry[T]{Item: item, Count: m[item]})
+	}
+	return entries
+}
+
+// All iterates over items and counts in unspecified order.
+func (m Multiset[T]) All() iter.Seq2[T, int] {
+	return func(yield func(T, int) bool) {
+		for item, n := range m {
+			if !yield(item, n) {
+				return
+			}
+		}
+	}
+}
+
+// String formats the multiset as {a:2, b:1} in sorted item order.
+func (m Multiset[T]) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, e := range m.Entries() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%v:%d", e.Item, e.Count)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// MarshalJSON encodes the multiset as a sorted array of entries.
+func (m Multiset[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Entries())
+}
+
+// UnmarshalJSON decodes an array of entries into the multiset.
+func (m *Multiset[T]) UnmarshalJSON(data []byte) error {
+	var entries []Entry[T]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	result := make(Multiset[T], len(entries))
+	for _, e := range entries {
+		if e.Count < 0 {
+			return fmt.Errorf("sets: negative count %d for %v", e.Count, e.Item)
+		}
+		result.Add(e.Item, e.Count)
+	}
+	*m = result
+	return nil
+}
+
+func (m Multiset[T]) set(item T, n int) {
+	if n <= 0 {
+		delete(m, item)
+		return
+	}
+	m[item] = n
+}
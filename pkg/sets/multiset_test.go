@@ -0,0 +1,107 @@
+package sets_test
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/sets"
+)
+
+func TestMultisetSymmetricDifference(t *testing.T) {
+	tests := []struct {
+		a, b, want sets.Multiset[string]
+	}{
+		{sets.NewMultiset("a"), sets.NewMultiset("a", "a", "a"), sets.Multiset[string]{"a": 2}},
+		{sets.NewMultiset("a", "a", "a"), sets.NewMultiset("a"), sets.Multiset[string]{"a": 2}},
+		{sets.NewMultiset("a", "b"), sets.NewMultiset("a", "c", "c"), sets.Multiset[string]{"b": 1, "c": 2}},
+		{sets.NewMultiset("a", "a"), sets.NewMultiset("a", "a"), sets.Multiset[string]{}},
+	}
+	for _, tt := range tests {
+		if got := tt.a.SymmetricDifference(tt.b); !maps.Equal(got, tt.want) {
+			t.Errorf("%v.SymmetricDifference(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMultisetCounts(t *testing.T) {
+	m := sets.NewMultiset("a", "b", "a")
+	m.Add("c", 3)
+	m.Remove("a", 1)
+	m.Remove("b", 5)
+	if want := (sets.Multiset[string]{"a": 1, "c": 3}); !maps.Equal(m, want) {
+		t.Fatalf("counts = %v, want %v", m, want)
+	}
+	if m.Total() != 4 || m.Len() != 2 || m.Count("b") != 0 {
+		t.Fatalf("Total, Len, Count(b) = %d, %d, %d", m.Total(), m.Len(), m.Count("b"))
+	}
+
+	for name, f := range map[string]func(){
+		"Add":    func() { m.Add("a", -1) },
+		"Remove": func() { m.Remove("a", -1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s with a negative count did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestMultisetOps(t *testing.T) {
+	a := sets.Multiset[string]{"x": 3, "y": 1}
+	b := sets.Multiset[string]{"x": 1, "z": 2}
+	tests := []struct {
+		name      string
+		got, want sets.Multiset[string]
+	}{
+		{"Union", a.Union(b), sets.Multiset[string]{"x": 3, "y": 1, "z": 2}},
+		{"Intersection", a.Intersection(b), sets.Multiset[string]{"x": 1}},
+		{"Sum", a.Sum(b), sets.Multiset[string]{"x": 4, "y": 1, "z": 2}},
+		{"Difference", a.Difference(b), sets.Multiset[string]{"x": 2, "y": 1}},
+	}
+	for _, tt := range tests {
+		if !maps.Equal(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if !a.Intersection(b).IsSubset(a) || a.IsSubset(b) {
+		t.Error("IsSubset")
+	}
+}
+
+func TestMultisetMostCommon(t *testing.T) {
+	m := sets.Multiset[string]{"d": 2, "a": 1, "c": 2, "b": 5}
+	want := []sets.Entry[string]{{"b", 5}, {"c", 2}, {"d", 2}}
+	if got := m.MostCommon(3); !slices.Equal(got, want) {
+		t.Fatalf("MostCommon(3) = %v, want %v", got, want)
+	}
+	if got := m.MostCommon(-1); len(got) != 4 || got[3].Item != "a" {
+		t.Fatalf("MostCommon(-1) = %v", got)
+	}
+}
+
+func TestMultisetStringAndJSON(t *testing.T) {
+	m := sets.Multiset[int]{10: 1, 2: 3, -1: 2}
+	if got := m.String(); got != "{-1:2, 2:3, 10:1}" {
+		t.Fatalf("String = %q", got)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"item":-1,"count":2},{"item":2,"count":3},{"item":10,"count":1}]`; string(data) != want {
+		t.Fatalf("Marshal = %s, want %s", data, want)
+	}
+	var back sets.Multiset[int]
+	if err := json.Unmarshal(data, &back); err != nil || !maps.Equal(back, m) {
+		t.Fatalf("round trip = %v, %v", back, err)
+	}
+	if err := json.Unmarshal([]byte(`[{"item":1,"count":-2}]`), &back); err == nil {
+		t.Fatal("negative count decoded without error")
+	}
+}
@@ -0,0 +1,51 @@
+package sets
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// compare orders arbitrary comparable values. Numbers and strings use
+// their natural order; bools sort false first; everything else falls
+// back to comparing the %v representation, which is stable for a given
+// value.
+//
+// Distinct values can format alike, such as pointers to equal structs or
+// structs that differ only in unexported fields. Their relative order is
+// then unspecified; use SortedFunc to order such items.
+func compare[T comparable](a, b T) int {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.IsValid() && vb.IsValid() && va.Kind() == vb.Kind() {
+		switch va.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp.Compare(va.Int(), vb.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return cmp.Compare(va.Uint(), vb.Uint())
+		case reflect.Float32, reflect.Float64:
+			return cmp.Compare(va.Float(), vb.Float())
+		case reflect.String:
+			return cmp.Compare(va.String(), vb.String())
+		case reflect.Bool:
+			switch {
+			case va.Bool() == vb.Bool():
+				return 0
+			case !va.Bool():
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	return cmp.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+}
+
+func sortedKeys[T comparable, V any](m map[T]V) []T {
+	keys := make([]T, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, compare[T])
+	return keys
+}
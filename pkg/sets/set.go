@@ -0,0 +1,203 @@
+package sets
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Set is an unordered collection of unique items.
+// The zero value is not usable; create sets with New or Of.
+type Set[T comparable] map[T]struct{}
+
+// New creates an empty set.
+func New[T comparable]() Set[T] {
+	return make(Set[T])
+}
+
+// Of creates a set containing items.
+func Of[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+	return s
+}
+
+// Collect builds a set from a sequence.
+func Collect[T comparable](seq iter.Seq[T]) Set[T] {
+	s := New[T]()
+	for item := range seq {
+		s[item] = struct{}{}
+	}
+	return s
+}
+
+// Add inserts items into the set.
+func (s Set[T]) Add(items ...T) {
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+}
+
+// Remove deletes items from the set.
+func (s Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(s, item)
+	}
+}
+
+// Contains reports whether item is in the set.
+func (s Set[T]) Contains(item T) bool {
+	_, ok := s[item]
+	return ok
+}
+
+// Len returns the number of items.
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Clone returns a shallow copy of the set.
+func (s Set[T]) Clone() Set[T] {
+	c := make(Set[T], len(s))
+	for item := range s {
+		c[item] = struct{}{}
+	}
+	return c
+}
+
+// Union returns the items in s or other.
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	result := s.Clone()
+	for item := range other {
+		result[item] = struct{}{}
+	}
+	return result
+}
+
+// Intersection returns the items in both s and other.
+func (s Set[T]) Intersection(other Set[T]) Set[T] {
+	small, large := s, other
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+
+	result := New[T]()
+	for item := range small {
+		if large.Contains(item) {
+			result[item] = struct{}{}
+		}
+	}
+	return result
+}
+
+// Difference returns the items in s that are not in other.
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	result := New[T]()
+	for item := range s {
+		if !other.Contains(item) {
+			result[item] = struct{}{}
+		}
+	}
+	return result
+}
+
+// SymmetricDifference returns the items in exactly one of s and other.
+func (s Set[T]) SymmetricDifference(other Set[T]) Set[T] {
+	result := s.Difference(other)
+	for item := range other {
+		if !s.Contains(item) {
+			result[item] = struct{}{}
+		}
+	}
+	return result
+}
+
+// IsSubset reports whether every item of s is in other.
+func (s Set[T]) IsSubset(other Set[T]) bool {
+	if len(s) > len(other) {
+		return false
+	}
+	for item := range s {
+		if !other.Contains(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSuperset reports whether every item of other is in s.
+func (s Set[T]) IsSuperset(other Set[T]) bool {
+	return other.IsSubset(s)
+}
+
+// IsDisjoint reports whether s and other share no items.
+func (s Set[T]) IsDisjoint(other Set[T]) bool {
+	return s.Intersection(other).Len() == 0
+}
+
+// Equal reports whether s and other contain the same items.
+func (s Set[T]) Equal(other Set[T]) bool {
+	return len(s) == len(other) && s.IsSubset(other)
+}
+
+// All iterates over the items in unspecified order.
+func (s Set[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for item := range s {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Sorted returns the items in a deterministic order: numbers, strings
+// and bools in their natural order and other items by their %v text.
+// Items whose text is equal, such as pointers to equal values, are not
+// ordered among themselves; use SortedFunc for those.
+func (s Set[T]) Sorted() []T {
+	return sortedKeys(s)
+}
+
+// SortedFunc returns the items ordered by cmp.
+func (s Set[T]) SortedFunc(cmp func(a, b T) int) []T {
+	items := make([]T, 0, len(s))
+	for item := range s {
+		items = append(items, item)
+	}
+	slices.SortFunc(items, cmp)
+	return items
+}
+
+// String formats the set as {a, b, c} in sorted order.
+func (s Set[T]) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, item := range s.Sorted() {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%v", item)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// MarshalJSON encodes the set as a sorted JSON array.
+func (s Set[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Sorted())
+}
+
+// UnmarshalJSON decodes a JSON array into the set.
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*s = Of(items...)
+	return nil
+}
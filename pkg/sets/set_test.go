@@ -0,0 +1,100 @@
+package sets_test
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/sets"
+)
+
+func TestSetOps(t *testing.T) {
+	a := sets.Of(1, 2, 3)
+	b := sets.Of(3, 4)
+	tests := []struct {
+		name      string
+		got, want sets.Set[int]
+	}{
+		{"Union", a.Union(b), sets.Of(1, 2, 3, 4)},
+		{"Intersection", a.Intersection(b), sets.Of(3)},
+		{"Difference", a.Difference(b), sets.Of(1, 2)},
+		{"SymmetricDifference", a.SymmetricDifference(b), sets.Of(1, 2, 4)},
+		{"SymmetricDifference reversed", b.SymmetricDifference(a), sets.Of(1, 2, 4)},
+	}
+	for _, tt := range tests {
+		if !tt.got.Equal(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	switch {
+	case !sets.Of(1, 2).IsSubset(a), a.IsSubset(b):
+		t.Error("IsSubset")
+	case !a.IsSuperset(sets.Of(2)):
+		t.Error("IsSuperset")
+	case !a.IsDisjoint(sets.Of(7)), a.IsDisjoint(b):
+		t.Error("IsDisjoint")
+	}
+
+	c := a.Clone()
+	c.Remove(1)
+	c.Add(9)
+	if !a.Contains(1) || a.Contains(9) || c.Len() != 3 {
+		t.Fatalf("Clone shares storage: a = %v, c = %v", a, c)
+	}
+}
+
+func TestSetString(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{sets.Of(10, 2, -1).String(), "{-1, 2, 10}"},
+		{sets.Of("b", "a", "c").String(), "{a, b, c}"},
+		{sets.Of(true, false).String(), "{false, true}"},
+		{sets.Of(2.5, -0.5).String(), "{-0.5, 2.5}"},
+		{sets.New[int]().String(), "{}"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("String = %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestSetJSON(t *testing.T) {
+	s := sets.Of("pear", "apple", "fig")
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["apple","fig","pear"]` {
+		t.Fatalf("Marshal = %s", data)
+	}
+	var back sets.Set[string]
+	if err := json.Unmarshal(data, &back); err != nil || !back.Equal(s) {
+		t.Fatalf("round trip = %v, %v", back, err)
+	}
+}
+
+func TestSortedFunc(t *testing.T) {
+	type point struct{ x, y int }
+	p, q := &point{1, 2}, &point{1, 2}
+	s := sets.Of(p, q)
+	byAddr := func(a, b *point) int {
+		switch {
+		case a == b:
+			return 0
+		case a == p:
+			return -1
+		default:
+			return 1
+		}
+	}
+	if got := s.SortedFunc(byAddr); !slices.Equal(got, []*point{p, q}) {
+		t.Fatalf("SortedFunc = %v", got)
+	}
+	if got := sets.Of(3, 1, 2).SortedFunc(func(a, b int) int { return cmp.Compare(b, a) }); !slices.Equal(got, []int{3, 2, 1}) {
+		t.Fatalf("SortedFunc descending = %v", got)
+	}
+}
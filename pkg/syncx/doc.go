@@ -0,0 +1,19 @@
+// Package syncx provides typed coordination primitives that build on the
+// synchronization chapter's Batch and SafeSingleton sketches:
+//
+//   - Group de-duplicates concurrent calls for the same key
+//     (singleflight), with per-caller cancellation and Forget.
+//   - Loader collects individual Load calls made within a short window
+//     into one bulk call, the DataLoader pattern.
+//   - KeyedMutex serialises work per key without keeping a lock for
+//     every key ever seen.
+//
+// A Loader turns N point lookups issued by independent goroutines into a
+// single query:
+//
+//	users := syncx.NewLoader(func(ctx context.Context, ids []int) (map[int]*User, error) {
+//	    return db.UsersByID(ctx, ids) // SELECT ... WHERE id IN (...)
+//	}, syncx.WithWait(2*time.Millisecond))
+//
+//	u, err := users.Load(ctx, 42)
+package syncx
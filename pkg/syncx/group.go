@@ -0,0 +1,132 @@
+package syncx
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError is returned to every caller waiting on a function that
+// panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("syncx: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Result is what DoChan delivers.
+type Result[V any] struct {
+	Val    V
+	Err    error
+	Shared bool // the value was delivered to more than one caller
+}
+
+type call[V any] struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	dups    int
+	val     V
+	err     error
+}
+
+// Group de-duplicates concurrent calls keyed by K. The zero value is
+// ready to use.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// Do runs fn once for all callers that ask for key while a call is in
+// flight and returns its result to each of them.
+//
+// fn runs in its own goroutine with a context that keeps the first
+// caller's values but not its cancellation. A caller whose ctx ends
+// returns ctx.Err() at once; when every caller has gone, fn's context is
+// cancelled and the key is forgotten so the next caller starts afresh.
+func (g *Group[K, V]) Do(ctx context.Context, key K, fn func(context.Context) (V, error)) (v V, err error, shared bool) {
+	c := g.join(ctx, key, fn)
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		shared = c.dups > 0
+		g.mu.Unlock()
+		return c.val, c.err, shared
+	case <-ctx.Done():
+		g.leave(key, c)
+		var zero V
+		return zero, ctx.Err(), false
+	}
+}
+
+// DoChan is like Do but delivers the result on a channel, which receives
+// exactly one value. The channel receives ctx.Err() if ctx ends first.
+func (g *Group[K, V]) DoChan(ctx context.Context, key K, fn func(context.Context) (V, error)) <-chan Result[V] {
+	ch := make(chan Result[V], 1)
+	go func() {
+		v, err, shared := g.Do(ctx, key, fn)
+		ch <- Result[V]{Val: v, Err: err, Shared: shared}
+	}()
+	return ch
+}
+
+// Forget makes the next Do for key start a new call even if one is in
+// flight. Callers already waiting still receive the old call's result.
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.calls, key)
+}
+
+func (g *Group[K, V]) join(ctx context.Context, key K, fn func(context.Context) (V, error)) *call[V] {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.calls[key]; ok {
+		c.waiters++
+		c.dups++
+		return c
+	}
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &call[V]{done: make(chan struct{}), cancel: cancel, waiters: 1}
+	g.calls[key] = c
+	go g.run(callCtx, key, c, fn)
+	return c
+}
+
+func (g *Group[K, V]) run(ctx context.Context, key K, c *call[V], fn func(context.Context) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+		c.cancel()
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = fn(ctx)
+}
+
+// leave drops a caller that gave up, cancelling the call when it was the
+// last one.
+func (g *Group[K, V]) leave(key K, c *call[V]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.cancel()
+}
@@ -0,0 +1,90 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+)
+
+// KeyedMutex is a set of mutexes indexed by key. A key's lock exists only
+// while someone holds or waits for it, so unbounded key spaces do not
+// grow memory. The zero value is ready to use.
+type KeyedMutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyLock
+}
+
+type keyLock struct {
+	ch   chan struct{} // holds a token while locked
+	refs int           // holders and waiters
+}
+
+// Lock locks key and returns the function that unlocks it.
+func (m *KeyedMutex[K]) Lock(key K) (unlock func()) {
+	unlock, _ = m.LockContext(context.Background(), key)
+	return unlock
+}
+
+// LockContext is like Lock but gives up with ctx.Err() when ctx ends
+// first.
+func (m *KeyedMutex[K]) LockContext(ctx context.Context, key K) (unlock func(), err error) {
+	l := m.ref(key)
+	select {
+	case l.ch <- struct{}{}:
+		return m.unlocker(key, l), nil
+	case <-ctx.Done():
+		m.unref(key, l)
+		return nil, ctx.Err()
+	}
+}
+
+// TryLock locks key if it is free.
+func (m *KeyedMutex[K]) TryLock(key K) (unlock func(), ok bool) {
+	l := m.ref(key)
+	select {
+	case l.ch <- struct{}{}:
+		return m.unlocker(key, l), true
+	default:
+		m.unref(key, l)
+		return nil, false
+	}
+}
+
+// Len returns the number of keys currently locked or waited for.
+func (m *KeyedMutex[K]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.locks)
+}
+
+func (m *KeyedMutex[K]) ref(key K) *keyLock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks == nil {
+		m.locks = make(map[K]*keyLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyLock{ch: make(chan struct{}, 1)}
+		m.locks[key] = l
+	}
+	l.refs++
+	return l
+}
+
+func (m *KeyedMutex[K]) unref(key K, l *keyLock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if l.refs--; l.refs == 0 {
+		delete(m.locks, key)
+	}
+}
+
+func (m *KeyedMutex[K]) unlocker(key K, l *keyLock) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-l.ch
+			m.unref(key, l)
+		})
+	}
+}
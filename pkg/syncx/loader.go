@@ -0,0 +1,240 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+// ErrNotFound is returned by Load for keys missing from the batch
+// function's result.
+var ErrNotFound = errors.New("syncx: key not found")
+
+// BatchFunc loads many keys at once. Keys are unique within a call.
+// Missing keys are reported to their callers as ErrNotFound; an error
+// fails every key in the batch.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+type options struct {
+	wait     time.Duration
+	maxBatch int
+	cache    bool
+	clock    clock.Clock
+}
+
+// Option configures a Loader.
+type Option func(*options)
+
+// WithWait sets how long a batch collects keys after its first Load. The
+// default is 1ms; longer windows make bigger batches at the cost of
+// latency.
+func WithWait(d time.Duration) Option {
+	return func(o *options) {
+		o.wait = d
+	}
+}
+
+// WithMaxBatch dispatches a batch as soon as it holds n keys. The
+// default is 100; zero means no limit.
+func WithMaxBatch(n int) Option {
+	return func(o *options) {
+		o.maxBatch = n
+	}
+}
+
+// WithCache memoises successful results until Clear. As in DataLoader,
+// a Loader with a cache is meant to live for one request so it never
+// serves stale data for long.
+func WithCache() Option {
+	return func(o *options) {
+		o.cache = true
+	}
+}
+
+// WithClock sets the clock that times batch windows. The default is
+// clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// Loader batches Load calls into BatchFunc calls. It is safe for
+// concurrent use.
+type Loader[K comparable, V any] struct {
+	fn BatchFunc[K, V]
+	o  options
+
+	mu      sync.Mutex
+	pending *batch[K, V]
+	cache   map[K]V
+}
+
+type batch[K comparable, V any] struct {
+	ctx     context.Context // values of the first caller, without its cancellation
+	keys    []K
+	seen    map[K]struct{}
+	timer   clock.Timer
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+// NewLoader returns a Loader that calls fn.
+func NewLoader[K comparable, V any](fn BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	o := options{wait: time.Millisecond, maxBatch: 100, clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	l := &Loader[K, V]{fn: fn, o: o}
+	if o.cache {
+		l.cache = make(map[K]V)
+	}
+	return l
+}
+
+// Load returns the value for key, batched with other keys loaded within
+// the wait window. If ctx ends first Load returns ctx.Err(); the batch
+// still runs for the other callers.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	if v, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return v, nil
+	}
+	b := l.add(ctx, key)
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		if b.err != nil {
+			var zero V
+			return zero, b.err
+		}
+		v, ok := b.results[key]
+		if !ok {
+			return v, ErrNotFound
+		}
+		return v, nil
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadAll loads keys, batched together, and returns their values in the
+// same order. It fails with the first error encountered.
+func (l *Loader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, error) {
+	type result struct {
+		v   V
+		err error
+	}
+	results := make([]chan result, len(keys))
+	for i, key := range keys {
+		results[i] = make(chan result, 1)
+		go func() {
+			v, err := l.Load(ctx, key)
+			results[i] <- result{v, err}
+		}()
+	}
+	values := make([]V, len(keys))
+	var firstErr error
+	for i, ch := range results {
+		r := <-ch
+		values[i] = r.v
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return values, nil
+}
+
+// Prime adds a value to the cache without loading it. It does nothing
+// without WithCache.
+func (l *Loader[K, V]) Prime(key K, v V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cache != nil {
+		l.cache[key] = v
+	}
+}
+
+// Clear drops key from the cache.
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// ClearAll empties the cache.
+func (l *Loader[K, V]) ClearAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.cache)
+}
+
+// add puts key in the pending batch, starting one if needed. It must be
+// called with l.mu held.
+func (l *Loader[K, V]) add(ctx context.Context, key K) *batch[K, V] {
+	b := l.pending
+	if b == nil {
+		b = &batch[K, V]{
+			ctx:  context.WithoutCancel(ctx),
+			seen: make(map[K]struct{}),
+			done: make(chan struct{}),
+		}
+		l.pending = b
+		// The callback may run synchronously under a fake clock, while
+		// l.mu is held, so it must not take the lock itself.
+		b.timer = l.o.clock.AfterFunc(l.o.wait, func() { go l.dispatch(b) })
+	}
+	if _, dup := b.seen[key]; !dup {
+		b.seen[key] = struct{}{}
+		b.keys = append(b.keys, key)
+	}
+	if l.o.maxBatch > 0 && len(b.keys) >= l.o.maxBatch {
+		b.timer.Stop()
+		l.pending = nil
+		go l.run(b)
+	}
+	return b
+}
+
+// dispatch runs b when its window closes, unless it already ran because
+// it filled up.
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+	l.run(b)
+}
+
+func (l *Loader[K, V]) run(b *batch[K, V]) {
+	defer close(b.done)
+	defer func() {
+		if r := recover(); r != nil {
+			b.err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	b.results, b.err = l.fn(b.ctx, b.keys)
+	if b.err == nil && l.cache != nil {
+		l.mu.Lock()
+		for k, v := range b.results {
+			if _, asked := b.seen[k]; asked {
+				l.cache[k] = v
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,383 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/clock"
+)
+
+// waitUntil polls cond until it holds, failing the test after a few
+// seconds.
+func waitUntil(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waiters returns how many callers are waiting on the call for key.
+func (g *Group[K, V]) waiters(key K) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.calls[key]; ok {
+		return c.waiters
+	}
+	return 0
+}
+
+// pendingKeys returns the keys collected by the open batch.
+func (l *Loader[K, V]) pendingKeys() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pending == nil {
+		return 0
+	}
+	return len(l.pending.keys)
+}
+
+func TestGroupDeduplicates(t *testing.T) {
+	var g Group[string, int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	const callers = 10
+	results := make(chan Result[int], callers)
+	for range callers {
+		go func() {
+			v, err, shared := g.Do(context.Background(), "k", fn)
+			results <- Result[int]{v, err, shared}
+		}()
+	}
+	waitUntil(t, "callers to join", func() bool { return g.waiters("k") == callers })
+	close(release)
+
+	for range callers {
+		r := <-results
+		if r.Val != 42 || r.Err != nil || !r.Shared {
+			t.Fatalf("Do = %+v, want shared 42", r)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("fn ran %d times, want 1", n)
+	}
+
+	// The key is forgotten once the call completes.
+	v, _, shared := g.Do(context.Background(), "k", func(context.Context) (int, error) { return 7, nil })
+	if v != 7 || shared {
+		t.Fatalf("Do after completion = %d, shared %v; want a fresh unshared call", v, shared)
+	}
+}
+
+func TestGroupForget(t *testing.T) {
+	var g Group[string, int]
+	release := make(chan struct{})
+	first := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+	waitUntil(t, "first call", func() bool { return g.waiters("k") == 1 })
+
+	g.Forget("k")
+	v, err, _ := g.Do(context.Background(), "k", func(context.Context) (int, error) { return 2, nil })
+	if v != 2 || err != nil {
+		t.Fatalf("Do after Forget = %d, %v; want a new call", v, err)
+	}
+
+	close(release)
+	if r := <-first; r.Val != 1 || r.Err != nil {
+		t.Fatalf("forgotten call delivered %+v, want 1", r)
+	}
+}
+
+func TestGroupLastWaiterCancels(t *testing.T) {
+	var g Group[string, int]
+	cancelled := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	r1 := g.DoChan(ctx1, "k", fn)
+	r2 := g.DoChan(ctx2, "k", fn)
+	waitUntil(t, "both callers", func() bool { return g.waiters("k") == 2 })
+
+	cancel1()
+	if r := <-r1; !errors.Is(r.Err, context.Canceled) {
+		t.Fatalf("first caller got %v, want context.Canceled", r.Err)
+	}
+	select {
+	case <-cancelled:
+		t.Fatal("fn cancelled while a caller was still waiting")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel2()
+	if r := <-r2; !errors.Is(r.Err, context.Canceled) {
+		t.Fatalf("second caller got %v, want context.Canceled", r.Err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fn not cancelled after the last caller left")
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	var g Group[string, int]
+	_, err, _ := g.Do(context.Background(), "k", func(context.Context) (int, error) {
+		panic("boom")
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Fatalf("Do = %v, want PanicError carrying boom and a stack", err)
+	}
+}
+
+// recordingBatch returns a BatchFunc that squares the keys it is given,
+// leaves out negative ones and records every batch.
+func recordingBatch() (BatchFunc[int, int], func() [][]int) {
+	var mu sync.Mutex
+	var batches [][]int
+	fn := func(_ context.Context, keys []int) (map[int]int, error) {
+		mu.Lock()
+		batches = append(batches, slices.Clone(keys))
+		mu.Unlock()
+		out := make(map[int]int, len(keys))
+		for _, k := range keys {
+			if k >= 0 {
+				out[k] = k * k
+			}
+		}
+		return out, nil
+	}
+	return fn, func() [][]int {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(batches)
+	}
+}
+
+func TestLoaderWindow(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	fn, batches := recordingBatch()
+	l := NewLoader(fn, WithWait(time.Millisecond), WithClock(clk))
+
+	type result struct {
+		key, v int
+		err    error
+	}
+	keys := []int{1, 2, 2, 3, -1}
+	results := make(chan result, len(keys))
+	for _, k := range keys {
+		go func() {
+			v, err := l.Load(context.Background(), k)
+			results <- result{k, v, err}
+		}()
+	}
+	// Duplicate keys share a slot, so four distinct keys fill the batch.
+	waitUntil(t, "keys to join the batch", func() bool { return l.pendingKeys() == 4 })
+	if got := len(batches()); got != 0 {
+		t.Fatalf("%d batches ran before the window closed", got)
+	}
+	clk.Advance(time.Millisecond)
+
+	for range keys {
+		r := <-results
+		switch {
+		case r.key < 0:
+			if !errors.Is(r.err, ErrNotFound) {
+				t.Errorf("Load(%d) = %v, want ErrNotFound", r.key, r.err)
+			}
+		case r.err != nil || r.v != r.key*r.key:
+			t.Errorf("Load(%d) = %d, %v", r.key, r.v, r.err)
+		}
+	}
+	if got := batches(); len(got) != 1 || len(got[0]) != 4 {
+		t.Fatalf("batches = %v, want one batch of 4 distinct keys", got)
+	}
+}
+
+func TestLoaderMaxBatch(t *testing.T) {
+	// The window never closes on a fake clock nobody advances, so only
+	// maxBatch can dispatch.
+	clk := clock.NewFake(time.Unix(0, 0))
+	fn, batches := recordingBatch()
+	l := NewLoader(fn, WithMaxBatch(3), WithClock(clk))
+
+	got, err := l.LoadAll(context.Background(), []int{1, 2, 3, 4, 5, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 4, 9, 16, 25, 36}; !slices.Equal(got, want) {
+		t.Fatalf("LoadAll = %v, want %v", got, want)
+	}
+	for _, b := range batches() {
+		if len(b) != 3 {
+			t.Fatalf("batches = %v, want batches of 3", batches())
+		}
+	}
+}
+
+func TestLoaderError(t *testing.T) {
+	failure := errors.New("db down")
+	l := NewLoader(func(context.Context, []int) (map[int]int, error) {
+		return nil, failure
+	}, WithWait(0))
+	if _, err := l.LoadAll(context.Background(), []int{1, 2}); !errors.Is(err, failure) {
+		t.Fatalf("LoadAll = %v, want %v", err, failure)
+	}
+}
+
+func TestLoaderCacheAndPrime(t *testing.T) {
+	fn, batches := recordingBatch()
+	l := NewLoader(fn, WithWait(0), WithCache())
+
+	l.Prime(10, -100)
+	if v, err := l.Load(context.Background(), 10); v != -100 || err != nil {
+		t.Fatalf("Load(primed) = %d, %v", v, err)
+	}
+	for range 2 {
+		if v, err := l.Load(context.Background(), 3); v != 9 || err != nil {
+			t.Fatalf("Load(3) = %d, %v", v, err)
+		}
+	}
+	if got := batches(); len(got) != 1 {
+		t.Fatalf("batches = %v, want one load for key 3 only", got)
+	}
+
+	l.Clear(3)
+	l.Load(context.Background(), 3)
+	if got := len(batches()); got != 2 {
+		t.Fatalf("Clear did not drop key 3: %d batches", got)
+	}
+
+	// Without a cache every Load reaches the batch function and Prime is
+	// a no-op.
+	fn, batches = recordingBatch()
+	l = NewLoader(fn, WithWait(0))
+	l.Prime(3, 0)
+	l.Load(context.Background(), 3)
+	l.Load(context.Background(), 3)
+	if got := len(batches()); got != 2 {
+		t.Fatalf("uncached loader ran %d batches, want 2", got)
+	}
+}
+
+func TestKeyedMutex(t *testing.T) {
+	var m KeyedMutex[int]
+	counters := make([]int, 4)
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := i % len(counters)
+			unlock := m.Lock(key)
+			defer unlock()
+			counters[key]++ // guarded by the key's lock; -race checks it
+		}()
+	}
+	wg.Wait()
+	for key, n := range counters {
+		if n != 25 {
+			t.Errorf("counter %d = %d, want 25", key, n)
+		}
+	}
+	if n := m.Len(); n != 0 {
+		t.Fatalf("Len = %d after every unlock, want 0", n)
+	}
+}
+
+func TestKeyedMutexLockContext(t *testing.T) {
+	var m KeyedMutex[string]
+	unlock := m.Lock("a")
+
+	// Other keys are independent.
+	unlockB, ok := m.TryLock("b")
+	if !ok {
+		t.Fatal("TryLock(b) failed while only a was held")
+	}
+	unlockB()
+	if _, ok := m.TryLock("a"); ok {
+		t.Fatal("TryLock(a) succeeded while a was held")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := m.LockContext(ctx, "a")
+		errc <- err
+	}()
+	waitUntil(t, "waiter", func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.locks["a"].refs == 2
+	})
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("LockContext = %v, want context.Canceled", err)
+	}
+
+	unlock()
+	unlock() // unlocking twice is harmless
+	if n := m.Len(); n != 0 {
+		t.Fatalf("Len = %d, want 0", n)
+	}
+}
+
+func BenchmarkGroupDo(b *testing.B) {
+	var g Group[int, int]
+	fn := func(context.Context) (int, error) { return 1, nil }
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			g.Do(context.Background(), i%8, fn)
+			i++
+		}
+	})
+}
+
+func BenchmarkLoader(b *testing.B) {
+	for _, maxBatch := range []int{1, 16, 128} {
+		b.Run(fmt.Sprintf("maxBatch=%d", maxBatch), func(b *testing.B) {
+			fn, _ := recordingBatch()
+			l := NewLoader(fn, WithMaxBatch(maxBatch))
+			var next atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Load(context.Background(), int(next.Add(1)))
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkKeyedMutex(b *testing.B) {
+	var m KeyedMutex[int]
+	var next atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			unlock := m.Lock(int(next.Add(1) % 64))
+			unlock()
+		}
+	})
+}
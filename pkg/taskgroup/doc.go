@@ -0,0 +1,23 @@
+// Package taskgroup runs related goroutines as a unit, replacing the bare
+// sync.WaitGroup of the README demo and the concurrency chapters with
+// error propagation, cancellation and limits.
+//
+// A Group cancels its context when a task fails, so sibling tasks can
+// stop early, and Wait returns the first error, or every error joined
+// with errors.Join under WithJoinErrors. Panics in tasks are recovered
+// and reported as *PanicError instead of crashing the process. SetLimit
+// bounds how many tasks run at once:
+//
+//	g, ctx := taskgroup.New(ctx)
+//	g.SetLimit(8)
+//	for _, url := range urls {
+//	    g.Go(func(ctx context.Context) error {
+//	        return fetch(ctx, url)
+//	    })
+//	}
+//	err := g.Wait()
+//
+// Semaphore is a weighted semaphore for sharing a budget, such as memory
+// or connections, between tasks of different sizes. Waiters are served
+// in FIFO order so a large request is not starved by small ones.
+package taskgroup
@@ -0,0 +1,158 @@
+package taskgroup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError reports a task that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("taskgroup: panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+type options struct {
+	join      bool
+	keepGoing bool
+}
+
+// Option configures a Group.
+type Option func(*options)
+
+// WithJoinErrors makes Wait return every task error joined with
+// errors.Join, in the order they occurred, instead of only the first.
+func WithJoinErrors() Option {
+	return func(o *options) {
+		o.join = true
+	}
+}
+
+// WithContinueOnError keeps the group's context alive when a task fails,
+// so the remaining tasks run to completion. It is usually combined with
+// WithJoinErrors.
+func WithContinueOnError() Option {
+	return func(o *options) {
+		o.keepGoing = true
+	}
+}
+
+// Group runs tasks in goroutines and waits for them. A Group must be
+// created with New and must not be reused after Wait returns.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	o      options
+	wg     sync.WaitGroup
+	sem    chan struct{}
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// New returns a Group and the context its tasks receive. The context is
+// cancelled when a task fails, unless WithContinueOnError is set, and
+// when Wait returns.
+func New(ctx context.Context, opts ...Option) (*Group, context.Context) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{ctx: ctx, cancel: cancel, o: o}, ctx
+}
+
+// SetLimit limits the number of tasks running at once to n; a negative n
+// removes the limit. It panics if tasks are running.
+func (g *Group) SetLimit(n int) {
+	if g.sem != nil && len(g.sem) != 0 {
+		panic(fmt.Sprintf("taskgroup: SetLimit called with %d tasks running", len(g.sem)))
+	}
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs fn in a new goroutine, first waiting for a free slot if the
+// group is limited.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(fn)
+}
+
+// TryGo runs fn only if a slot is free and reports whether it did.
+func (g *Group) TryGo(fn func(ctx context.Context) error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(fn)
+	return true
+}
+
+// Wait blocks until all tasks have returned, cancels the context and
+// returns the first task error, or all of them under WithJoinErrors.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) == 0 {
+		return nil
+	}
+	if g.o.join {
+		return errors.Join(g.errs...)
+	}
+	return g.errs[0]
+}
+
+func (g *Group) start(fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		if err := g.run(fn); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *Group) run(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(g.ctx)
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	g.errs = append(g.errs, err)
+	g.mu.Unlock()
+	if !g.o.keepGoing {
+		g.cancel(err)
+	}
+}
@@ -0,0 +1,115 @@
+package taskgroup
+
+import (
+	"context"
+	"sync"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/containers"
+)
+
+// Semaphore is a weighted semaphore. It is safe for concurrent use.
+type Semaphore struct {
+	size int64
+
+	mu      sync.Mutex
+	cur     int64
+	waiters containers.Queue[*semWaiter]
+	waiting int // waiters that have not given up
+}
+
+type semWaiter struct {
+	n     int64
+	ready chan struct{}
+	gone  bool
+}
+
+// NewSemaphore returns a semaphore with a total weight of size.
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Acquire takes n from the semaphore, blocking until it is available or
+// ctx ends. Requests are granted in FIFO order. On failure it returns
+// ctx.Err() and takes nothing.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	s.mu.Lock()
+	if s.waiting == 0 && s.cur+n <= s.size {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	if n > s.size {
+		// Can never succeed; wait for ctx without blocking others.
+		s.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	w := &semWaiter{n: n, ready: make(chan struct{})}
+	s.waiters.Enqueue(w)
+	s.waiting++
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// Granted while giving up; give it back.
+			s.cur -= n
+		default:
+			w.gone = true
+			s.waiting--
+		}
+		// Either way the head of the queue may now fit.
+		s.notify()
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes n without blocking and reports whether it did.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.waiting == 0 && s.cur+n <= s.size {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release returns n to the semaphore. It panics if more is released than
+// was acquired.
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("taskgroup: semaphore released more than held")
+	}
+	s.notify()
+}
+
+// notify grants waiters in order while they fit. It must be called with
+// s.mu held.
+func (s *Semaphore) notify() {
+	for {
+		w, ok := s.waiters.Peek()
+		if !ok {
+			return
+		}
+		if w.gone {
+			s.waiters.Dequeue()
+			continue
+		}
+		if s.cur+w.n > s.size {
+			return
+		}
+		s.cur += w.n
+		s.waiting--
+		s.waiters.Dequeue()
+		close(w.ready)
+	}
+}
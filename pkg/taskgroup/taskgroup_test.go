@@ -0,0 +1,230 @@
+package taskgroup_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thanhnamdk2710/go-handbook/pkg/taskgroup"
+)
+
+func TestFirstErrorCancels(t *testing.T) {
+	errFirst := errors.New("first")
+	g, ctx := taskgroup.New(context.Background())
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return errors.New("second, after cancellation")
+	})
+	g.Go(func(context.Context) error { return errFirst })
+
+	if err := g.Wait(); err != errFirst {
+		t.Fatalf("Wait = %v, want the first error", err)
+	}
+	if cause := context.Cause(ctx); cause != errFirst {
+		t.Fatalf("context cause = %v, want the failing task's error", cause)
+	}
+}
+
+func TestWaitCancelsContext(t *testing.T) {
+	g, ctx := taskgroup.New(context.Background())
+	g.Go(func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			t.Error("context cancelled while tasks run")
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("context after Wait = %v, want cancelled", ctx.Err())
+	}
+}
+
+func TestSetLimit(t *testing.T) {
+	g, _ := taskgroup.New(context.Background())
+	g.SetLimit(2)
+
+	started, release := make(chan struct{}, 6), make(chan struct{})
+	var running, peak atomic.Int32
+	task := func(context.Context) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		started <- struct{}{}
+		<-release
+		running.Add(-1)
+		return nil
+	}
+
+	g.Go(task)
+	g.Go(task)
+	<-started
+	<-started
+	if g.TryGo(task) {
+		t.Fatal("TryGo started a task over the limit")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("SetLimit with tasks running did not panic")
+			}
+		}()
+		g.SetLimit(3)
+	}()
+
+	// Go blocks for a slot rather than failing.
+	queued := make(chan struct{})
+	go func() {
+		for range 4 {
+			g.Go(task)
+		}
+		close(queued)
+	}()
+	close(release)
+	<-queued
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Fatalf("peak concurrency %d, want 2", p)
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	g, ctx := taskgroup.New(context.Background(), taskgroup.WithJoinErrors(), taskgroup.WithContinueOnError())
+	done := make(chan struct{})
+	g.Go(func(context.Context) error { return errA })
+	g.Go(func(context.Context) error {
+		<-done
+		return errB
+	})
+	g.Go(func(ctx context.Context) error {
+		// Keep running after errA.
+		time.Sleep(10 * time.Millisecond)
+		if ctx.Err() != nil {
+			t.Error("WithContinueOnError cancelled the context")
+		}
+		close(done)
+		return nil
+	})
+
+	err := g.Wait()
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("Wait = %v, want both errors", err)
+	}
+	if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != 2 || errs[0] != errA {
+		t.Fatalf("joined %v, want errors in the order they occurred", errs)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("context alive after Wait")
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	errCause := errors.New("cause")
+	for _, value := range []any{"boom", errCause} {
+		g, _ := taskgroup.New(context.Background())
+		g.Go(func(context.Context) error { panic(value) })
+
+		err := g.Wait()
+		var pe *taskgroup.PanicError
+		if !errors.As(err, &pe) || pe.Value != value {
+			t.Fatalf("Wait = %v, want a PanicError of %v", err, value)
+		}
+		if !strings.Contains(string(pe.Stack), "taskgroup_test.TestPanicError") {
+			t.Errorf("stack does not show the panicking task:\n%s", pe.Stack)
+		}
+		if isErr := value == errCause; errors.Is(err, errCause) != isErr {
+			t.Errorf("errors.Is(%v, cause) = %v, want %v", err, !isErr, isErr)
+		}
+	}
+}
+
+func TestSemaphoreFIFO(t *testing.T) {
+	sem := taskgroup.NewSemaphore(10)
+	if !sem.TryAcquire(10) {
+		t.Fatal("TryAcquire on an empty semaphore failed")
+	}
+
+	order := make(chan int64, 2)
+	for _, n := range []int64{8, 1} {
+		go func() {
+			if err := sem.Acquire(context.Background(), n); err != nil {
+				t.Error(err)
+			}
+			order <- n
+		}()
+		// Let each waiter queue before the next one.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The small request fits but must not overtake the large one.
+	sem.Release(2)
+	if sem.TryAcquire(1) {
+		t.Fatal("TryAcquire jumped the queue")
+	}
+	select {
+	case n := <-order:
+		t.Fatalf("Acquire(%d) granted ahead of the queue", n)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	sem.Release(8)
+	if a, b := <-order, <-order; a+b != 9 {
+		t.Fatalf("granted %d and %d, want both waiters", a, b)
+	}
+	sem.Release(9)
+	if !sem.TryAcquire(10) {
+		t.Fatal("weight lost")
+	}
+}
+
+func TestSemaphoreCancel(t *testing.T) {
+	sem := taskgroup.NewSemaphore(10)
+	sem.TryAcquire(5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() { cancelled <- sem.Acquire(ctx, 10) }()
+	time.Sleep(10 * time.Millisecond)
+	granted := make(chan error, 1)
+	go func() { granted <- sem.Acquire(context.Background(), 5) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// Giving up at the head of the queue lets the waiter behind it in.
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Acquire = %v", err)
+	}
+	if err := <-granted; err != nil {
+		t.Fatalf("Acquire behind a cancelled waiter = %v", err)
+	}
+	sem.Release(10)
+
+	// A request larger than the semaphore waits for ctx without
+	// blocking others.
+	tctx, tcancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer tcancel()
+	if err := sem.Acquire(tctx, 11); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire over the size = %v", err)
+	}
+	if !sem.TryAcquire(10) {
+		t.Fatal("oversized request blocked the semaphore")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("releasing more than held did not panic")
+		}
+	}()
+	sem.Release(11)
+}